@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -19,6 +20,8 @@ import (
 	"github.com/finchley-foodbank/foodbank/internal/email"
 	"github.com/finchley-foodbank/foodbank/internal/handler"
 	"github.com/finchley-foodbank/foodbank/internal/handler/middleware"
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/redact"
 	"github.com/finchley-foodbank/foodbank/internal/repository"
 	"github.com/finchley-foodbank/foodbank/internal/service"
 )
@@ -31,6 +34,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	redact.SetEnabled(cfg.LogRedact)
 
 	// Connect to database
 	db, err := database.Connect(ctx, cfg.DatabaseURL)
@@ -52,6 +56,12 @@ func main() {
 			cfg.Auth0M2MClientSecret,
 			cfg.Auth0ConnectionID,
 		)
+		auth0Client.ConfigureResilience(
+			cfg.Auth0MaxRetries,
+			cfg.Auth0BreakerThreshold,
+			time.Duration(cfg.Auth0BreakerCooldownSeconds)*time.Second,
+		)
+		auth0Client.SetSendAppMetadata(cfg.Auth0SendAppMetadata)
 		log.Println("Auth0 Management API client configured")
 	} else {
 		log.Println("Warning: Auth0 Management API not configured (staff invitation disabled)")
@@ -59,24 +69,39 @@ func main() {
 
 	// Create email service (Resend)
 	emailService := email.NewService(cfg.ResendAPIKey, cfg.FromEmail, cfg.FromName, cfg.AppBaseURL)
+	emailService.SetSender(email.MessageVerification, cfg.FromEmailSecurity, cfg.FromNameSecurity)
+	emailService.SetSender(email.MessageAdminNotification, cfg.FromEmailAdmin, cfg.FromNameAdmin)
 	if emailService.IsConfigured() {
 		log.Println("Email service configured")
 	} else {
-		log.Println("Warning: Email service not configured (admin notifications disabled)")
+		log.Println("Warning: Email service not configured - registration notifications disabled; new requests will only appear in the admin dashboard")
 	}
 
 	// Create router
 	r := chi.NewRouter()
 
 	// Middleware
-	r.Use(chimiddleware.Logger)
-	r.Use(chimiddleware.Recoverer)
 	r.Use(chimiddleware.RequestID)
+	if cfg.AccessLogJSON {
+		logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+		r.Use(middleware.JSONAccessLog(logger, middleware.ParseAccessLogSampling(cfg.AccessLogSample)))
+	} else {
+		r.Use(chimiddleware.Logger)
+	}
+	r.Use(chimiddleware.Recoverer)
+	r.Use(middleware.NoStore)
+	r.Use(middleware.LimitBody(cfg.MaxBodyBytes, map[string]int64{
+		"/api/admin/restore":         cfg.MaxLargeBodyBytes,
+		"/api/admin/backup/validate": cfg.MaxLargeBodyBytes,
+		"/api/admin/import/validate": cfg.MaxLargeBodyBytes,
+		"/api/admin/import/upload":   cfg.MaxLargeBodyBytes,
+		"/api/admin/import/clients":  cfg.MaxLargeBodyBytes,
+	}))
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:5173", "http://localhost:3000", "https://foodbank-web.fly.dev"},
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "X-Total-Count"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -84,40 +109,79 @@ func main() {
 	// Repositories
 	staffRepo := repository.NewStaffRepository(db)
 	clientRepo := repository.NewClientRepository(db)
+	clientRepo.SetStatementTimeout(time.Duration(cfg.DBStatementTimeoutMS) * time.Millisecond)
 	auditRepo := repository.NewAuditRepository(db)
+	auditRepo.SetDiffTables(cfg.AuditDiffTables)
 	registrationRequestRepo := repository.NewRegistrationRequestRepository(db)
 	verificationRepo := repository.NewVerificationRepository(db)
+	emailLogRepo := repository.NewEmailLogRepository(db)
+	emailService.SetDeliveryLog(emailLogRepo)
 
 	// Services
-	staffService := service.NewStaffService(staffRepo, auth0Client)
-	clientService := service.NewClientService(clientRepo, auditRepo)
-	registrationRequestService := service.NewRegistrationRequestService(registrationRequestRepo, staffRepo, auth0Client, emailService)
+	staffService := service.NewStaffService(staffRepo, auditRepo, auth0Client)
+	staffService.SetRequireEmailVerification(cfg.RequireEmailVerification)
+	clientService := service.NewClientService(clientRepo, auditRepo, cfg.AppLocation)
+	clientService.SetAttendanceDedupWindow(time.Duration(cfg.AttendanceDedupSeconds) * time.Second)
+	clientService.SetAttendanceCooldown(time.Duration(cfg.AttendanceCooldownHours) * time.Hour)
+	clientService.SetRequireOverrideReason(cfg.AttendanceOverrideReasonRequired)
+	clientService.SetStrictDuplicates(cfg.ClientDuplicatesStrict)
+	registrationRequestService := service.NewRegistrationRequestService(registrationRequestRepo, staffRepo, auditRepo, auth0Client, emailService)
+	registrationRequestService.SetTokenTTL(time.Duration(cfg.RegistrationTokenTTLHours) * time.Hour)
 	verificationService := service.NewVerificationService(verificationRepo, staffRepo, emailService)
 	backupService := service.NewBackupService(db)
 	importService := service.NewImportService(db, clientRepo, auditRepo)
+	importService.SetWorkers(cfg.ImportWorkers)
+	importService.SetStrictDuplicates(cfg.ClientDuplicatesStrict)
+	maintenanceService := service.NewMaintenanceService(verificationRepo, registrationRequestRepo, emailLogRepo)
+	reportService := service.NewReportService(clientRepo, cfg.AppLocation)
+	statsService := service.NewStatsService(clientRepo, staffRepo, registrationRequestRepo)
 
 	// Handlers
-	healthHandler := handler.NewHealthHandler()
+	healthHandler := handler.NewHealthHandler(db, registrationRequestService)
 	staffHandler := handler.NewStaffHandler(staffService)
-	clientHandler := handler.NewClientHandler(clientService, staffService)
+	clientHandler := handler.NewClientHandler(clientService)
 	auditHandler := handler.NewAuditHandler(auditRepo)
 	registrationRequestHandler := handler.NewRegistrationRequestHandler(registrationRequestService)
 	verificationHandler := handler.NewVerificationHandler(verificationService)
 	recoveryHandler := handler.NewRecoveryHandler(backupService)
-	importHandler := handler.NewImportHandler(importService)
+	importHandler := handler.NewImportHandler(importService, cfg.ImportMaxValidateRows, cfg.ImportMaxImportRows, cfg.ImportMaxUploadBytes)
+	importHandler.SetTemplateCacheMaxAge(time.Duration(cfg.ImportTemplateCacheSeconds) * time.Second)
+	maintenanceHandler := handler.NewMaintenanceHandler(maintenanceService)
+	reportHandler := handler.NewReportHandler(reportService)
+	statsHandler := handler.NewStatsHandler(statsService)
+	emailHandler := handler.NewEmailHandler(emailService, emailLogRepo)
+
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	emailTestLimiter := middleware.NewRateLimiter(5, time.Hour)
+	emailTestLimiter.SetTrustedProxies(trustedProxies)
 
 	// Public routes
 	r.Get("/api/health", healthHandler.Health)
+	r.Get("/api/health/ready", healthHandler.Ready)
+
+	// Public registration request routes (no auth required, rate limited per IP)
+	r.Group(func(r chi.Router) {
+		registrationLimiter := middleware.NewRateLimiter(cfg.RegistrationRateLimit, time.Hour)
+		registrationLimiter.SetTrustedProxies(trustedProxies)
+		r.Use(middleware.RateLimit(registrationLimiter))
+		r.Post("/api/registration-requests", registrationRequestHandler.Submit)
+		r.Get("/api/registration-requests/action/{token}", registrationRequestHandler.GetByToken)
+		r.Post("/api/registration-requests/action/{token}/approve", registrationRequestHandler.ApproveByToken)
+		r.Post("/api/registration-requests/action/{token}/reject", registrationRequestHandler.RejectByToken)
+	})
+
+	// Barcode lookup for kiosk/scanner integrations (API key only - no PII returned)
+	r.With(middleware.RequireAPIKey(cfg.KioskAPIKey)).Get("/api/clients/barcode/{code}/exists", clientHandler.BarcodeExists)
 
-	// Public registration request routes (no auth required)
-	r.Post("/api/registration-requests", registrationRequestHandler.Submit)
-	r.Get("/api/registration-requests/action/{token}", registrationRequestHandler.GetByToken)
-	r.Post("/api/registration-requests/action/{token}/approve", registrationRequestHandler.ApproveByToken)
-	r.Post("/api/registration-requests/action/{token}/reject", registrationRequestHandler.RejectByToken)
+	// Offline backup validation (recovery token only - no database access)
+	r.With(middleware.RecoveryTokenOnly(cfg.RecoveryToken)).Post("/api/admin/backup/validate", recoveryHandler.Validate)
 
 	// Protected routes (require Auth0 JWT)
 	if cfg.Auth0Domain != "" && cfg.Auth0Audience != "" {
-		authMiddleware, err := middleware.NewAuthMiddleware(cfg.Auth0Domain, cfg.Auth0Audience)
+		authMiddleware, err := middleware.NewAuthMiddleware(cfg.Auth0Domain, cfg.Auth0Audience, cfg.Auth0TrustRoleClaim)
 		if err != nil {
 			log.Fatalf("Failed to create auth middleware: %v", err)
 		}
@@ -127,63 +191,124 @@ func main() {
 			r.Use(middleware.LoadStaff(staffService))
 			r.Use(middleware.RequireActive(staffService))
 
-			// Staff routes - all authenticated users
-			r.Get("/api/me", staffHandler.Me)
-			r.Get("/api/me/mfa", staffHandler.GetMFAStatus)
-			r.Post("/api/me/mfa/enroll", staffHandler.EnrollMFA)
-			r.Delete("/api/me/mfa", staffHandler.DisableMFA)
-
-			// Email verification routes
-			r.Get("/api/verification/status", verificationHandler.GetStatus)
-			r.Post("/api/verification/send", verificationHandler.SendCode)
-			r.Post("/api/verification/verify", verificationHandler.VerifyCode)
-
-			r.Get("/api/staff", staffHandler.List)
-			r.Get("/api/staff/{id}", staffHandler.Get)
-			r.Put("/api/staff/{id}", staffHandler.Update)
-
-			// Staff routes - admin only
-			r.Group(func(r chi.Router) {
-				r.Use(middleware.RequireAdmin(staffService))
-				r.Post("/api/staff", staffHandler.Create)
-				r.Delete("/api/staff/{id}", staffHandler.Deactivate)
-				r.Post("/api/staff/{id}/reactivate", staffHandler.Reactivate)
-				r.Put("/api/staff/{id}/role", staffHandler.UpdateRole)
-
-				// Registration request management
-				r.Get("/api/registration-requests", registrationRequestHandler.List)
-				r.Get("/api/registration-requests/count", registrationRequestHandler.CountPending)
-				r.Post("/api/registration-requests/{id}/approve", registrationRequestHandler.ApproveByID)
-				r.Post("/api/registration-requests/{id}/reject", registrationRequestHandler.RejectByID)
-
-				// Backup (admin only - normal auth)
-				r.Get("/api/admin/backup", recoveryHandler.Backup)
-
-				// Import (admin only)
-				r.Get("/api/admin/import/template", importHandler.Template)
-				r.Post("/api/admin/import/validate", importHandler.Validate)
-				r.Post("/api/admin/import/clients", importHandler.Import)
-			})
-
 			// Recovery routes (recovery token OR admin)
+			// Not behind EnsureStaff so restore still works when the database is unavailable
 			r.Group(func(r chi.Router) {
 				r.Use(middleware.RecoveryAuth(cfg.RecoveryToken, staffService))
 				r.Post("/api/admin/restore", recoveryHandler.Restore)
 				r.Get("/api/admin/recovery/status", recoveryHandler.Status)
 			})
 
-			// Client routes
-			r.Get("/api/clients", clientHandler.List)
-			r.Post("/api/clients", clientHandler.Create)
-			r.Get("/api/clients/{id}", clientHandler.Get)
-			r.Put("/api/clients/{id}", clientHandler.Update)
-			r.Post("/api/clients/{id}/attendance", clientHandler.RecordAttendance)
-			r.Get("/api/clients/{id}/attendance", clientHandler.GetAttendanceHistory)
-			r.Get("/api/clients/barcode/{code}", clientHandler.GetByBarcode)
-
-			// Audit log routes
-			r.Get("/api/audit", auditHandler.List)
-			r.Get("/api/audit/{table}/{id}", auditHandler.GetByRecord)
+			// Routes for registered staff (unknown users get 403 not_registered)
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.EnsureStaff(staffService))
+
+				// Staff routes - all authenticated users
+				r.Get("/api/me", staffHandler.Me)
+				r.Get("/api/me/activity", staffHandler.MyActivity)
+				r.Get("/api/me/mfa", staffHandler.GetMFAStatus)
+				r.Post("/api/me/mfa/enroll", staffHandler.EnrollMFA)
+				r.Delete("/api/me/mfa", staffHandler.DisableMFA)
+
+				// Email verification routes
+				r.Get("/api/verification/status", verificationHandler.GetStatus)
+				r.Post("/api/verification/send", verificationHandler.SendCode)
+				r.Post("/api/verification/verify", verificationHandler.VerifyCode)
+
+				// Everything below requires a verified email once the grace period ends
+				r.Group(func(r chi.Router) {
+					if cfg.RequireEmailVerification {
+						r.Use(middleware.RequireVerifiedEmail(time.Duration(cfg.EmailVerificationGraceHours) * time.Hour))
+					}
+
+					r.Get("/api/staff", staffHandler.List)
+					r.With(middleware.RequireRole(staffService, model.RoleAdmin)).Get("/api/staff/deactivated", staffHandler.ListDeactivated)
+					r.Get("/api/staff/{id}", staffHandler.Get)
+					r.Put("/api/staff/{id}", staffHandler.Update)
+
+					// Staff routes - admin only
+					r.Group(func(r chi.Router) {
+						r.Use(middleware.RequireAdmin(staffService))
+						r.Post("/api/staff", staffHandler.Create)
+						r.Delete("/api/staff/{id}", staffHandler.Deactivate)
+						r.Post("/api/staff/{id}/reactivate", staffHandler.Reactivate)
+						r.Post("/api/staff/{id}/resend-invite", staffHandler.ResendInvite)
+						r.Post("/api/staff/{id}/verify-email", staffHandler.VerifyEmail)
+						r.Delete("/api/staff/{id}/verify-email", staffHandler.UnverifyEmail)
+						r.Put("/api/staff/{id}/role", staffHandler.UpdateRole)
+						r.Get("/api/staff/{id}/mfa", staffHandler.GetStaffMFA)
+						r.Get("/api/staff/{id}/onboarding", staffHandler.GetOnboarding)
+						r.Delete("/api/staff/{id}/mfa", staffHandler.ResetStaffMFA)
+
+						// Registration request management
+						r.Get("/api/registration-requests", registrationRequestHandler.List)
+						r.Get("/api/registration-requests/count", registrationRequestHandler.CountPending)
+						r.Get("/api/registration-requests/export", registrationRequestHandler.Export)
+						r.Post("/api/registration-requests/{id}/approve", registrationRequestHandler.ApproveByID)
+						r.Post("/api/registration-requests/{id}/reject", registrationRequestHandler.RejectByID)
+						r.Post("/api/registration-requests/{id}/resend", registrationRequestHandler.Resend)
+						r.Post("/api/registration-requests/{id}/resend-decision", registrationRequestHandler.ResendDecision)
+
+						// Backup (admin only - normal auth)
+						r.Get("/api/admin/backup", recoveryHandler.Backup)
+
+						// Import (admin only)
+						r.Get("/api/admin/import/template", importHandler.Template)
+						r.Post("/api/admin/import/validate", importHandler.Validate)
+						r.Post("/api/admin/import/upload", importHandler.Upload)
+						r.Post("/api/admin/import/clients", importHandler.Import)
+
+						// Maintenance (admin only)
+						r.Post("/api/admin/maintenance/cleanup", maintenanceHandler.Cleanup)
+
+						// Email configuration check (admin only, rate limited)
+						r.With(middleware.RateLimit(emailTestLimiter)).Post("/api/admin/email-test", emailHandler.SendTest)
+						r.Get("/api/admin/email-log", emailHandler.Log)
+
+						// Audit log export (admin only)
+						r.Get("/api/audit/export", auditHandler.Export)
+
+						// Dashboard counts (admin only, cached briefly)
+						r.Get("/api/admin/stats", statsHandler.Dashboard)
+
+						// Reports (admin only)
+						r.Get("/api/reports/attendance/heatmap", reportHandler.AttendanceHeatmap)
+						r.Get("/api/reports/export", reportHandler.Export)
+
+						// Reporting (admin only)
+						r.Get("/api/attendance/summary", clientHandler.AttendanceSummary)
+					})
+
+					// Client routes
+					r.Get("/api/clients", clientHandler.List)
+					r.Post("/api/clients", clientHandler.Create)
+					r.Post("/api/clients/batch-update", clientHandler.BatchUpdate)
+					r.Get("/api/clients/export", clientHandler.Export)
+					r.Get("/api/clients/count", clientHandler.Count)
+					r.Get("/api/clients/{id}", clientHandler.Get)
+					r.Get("/api/clients/{id}/barcode.png", clientHandler.BarcodeImage)
+					r.Get("/api/clients/{id}/full", clientHandler.GetProfile)
+					r.Put("/api/clients/{id}", clientHandler.Update)
+					r.Delete("/api/clients/{id}", clientHandler.Archive)
+					r.Post("/api/clients/{id}/unarchive", clientHandler.Unarchive)
+					r.Delete("/api/clients/{id}/photo", clientHandler.RemovePhoto)
+					r.Post("/api/clients/{id}/attendance", clientHandler.RecordAttendance)
+					r.Get("/api/clients/{id}/attendance", clientHandler.GetAttendanceHistory)
+					r.Delete("/api/clients/{id}/attendance/{attendanceID}", clientHandler.VoidAttendance)
+					r.Get("/api/clients/{id}/appointments", clientHandler.ListAppointments)
+					r.Post("/api/clients/{id}/appointments", clientHandler.AddAppointment)
+					r.Delete("/api/clients/{id}/appointments/{appointmentID}", clientHandler.DeleteAppointment)
+					r.Get("/api/clients/barcode/{code}", clientHandler.GetByBarcode)
+					r.Get("/api/attendance", clientHandler.ListAttendance)
+					r.Post("/api/attendance/bulk", clientHandler.BulkRecordAttendance)
+					r.Post("/api/attendance/import", clientHandler.ImportAttendance)
+
+					// Audit log routes
+					r.Get("/api/audit", auditHandler.List)
+					r.Get("/api/audit/entry/{id}", auditHandler.GetEntry)
+					r.Get("/api/audit/{table}/{id}", auditHandler.GetByRecord)
+				})
+			})
 		})
 	} else {
 		log.Println("Warning: Auth0 not configured, protected routes disabled")
@@ -198,18 +323,29 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// Graceful shutdown
+	// Background jobs (stopped on shutdown)
+	bgCtx, stopBackground := context.WithCancel(ctx)
+	go maintenanceService.Run(bgCtx, time.Hour)
+
+	// Graceful shutdown: stop accepting requests, let in-flight requests and
+	// admin notifications finish, then return so the DB pool is closed
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 		<-sigChan
 
 		log.Println("Shutting down server...")
+		stopBackground()
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
 		if err := server.Shutdown(ctx); err != nil {
-			log.Fatalf("Server shutdown failed: %v", err)
+			log.Printf("Server shutdown incomplete: %v", err)
+		}
+		if err := registrationRequestService.Shutdown(ctx); err != nil {
+			log.Printf("Admin notifications still pending at shutdown: %v", err)
 		}
 	}()
 
@@ -217,5 +353,6 @@ func main() {
 	if err := server.ListenAndServe(); err != http.ErrServerClosed {
 		log.Fatalf("Server failed: %v", err)
 	}
+	<-shutdownDone
 	log.Println("Server stopped")
 }
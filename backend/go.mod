@@ -4,6 +4,7 @@ go 1.23
 
 require (
 	github.com/auth0/go-jwt-middleware/v2 v2.2.2
+	github.com/boombuler/barcode v1.0.2
 	github.com/go-chi/chi/v5 v5.1.0
 	github.com/go-chi/cors v1.2.1
 	github.com/google/uuid v1.6.0
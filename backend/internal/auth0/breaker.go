@@ -0,0 +1,172 @@
+package auth0
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable is returned without contacting Auth0 while the circuit
+// breaker is open after repeated failures
+var ErrUnavailable = errors.New("auth0 unavailable")
+
+// Default resilience settings, overridable via Client.ConfigureResilience
+const (
+	defaultMaxRetries       = 3
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+	retryBackoff            = 200 * time.Millisecond
+	maxRetryWait            = 5 * time.Second
+)
+
+// circuitBreaker opens after threshold consecutive failures and rejects calls
+// until cooldown has passed. It then lets a single trial call through: success
+// closes the breaker, failure re-opens it for another cooldown.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	trial     bool // a half-open trial call is in flight
+	now       func() time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether a call may proceed
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true
+	}
+	if b.trial || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// isAuth0Failure reports whether a response indicates Auth0 itself is
+// unhealthy, as opposed to a client error such as a duplicate user
+func isAuth0Failure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// isIdempotent reports whether a request can safely be retried
+func isIdempotent(req *http.Request) bool {
+	return req.Method == http.MethodGet || req.Method == http.MethodDelete
+}
+
+// canRetry reports whether a failed attempt may be repeated. Rate-limited
+// requests were rejected before Auth0 acted on them, so any method whose
+// body can be replayed is safe; other failures only retry idempotent calls.
+func canRetry(req *http.Request, resp *http.Response) bool {
+	if isIdempotent(req) {
+		return true
+	}
+	return resp != nil && resp.StatusCode == http.StatusTooManyRequests &&
+		(req.Body == nil || req.GetBody != nil)
+}
+
+// retryWait is the delay before retry attempt n (0-based): the server's
+// Retry-After on 429s, otherwise exponential backoff, capped at maxRetryWait
+func retryWait(resp *http.Response, n int) time.Duration {
+	wait := retryBackoff << n
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		if after := parseRetryAfter(resp.Header.Get("Retry-After")); after > 0 {
+			wait = after
+		}
+	}
+	if wait > maxRetryWait {
+		wait = maxRetryWait
+	}
+	return wait
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or HTTP-date form
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// do sends req through the circuit breaker, retrying on transport errors,
+// rate limits and server failures where canRetry allows it
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if !c.breaker.allow() {
+		return nil, ErrUnavailable
+	}
+
+	ctx := req.Context()
+	for i := 0; ; i++ {
+		resp, err := c.httpClient.Do(req)
+		if ctx.Err() != nil {
+			// The caller gave up; that says nothing about Auth0's health
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+		if !isAuth0Failure(resp, err) {
+			c.breaker.success()
+			return resp, nil
+		}
+		if i >= c.maxRetries || !canRetry(req, resp) {
+			c.breaker.failure()
+			return resp, err
+		}
+		wait := retryWait(resp, i)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				c.breaker.failure()
+				return nil, err
+			}
+			req.Body = body
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
@@ -2,6 +2,7 @@ package auth0
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,6 +23,13 @@ type Client struct {
 	tokenMu    sync.RWMutex
 	token      string
 	tokenExpAt time.Time
+
+	// Resilience
+	maxRetries int
+	breaker    *circuitBreaker
+
+	// sendAppMetadata includes app_metadata when creating users
+	sendAppMetadata bool
 }
 
 // NewClient creates a new Auth0 Management API client
@@ -34,9 +42,34 @@ func NewClient(domain, clientID, clientSecret, connectionID string) *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		maxRetries: defaultMaxRetries,
+		breaker:    newCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown),
 	}
 }
 
+// ConfigureResilience sets how many times idempotent requests are retried and
+// how many consecutive failures open the circuit breaker (0 disables it)
+func (c *Client) ConfigureResilience(maxRetries, breakerThreshold int, breakerCooldown time.Duration) {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	c.maxRetries = maxRetries
+	c.breaker = newCircuitBreaker(breakerThreshold, breakerCooldown)
+}
+
+// SetHTTPClient replaces the HTTP client used for Auth0 requests, e.g. with
+// one that trusts a test server's certificate
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// SetSendAppMetadata controls whether CreateUser stores the given metadata
+// (role, invited_by) as the user's app_metadata, where an Auth0 Action can
+// read it to add namespaced role claims
+func (c *Client) SetSendAppMetadata(enabled bool) {
+	c.sendAppMetadata = enabled
+}
+
 // IsConfigured returns true if the client has all required credentials
 func (c *Client) IsConfigured() bool {
 	return c.domain != "" && c.clientID != "" && c.clientSecret != "" && c.connectionID != ""
@@ -50,7 +83,7 @@ type tokenResponse struct {
 }
 
 // GetManagementToken obtains or returns cached M2M access token
-func (c *Client) GetManagementToken() (string, error) {
+func (c *Client) GetManagementToken(ctx context.Context) (string, error) {
 	c.tokenMu.RLock()
 	if c.token != "" && time.Now().Before(c.tokenExpAt) {
 		token := c.token
@@ -79,13 +112,13 @@ func (c *Client) GetManagementToken() (string, error) {
 		return "", fmt.Errorf("marshal token request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("https://%s/oauth/token", c.domain), bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("https://%s/oauth/token", c.domain), bytes.NewReader(body))
 	if err != nil {
 		return "", fmt.Errorf("create token request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("token request failed: %w", err)
 	}
@@ -116,9 +149,10 @@ type CreateUserResponse struct {
 }
 
 // CreateUser creates a new user in Auth0 without a password
-// The user will need to set their password via password reset email
-func (c *Client) CreateUser(email, name string) (*CreateUserResponse, error) {
-	token, err := c.GetManagementToken()
+// The user will need to set their password via password reset email.
+// appMetadata is sent only when enabled with SetSendAppMetadata.
+func (c *Client) CreateUser(ctx context.Context, email, name string, appMetadata map[string]interface{}) (*CreateUserResponse, error) {
+	token, err := c.GetManagementToken(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get management token: %w", err)
 	}
@@ -131,20 +165,23 @@ func (c *Client) CreateUser(email, name string) (*CreateUserResponse, error) {
 		// Generate random password - user will reset it
 		"password": generateSecurePassword(),
 	}
+	if c.sendAppMetadata && len(appMetadata) > 0 {
+		payload["app_metadata"] = appMetadata
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("marshal create user request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("https://%s/api/v2/users", c.domain), bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("https://%s/api/v2/users", c.domain), bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("create user request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("create user request failed: %w", err)
 	}
@@ -170,8 +207,8 @@ type PasswordChangeTicketResponse struct {
 
 // SendPasswordSetEmail creates a password change ticket and returns the URL
 // This is used to send invitation emails to new users
-func (c *Client) SendPasswordSetEmail(auth0ID string) (string, error) {
-	token, err := c.GetManagementToken()
+func (c *Client) SendPasswordSetEmail(ctx context.Context, auth0ID string) (string, error) {
+	token, err := c.GetManagementToken(ctx)
 	if err != nil {
 		return "", fmt.Errorf("get management token: %w", err)
 	}
@@ -187,14 +224,14 @@ func (c *Client) SendPasswordSetEmail(auth0ID string) (string, error) {
 		return "", fmt.Errorf("marshal password ticket request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("https://%s/api/v2/tickets/password-change", c.domain), bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("https://%s/api/v2/tickets/password-change", c.domain), bytes.NewReader(body))
 	if err != nil {
 		return "", fmt.Errorf("create password ticket request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("password ticket request failed: %w", err)
 	}
@@ -213,18 +250,46 @@ func (c *Client) SendPasswordSetEmail(auth0ID string) (string, error) {
 	return ticketResp.Ticket, nil
 }
 
+// DeleteUser permanently deletes a user from Auth0
+// Used to roll back user creation when the local staff record can't be created
+func (c *Client) DeleteUser(ctx context.Context, auth0ID string) error {
+	token, err := c.GetManagementToken(ctx)
+	if err != nil {
+		return fmt.Errorf("get management token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", fmt.Sprintf("https://%s/api/v2/users/%s", c.domain, auth0ID), nil)
+	if err != nil {
+		return fmt.Errorf("create delete user request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("delete user request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete user failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
 // BlockUser blocks a user from logging in (for deactivation)
-func (c *Client) BlockUser(auth0ID string) error {
-	return c.updateUserBlocked(auth0ID, true)
+func (c *Client) BlockUser(ctx context.Context, auth0ID string) error {
+	return c.updateUserBlocked(ctx, auth0ID, true)
 }
 
 // UnblockUser unblocks a user (for reactivation)
-func (c *Client) UnblockUser(auth0ID string) error {
-	return c.updateUserBlocked(auth0ID, false)
+func (c *Client) UnblockUser(ctx context.Context, auth0ID string) error {
+	return c.updateUserBlocked(ctx, auth0ID, false)
 }
 
-func (c *Client) updateUserBlocked(auth0ID string, blocked bool) error {
-	token, err := c.GetManagementToken()
+func (c *Client) updateUserBlocked(ctx context.Context, auth0ID string, blocked bool) error {
+	token, err := c.GetManagementToken(ctx)
 	if err != nil {
 		return fmt.Errorf("get management token: %w", err)
 	}
@@ -238,14 +303,14 @@ func (c *Client) updateUserBlocked(auth0ID string, blocked bool) error {
 		return fmt.Errorf("marshal block user request: %w", err)
 	}
 
-	req, err := http.NewRequest("PATCH", fmt.Sprintf("https://%s/api/v2/users/%s", c.domain, auth0ID), bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "PATCH", fmt.Sprintf("https://%s/api/v2/users/%s", c.domain, auth0ID), bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("create block user request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("block user request failed: %w", err)
 	}
@@ -259,6 +324,44 @@ func (c *Client) updateUserBlocked(auth0ID string, blocked bool) error {
 	return nil
 }
 
+// UserLoginInfo holds the login fields of an Auth0 user
+type UserLoginInfo struct {
+	LastLogin   *time.Time `json:"last_login,omitempty"`
+	LoginsCount int        `json:"logins_count"`
+}
+
+// GetUserLoginInfo returns when a user last logged in and how many times
+func (c *Client) GetUserLoginInfo(ctx context.Context, auth0ID string) (*UserLoginInfo, error) {
+	token, err := c.GetManagementToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get management token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s/api/v2/users/%s?fields=last_login,logins_count&include_fields=true", c.domain, auth0ID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create get user request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get user request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get user failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var info UserLoginInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("decode user response: %w", err)
+	}
+
+	return &info, nil
+}
+
 // MFAEnrollment represents an MFA enrollment for a user
 type MFAEnrollment struct {
 	ID         string `json:"id"`
@@ -269,19 +372,19 @@ type MFAEnrollment struct {
 }
 
 // GetMFAEnrollments returns all MFA enrollments for a user
-func (c *Client) GetMFAEnrollments(auth0ID string) ([]MFAEnrollment, error) {
-	token, err := c.GetManagementToken()
+func (c *Client) GetMFAEnrollments(ctx context.Context, auth0ID string) ([]MFAEnrollment, error) {
+	token, err := c.GetManagementToken(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get management token: %w", err)
 	}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://%s/api/v2/users/%s/enrollments", c.domain, auth0ID), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://%s/api/v2/users/%s/enrollments", c.domain, auth0ID), nil)
 	if err != nil {
 		return nil, fmt.Errorf("create get enrollments request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("get enrollments request failed: %w", err)
 	}
@@ -301,19 +404,19 @@ func (c *Client) GetMFAEnrollments(auth0ID string) ([]MFAEnrollment, error) {
 }
 
 // DeleteMFAEnrollment removes an MFA enrollment for a user
-func (c *Client) DeleteMFAEnrollment(auth0ID, enrollmentID string) error {
-	token, err := c.GetManagementToken()
+func (c *Client) DeleteMFAEnrollment(ctx context.Context, auth0ID, enrollmentID string) error {
+	token, err := c.GetManagementToken(ctx)
 	if err != nil {
 		return fmt.Errorf("get management token: %w", err)
 	}
 
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("https://%s/api/v2/users/%s/enrollments/%s", c.domain, auth0ID, enrollmentID), nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", fmt.Sprintf("https://%s/api/v2/users/%s/enrollments/%s", c.domain, auth0ID, enrollmentID), nil)
 	if err != nil {
 		return fmt.Errorf("create delete enrollment request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("delete enrollment request failed: %w", err)
 	}
@@ -334,8 +437,8 @@ type MFAEnrollmentTicketResponse struct {
 }
 
 // CreateMFAEnrollmentTicket creates a ticket for MFA enrollment
-func (c *Client) CreateMFAEnrollmentTicket(auth0ID string) (*MFAEnrollmentTicketResponse, error) {
-	token, err := c.GetManagementToken()
+func (c *Client) CreateMFAEnrollmentTicket(ctx context.Context, auth0ID string) (*MFAEnrollmentTicketResponse, error) {
+	token, err := c.GetManagementToken(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get management token: %w", err)
 	}
@@ -350,14 +453,14 @@ func (c *Client) CreateMFAEnrollmentTicket(auth0ID string) (*MFAEnrollmentTicket
 		return nil, fmt.Errorf("marshal enrollment ticket request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("https://%s/api/v2/guardian/enrollments/ticket", c.domain), bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("https://%s/api/v2/guardian/enrollments/ticket", c.domain), bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("create enrollment ticket request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("enrollment ticket request failed: %w", err)
 	}
@@ -0,0 +1,81 @@
+// Package barcode renders client barcode IDs as images for printed cards.
+// The symbols are encoded by github.com/boombuler/barcode; this package only
+// lays them out with the quiet zones scanners need and writes PNGs.
+package barcode
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	bc "github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+)
+
+// Blank margins, in modules, required around each symbology
+const (
+	code128QuietZone = 10
+	qrQuietZone      = 4
+)
+
+// WriteCode128PNG renders s as a Code 128 PNG where each module is scale
+// pixels wide and bars are height pixels tall
+func WriteCode128PNG(w io.Writer, s string, scale, height int) error {
+	code, err := code128.Encode(s)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, render(code, scale, code128QuietZone, height))
+}
+
+// WriteQRPNG renders s as a QR code PNG where each module is scale pixels
+// square. Medium error correction survives a worn or creased card.
+func WriteQRPNG(w io.Writer, s string, scale int) error {
+	code, err := qr.Encode(s, qr.M, qr.Auto)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, render(code, scale, qrQuietZone, 0))
+}
+
+// render draws code with each module scale pixels wide and quietZone blank
+// modules around it. A 1D code is drawn height pixels tall with margins
+// only at the sides; a 2D code is square with margins all round.
+func render(code bc.Barcode, scale, quietZone, height int) *image.Paletted {
+	b := code.Bounds()
+	twoD := code.Metadata().Dimensions == 2
+	rows := 1
+	if twoD {
+		rows = b.Dy()
+		height = (rows + 2*quietZone) * scale
+	}
+	width := (b.Dx() + 2*quietZone) * scale
+
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.White, color.Black})
+	for my := 0; my < rows; my++ {
+		y0, y1 := 0, height
+		if twoD {
+			y0 = (quietZone + my) * scale
+			y1 = y0 + scale
+		}
+		for mx := 0; mx < b.Dx(); mx++ {
+			if !isDark(code.At(b.Min.X+mx, b.Min.Y+my)) {
+				continue
+			}
+			x0 := (quietZone + mx) * scale
+			for x := x0; x < x0+scale; x++ {
+				for y := y0; y < y1; y++ {
+					img.SetColorIndex(x, y, 1)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// isDark reports whether a module colour is a bar rather than a space
+func isDark(c color.Color) bool {
+	return color.GrayModel.Convert(c).(color.Gray).Y < 0x80
+}
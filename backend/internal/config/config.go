@@ -1,11 +1,23 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// defaultCORSAllowedOrigins are used when CORS_ALLOWED_ORIGINS is unset
+var defaultCORSAllowedOrigins = []string{"http://localhost:5173", "http://localhost:3000", "https://foodbank-web.fly.dev"}
+
+// ErrCORSWildcard is returned when CORS_ALLOWED_ORIGINS contains "*". The
+// API sends credentials, which browsers refuse for a wildcard origin.
+var ErrCORSWildcard = errors.New(`CORS_ALLOWED_ORIGINS cannot contain "*" because credentials are allowed`)
+
 type Config struct {
 	DatabaseURL          string
 	Port                 string
@@ -14,13 +26,78 @@ type Config struct {
 	Auth0M2MClientID     string
 	Auth0M2MClientSecret string
 	Auth0ConnectionID    string
+	// Auth0 Management API resilience
+	Auth0MaxRetries             int
+	Auth0BreakerThreshold       int
+	Auth0BreakerCooldownSeconds int
+	// Send role/invited_by as app_metadata when creating Auth0 users
+	Auth0SendAppMetadata bool
+	// Accept the https://foodbank.app/role token claim for role checks
+	Auth0TrustRoleClaim bool
 	// Resend configuration
 	ResendAPIKey string
 	FromEmail    string
 	FromName     string
 	AppBaseURL   string
+	// APP_TIMEZONE, used to bucket reports by local day/hour and to bound
+	// date-based attendance lists
+	AppLocation *time.Location
+	// Per-message-type sender overrides (empty falls back to FromEmail/FromName)
+	FromEmailSecurity string
+	FromNameSecurity  string
+	FromEmailAdmin    string
+	FromNameAdmin     string
 	// Recovery configuration
 	RecoveryToken string
+	// Per-statement timeout for repository transactions (0 disables)
+	DBStatementTimeoutMS int
+	// Reject new clients whose name+address match an existing client
+	ClientDuplicatesStrict bool
+	// Public registration requests allowed per IP per hour (0 disables)
+	RegistrationRateLimit int
+	// Reverse proxies (IPs or CIDRs, TRUSTED_PROXIES=10.0.0.1,fdaa::/16)
+	// whose X-Forwarded-For is used for per-IP rate limits. When empty the
+	// connection's address is always used.
+	TrustedProxies []string
+	// How long registration approval links stay valid
+	RegistrationTokenTTLHours int
+	// Repeat attendance scans of a client within this many seconds return
+	// the existing visit (0 disables)
+	AttendanceDedupSeconds int
+	// Minimum hours between a client's visits (0 = unlimited)
+	AttendanceCooldownHours int
+	// Require a reason when an admin overrides the cooldown
+	// (ATTENDANCE_OVERRIDE_REASON_REQUIRED=false to disable)
+	AttendanceOverrideReasonRequired bool
+	// Block unverified staff (ENFORCE_EMAIL_VERIFICATION=true, or its alias
+	// REQUIRE_EMAIL_VERIFICATION=true) once their account is older than the
+	// grace period
+	RequireEmailVerification    bool
+	EmailVerificationGraceHours int
+	// Kiosk/scanner integration key
+	KioskAPIKey string
+	// Mask emails in logs (LOG_REDACT=false to disable)
+	LogRedact bool
+	// Structured JSON access logs (ACCESS_LOG_JSON=true) with optional
+	// "prefix:N,prefix:N" sampling to log 1 in N matching requests
+	AccessLogJSON   bool
+	AccessLogSample string
+	// Tables whose audit entries store only changed fields (AUDIT_DIFF_TABLES=clients,staff)
+	AuditDiffTables []string
+	// Import configuration
+	ImportMaxValidateRows int
+	ImportMaxImportRows   int
+	// Largest CSV file accepted by the import upload endpoint
+	ImportMaxUploadBytes int64
+	// Batches imported concurrently (0 = one per CPU)
+	ImportWorkers int
+	// How long browsers may cache the import template
+	ImportTemplateCacheSeconds int
+	// Origins allowed to call the API from a browser (CORS_ALLOWED_ORIGINS=a,b)
+	CORSAllowedOrigins []string
+	// Request body caps: the default, and the larger one for import and restore
+	MaxBodyBytes      int64
+	MaxLargeBodyBytes int64
 }
 
 func Load() (*Config, error) {
@@ -36,11 +113,74 @@ func Load() (*Config, error) {
 		Auth0M2MClientID:     getEnv("AUTH0_M2M_CLIENT_ID", ""),
 		Auth0M2MClientSecret: getEnv("AUTH0_M2M_CLIENT_SECRET", ""),
 		Auth0ConnectionID:    getEnv("AUTH0_CONNECTION_ID", ""),
+
+		Auth0MaxRetries:             getEnvInt("AUTH0_MAX_RETRIES", 3),
+		Auth0BreakerThreshold:       getEnvInt("AUTH0_BREAKER_THRESHOLD", 5),
+		Auth0BreakerCooldownSeconds: getEnvInt("AUTH0_BREAKER_COOLDOWN_SECONDS", 30),
+
+		Auth0SendAppMetadata: getEnv("AUTH0_SEND_APP_METADATA", "false") == "true",
+		Auth0TrustRoleClaim:  getEnv("AUTH0_TRUST_ROLE_CLAIM", "false") == "true",
+
 		ResendAPIKey:  getEnv("RESEND_API_KEY", ""),
 		FromEmail:     getEnv("FROM_EMAIL", "noreply@finchley-foodbank.org"),
 		FromName:      getEnv("FROM_NAME", "Finchley Foodbank"),
 		AppBaseURL:    getEnv("APP_BASE_URL", "http://localhost:5173"),
 		RecoveryToken: getEnv("RECOVERY_TOKEN", ""),
+		KioskAPIKey:   getEnv("KIOSK_API_KEY", ""),
+		LogRedact:     getEnv("LOG_REDACT", "true") != "false",
+
+		AccessLogJSON:   getEnv("ACCESS_LOG_JSON", "false") == "true",
+		AccessLogSample: getEnv("ACCESS_LOG_SAMPLE", "/api/clients/barcode/:10"),
+
+		FromEmailSecurity: getEnv("FROM_EMAIL_SECURITY", ""),
+		FromNameSecurity:  getEnv("FROM_NAME_SECURITY", ""),
+		FromEmailAdmin:    getEnv("FROM_EMAIL_ADMIN", ""),
+		FromNameAdmin:     getEnv("FROM_NAME_ADMIN", ""),
+
+		AuditDiffTables: getEnvList("AUDIT_DIFF_TABLES"),
+
+		RegistrationRateLimit:   getEnvInt("REGISTRATION_RATE_LIMIT", 10),
+		TrustedProxies:          getEnvList("TRUSTED_PROXIES"),
+		AttendanceDedupSeconds:  getEnvInt("ATTENDANCE_DEDUP_SECONDS", 60),
+		AttendanceCooldownHours: getEnvInt("ATTENDANCE_COOLDOWN_HOURS", 0),
+
+		DBStatementTimeoutMS:             getEnvInt("DB_STATEMENT_TIMEOUT_MS", 15000),
+		ClientDuplicatesStrict:           getEnv("CLIENT_DUPLICATES_STRICT", "false") == "true",
+		RegistrationTokenTTLHours:        getEnvInt("REGISTRATION_TOKEN_TTL_HOURS", 168),
+		AttendanceOverrideReasonRequired: getEnv("ATTENDANCE_OVERRIDE_REASON_REQUIRED", "true") != "false",
+
+		RequireEmailVerification: getEnv("ENFORCE_EMAIL_VERIFICATION", "false") == "true" ||
+			getEnv("REQUIRE_EMAIL_VERIFICATION", "false") == "true",
+		EmailVerificationGraceHours: getEnvInt("EMAIL_VERIFICATION_GRACE_HOURS", 72),
+
+		ImportMaxValidateRows: getEnvInt("IMPORT_MAX_VALIDATE_ROWS", 10000),
+		ImportMaxImportRows:   getEnvInt("IMPORT_MAX_IMPORT_ROWS", 10000),
+
+		ImportMaxUploadBytes: int64(getEnvInt("IMPORT_MAX_UPLOAD_BYTES", 5<<20)),
+		ImportWorkers:        getEnvInt("IMPORT_WORKERS", 0),
+
+		ImportTemplateCacheSeconds: getEnvInt("IMPORT_TEMPLATE_CACHE_SECONDS", 3600),
+
+		CORSAllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS"),
+
+		MaxBodyBytes:      int64(getEnvInt("MAX_BODY_BYTES", 1<<20)),
+		MaxLargeBodyBytes: int64(getEnvInt("MAX_LARGE_BODY_BYTES", 64<<20)),
+	}
+
+	timezone := getEnv("APP_TIMEZONE", "Europe/London")
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid APP_TIMEZONE %q: %w", timezone, err)
+	}
+	cfg.AppLocation = loc
+
+	if len(cfg.CORSAllowedOrigins) == 0 {
+		cfg.CORSAllowedOrigins = defaultCORSAllowedOrigins
+	}
+	for _, origin := range cfg.CORSAllowedOrigins {
+		if origin == "*" {
+			return nil, ErrCORSWildcard
+		}
 	}
 
 	return cfg, nil
@@ -52,3 +192,23 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList splits a comma-separated value, dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
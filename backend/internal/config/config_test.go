@@ -0,0 +1,40 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLoadCORSAllowedOrigins(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		want    []string
+		wantErr error
+	}{
+		{"unset uses the defaults", "", defaultCORSAllowedOrigins, nil},
+		{"multiple origins", "https://a.example.org, https://b.example.org,,http://localhost:5173",
+			[]string{"https://a.example.org", "https://b.example.org", "http://localhost:5173"}, nil},
+		{"wildcard is rejected", "https://a.example.org,*", nil, ErrCORSWildcard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CORS_ALLOWED_ORIGINS", tt.env)
+			cfg, err := Load()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.CORSAllowedOrigins, tt.want) {
+				t.Errorf("origins = %q, want %q", cfg.CORSAllowedOrigins, tt.want)
+			}
+		})
+	}
+}
@@ -2,22 +2,66 @@ package email
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html"
 	"log"
+	"net"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/resend/resend-go/v2"
 
 	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/redact"
 )
 
+// MessageType identifies a kind of outgoing email for per-type sender overrides
+type MessageType string
+
+const (
+	MessageAdminNotification MessageType = "admin_notification"
+	MessageVerification      MessageType = "verification"
+	// MessageTest is the admin email-configuration check; it is sent with
+	// the admin notification sender
+	MessageTest MessageType = "test"
+)
+
+const (
+	// sendAttempts is how many times a send is tried before giving up on
+	// a transient failure
+	sendAttempts = 3
+	// maxRetryDelay caps how long we honour a rate limit's retry-after
+	maxRetryDelay = 10 * time.Second
+)
+
+// emailSender is the part of the Resend client used to send mail
+type emailSender interface {
+	SendWithContext(ctx context.Context, params *resend.SendEmailRequest) (*resend.SendEmailResponse, error)
+}
+
+// DeliveryLog records the outcome of each send
+type DeliveryLog interface {
+	Create(ctx context.Context, entry *model.EmailLog) error
+}
+
+// Sender is the from address used for a message type
+type Sender struct {
+	Email string
+	Name  string
+}
+
 // Service handles email sending via Resend
 type Service struct {
-	apiKey     string
-	fromEmail  string
-	fromName   string
-	appBaseURL string
+	apiKey      string
+	fromEmail   string
+	fromName    string
+	appBaseURL  string
+	senders     map[MessageType]Sender
+	emails      emailSender
+	deliveryLog DeliveryLog
+	retryDelay  time.Duration
 }
 
 // NewService creates a new email service
@@ -27,7 +71,35 @@ func NewService(apiKey, fromEmail, fromName, appBaseURL string) *Service {
 		fromEmail:  fromEmail,
 		fromName:   fromName,
 		appBaseURL: appBaseURL,
+		senders:    make(map[MessageType]Sender),
+		emails:     resend.NewClient(apiKey).Emails,
+		retryDelay: time.Second,
+	}
+}
+
+// SetDeliveryLog records every send, successful or not, to deliveryLog
+func (s *Service) SetDeliveryLog(deliveryLog DeliveryLog) {
+	s.deliveryLog = deliveryLog
+}
+
+// SetSender overrides the from address for a message type. Empty fields
+// fall back to the global from email/name.
+func (s *Service) SetSender(msgType MessageType, fromEmail, fromName string) {
+	s.senders[msgType] = Sender{Email: fromEmail, Name: fromName}
+}
+
+// from returns the formatted from header for a message type
+func (s *Service) from(msgType MessageType) string {
+	fromEmail, fromName := s.fromEmail, s.fromName
+	if sender, ok := s.senders[msgType]; ok {
+		if sender.Email != "" {
+			fromEmail = sender.Email
+		}
+		if sender.Name != "" {
+			fromName = sender.Name
+		}
 	}
+	return fmt.Sprintf("%s <%s>", fromName, fromEmail)
 }
 
 // IsConfigured returns true if the email service has required configuration
@@ -35,9 +107,99 @@ func (s *Service) IsConfigured() bool {
 	return s.apiKey != "" && s.fromEmail != ""
 }
 
-// SendAdminNotification sends a notification to all admins about a new registration request
+// send delivers params to a single recipient, retrying transient failures
+// with exponential backoff, and records the outcome in the delivery log.
+// It returns the Resend message ID on success.
+func (s *Service) send(ctx context.Context, msgType MessageType, params *resend.SendEmailRequest) (string, error) {
+	var (
+		sent     *resend.SendEmailResponse
+		err      error
+		attempts int
+	)
+	delay := s.retryDelay
+	for attempts = 1; ; attempts++ {
+		sent, err = s.emails.SendWithContext(ctx, params)
+		if err == nil || attempts == sendAttempts || !isTransient(err) {
+			break
+		}
+		wait := delay
+		if d, ok := retryAfter(err); ok {
+			wait = d
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		delay *= 2
+	}
+
+	entry := &model.EmailLog{
+		Recipient:   params.To[0],
+		MessageType: string(msgType),
+		Status:      model.EmailStatusSent,
+		Attempts:    attempts,
+	}
+	if err != nil {
+		err = fmt.Errorf("resend error: %w", err)
+		msg := err.Error()
+		entry.Status = model.EmailStatusFailed
+		entry.Error = &msg
+	} else {
+		entry.MessageID = &sent.Id
+	}
+	s.logDelivery(entry)
+
+	if err != nil {
+		return "", err
+	}
+	return sent.Id, nil
+}
+
+// logDelivery writes entry to the delivery log, if one is set. It uses its
+// own context so a send that timed out is still recorded.
+func (s *Service) logDelivery(entry *model.EmailLog) {
+	if s.deliveryLog == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.deliveryLog.Create(ctx, entry); err != nil {
+		log.Printf("Failed to record %s email to %s: %v", entry.MessageType, redact.Email(entry.Recipient), err)
+	}
+}
+
+// isTransient reports whether a send error is worth retrying. Resend's
+// client only types rate limits; other API errors come back as plain
+// strings, so beyond 429s we retry only network failures.
+func isTransient(err error) bool {
+	if errors.Is(err, resend.ErrRateLimit) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryAfter returns the wait a rate limit response asked for, capped at
+// maxRetryDelay
+func retryAfter(err error) (time.Duration, bool) {
+	var rl *resend.RateLimitError
+	if !errors.As(err, &rl) {
+		return 0, false
+	}
+	secs, convErr := strconv.Atoi(rl.RetryAfter)
+	if convErr != nil || secs < 0 {
+		return 0, false
+	}
+	return min(time.Duration(secs)*time.Second, maxRetryDelay), true
+}
+
+// SendAdminNotification sends a notification to all admins about a new registration request.
+// linkTTL is how long the approve/reject links stay valid, quoted in the email.
 // Returns the number of emails that failed to send
-func (s *Service) SendAdminNotification(adminEmails []string, request *model.RegistrationRequest) int {
+func (s *Service) SendAdminNotification(adminEmails []string, request *model.RegistrationRequest, linkTTL time.Duration) int {
 	if !s.IsConfigured() {
 		log.Println("Email service not configured, skipping admin notification")
 		return len(adminEmails)
@@ -45,8 +207,8 @@ func (s *Service) SendAdminNotification(adminEmails []string, request *model.Reg
 
 	failures := 0
 	for _, adminEmail := range adminEmails {
-		if err := s.sendAdminEmail(adminEmail, request); err != nil {
-			log.Printf("Failed to send admin notification to %s: %v", adminEmail, err)
+		if err := s.sendAdminEmail(adminEmail, request, linkTTL); err != nil {
+			log.Printf("Failed to send admin notification to %s: %v", redact.Email(adminEmail), err)
 			failures++
 			// Continue sending to other admins even if one fails
 		}
@@ -54,47 +216,66 @@ func (s *Service) SendAdminNotification(adminEmails []string, request *model.Reg
 	return failures
 }
 
-func (s *Service) sendAdminEmail(adminEmail string, request *model.RegistrationRequest) error {
-	client := resend.NewClient(s.apiKey)
+func (s *Service) sendAdminEmail(adminEmail string, request *model.RegistrationRequest, linkTTL time.Duration) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	approveURL := fmt.Sprintf("%s/registration/action/%s?action=approve", s.appBaseURL, request.ApprovalToken)
 	rejectURL := fmt.Sprintf("%s/registration/action/%s?action=reject", s.appBaseURL, request.ApprovalToken)
 
-	htmlContent := s.buildAdminEmailHTML(request, approveURL, rejectURL)
-	plainContent := s.buildAdminEmailPlain(request, approveURL, rejectURL)
-
-	from := fmt.Sprintf("%s <%s>", s.fromName, s.fromEmail)
+	expiresIn := humanDuration(linkTTL)
+	htmlContent := s.buildAdminEmailHTML(request, approveURL, rejectURL, expiresIn)
+	plainContent := s.buildAdminEmailPlain(request, approveURL, rejectURL, expiresIn)
 
 	params := &resend.SendEmailRequest{
-		From:    from,
+		From:    s.from(MessageAdminNotification),
 		To:      []string{adminEmail},
 		Subject: fmt.Sprintf("New Staff Registration Request: %s", request.Name),
 		Html:    htmlContent,
 		Text:    plainContent,
 	}
 
-	sent, err := client.Emails.SendWithContext(ctx, params)
+	id, err := s.send(ctx, MessageAdminNotification, params)
 	if err != nil {
-		return fmt.Errorf("resend error: %w", err)
+		return err
 	}
 
 	if os.Getenv("DEBUG") != "" {
-		log.Printf("Email sent to %s: %s", adminEmail, sent.Id)
+		log.Printf("Email sent to %s: %s", redact.Email(adminEmail), id)
 	}
 
 	return nil
 }
 
-func (s *Service) buildAdminEmailHTML(request *model.RegistrationRequest, approveURL, rejectURL string) string {
+// humanDuration renders d as whole days when it divides evenly, else hours
+func humanDuration(d time.Duration) string {
+	hours := int(d.Round(time.Hour) / time.Hour)
+	if hours < 1 {
+		hours = 1
+	}
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	if hours%24 == 0 {
+		return plural(hours/24, "day")
+	}
+	return plural(hours, "hour")
+}
+
+// buildAdminEmailHTML renders the admin notification. Every interpolated
+// value is HTML-escaped, since name, email, mobile and address come straight
+// from the public registration form.
+func (s *Service) buildAdminEmailHTML(request *model.RegistrationRequest, approveURL, rejectURL, expiresIn string) string {
 	mobile := ""
 	if request.Mobile != nil {
-		mobile = *request.Mobile
+		mobile = html.EscapeString(*request.Mobile)
 	}
 	address := ""
 	if request.Address != nil {
-		address = *request.Address
+		address = html.EscapeString(*request.Address)
 	}
 
 	mobileRow := ""
@@ -148,23 +329,24 @@ func (s *Service) buildAdminEmailHTML(request *model.RegistrationRequest, approv
         </div>
 
         <div style="margin-top: 24px; font-size: 12px; color: #666; text-align: center;">
-            <p>This link expires in 7 days.</p>
+            <p>This link expires in %s.</p>
             <p>Finchley Foodbank Staff System</p>
         </div>
     </div>
 </body>
 </html>`,
-		request.Name,
-		request.Email,
+		html.EscapeString(request.Name),
+		html.EscapeString(request.Email),
 		mobileRow,
 		addressRow,
 		request.CreatedAt.Format("2 Jan 2006 at 3:04 PM"),
-		approveURL,
-		rejectURL,
+		html.EscapeString(approveURL),
+		html.EscapeString(rejectURL),
+		html.EscapeString(expiresIn),
 	)
 }
 
-func (s *Service) buildAdminEmailPlain(request *model.RegistrationRequest, approveURL, rejectURL string) string {
+func (s *Service) buildAdminEmailPlain(request *model.RegistrationRequest, approveURL, rejectURL, expiresIn string) string {
 	mobile := ""
 	if request.Mobile != nil {
 		mobile = fmt.Sprintf("\nMobile: %s", *request.Mobile)
@@ -188,7 +370,7 @@ To approve this request, visit:
 To reject this request, visit:
 %s
 
-This link expires in 7 days.
+This link expires in %s.
 
 Finchley Foodbank Staff System`,
 		request.Name,
@@ -198,6 +380,7 @@ Finchley Foodbank Staff System`,
 		request.CreatedAt.Format("2 Jan 2006 at 3:04 PM"),
 		approveURL,
 		rejectURL,
+		expiresIn,
 	)
 }
 
@@ -208,30 +391,27 @@ func (s *Service) SendVerificationCode(toEmail, staffName, code string) error {
 		return fmt.Errorf("email service not configured")
 	}
 
-	client := resend.NewClient(s.apiKey)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	htmlContent := s.buildVerificationEmailHTML(staffName, code)
 	plainContent := s.buildVerificationEmailPlain(staffName, code)
 
-	from := fmt.Sprintf("%s <%s>", s.fromName, s.fromEmail)
-
 	params := &resend.SendEmailRequest{
-		From:    from,
+		From:    s.from(MessageVerification),
 		To:      []string{toEmail},
 		Subject: "Verify your email - Finchley Foodbank",
 		Html:    htmlContent,
 		Text:    plainContent,
 	}
 
-	sent, err := client.Emails.SendWithContext(ctx, params)
+	id, err := s.send(ctx, MessageVerification, params)
 	if err != nil {
-		return fmt.Errorf("resend error: %w", err)
+		return err
 	}
 
 	if os.Getenv("DEBUG") != "" {
-		log.Printf("Verification email sent to %s: %s", toEmail, sent.Id)
+		log.Printf("Verification email sent to %s: %s", redact.Email(toEmail), id)
 	}
 
 	return nil
@@ -259,7 +439,7 @@ func (s *Service) buildVerificationEmailHTML(staffName, code string) string {
         </div>
     </div>
 </body>
-</html>`, staffName, code)
+</html>`, html.EscapeString(staffName), html.EscapeString(code))
 }
 
 func (s *Service) buildVerificationEmailPlain(staffName, code string) string {
@@ -275,3 +455,31 @@ This code expires in 15 minutes.
 
 Finchley Foodbank Staff System`, staffName, code)
 }
+
+// SendTestEmail sends a short message confirming the email configuration works
+func (s *Service) SendTestEmail(toEmail string) error {
+	if !s.IsConfigured() {
+		return fmt.Errorf("email service not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	params := &resend.SendEmailRequest{
+		From:    s.from(MessageAdminNotification),
+		To:      []string{toEmail},
+		Subject: "Test email - Finchley Foodbank",
+		Text:    "This is a test email from the Finchley Foodbank Staff System.\n\nIf you received it, email sending is configured correctly.",
+	}
+
+	id, err := s.send(ctx, MessageTest, params)
+	if err != nil {
+		return err
+	}
+
+	if os.Getenv("DEBUG") != "" {
+		log.Printf("Test email sent to %s: %s", redact.Email(toEmail), id)
+	}
+
+	return nil
+}
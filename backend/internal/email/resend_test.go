@@ -0,0 +1,106 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/resend/resend-go/v2"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+)
+
+// fakeSender fails with each of errs in turn, then succeeds
+type fakeSender struct {
+	errs  []error
+	calls int
+}
+
+func (f *fakeSender) SendWithContext(ctx context.Context, params *resend.SendEmailRequest) (*resend.SendEmailResponse, error) {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return nil, f.errs[f.calls-1]
+	}
+	return &resend.SendEmailResponse{Id: "msg_123"}, nil
+}
+
+type fakeDeliveryLog struct {
+	entries []*model.EmailLog
+}
+
+func (f *fakeDeliveryLog) Create(ctx context.Context, entry *model.EmailLog) error {
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func TestSendRetriesTransientFailures(t *testing.T) {
+	rateLimited := &resend.RateLimitError{Message: "slow down", RetryAfter: "0"}
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantAttempts int
+		wantStatus   string
+	}{
+		{"succeeds first time", nil, 1, model.EmailStatusSent},
+		{"rate limited then sent", []error{rateLimited, rateLimited}, 3, model.EmailStatusSent},
+		{"rate limited every time", []error{rateLimited, rateLimited, rateLimited}, sendAttempts, model.EmailStatusFailed},
+		{"permanent failure is not retried", []error{errors.New("invalid from address")}, 1, model.EmailStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeSender{errs: tt.errs}
+			deliveries := &fakeDeliveryLog{}
+			s := NewService("key", "from@example.com", "Foodbank", "https://example.com")
+			s.emails = sender
+			s.retryDelay = 0
+			s.SetDeliveryLog(deliveries)
+
+			_, err := s.send(context.Background(), MessageTest, &resend.SendEmailRequest{To: []string{"to@example.com"}})
+			if (err == nil) != (tt.wantStatus == model.EmailStatusSent) {
+				t.Errorf("err = %v, want status %s", err, tt.wantStatus)
+			}
+			if sender.calls != tt.wantAttempts {
+				t.Errorf("sender called %d times, want %d", sender.calls, tt.wantAttempts)
+			}
+			if len(deliveries.entries) != 1 {
+				t.Fatalf("logged %d deliveries, want 1", len(deliveries.entries))
+			}
+			entry := deliveries.entries[0]
+			if entry.Attempts != tt.wantAttempts || entry.Status != tt.wantStatus {
+				t.Errorf("logged attempts %d status %s, want %d %s", entry.Attempts, entry.Status, tt.wantAttempts, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestEmailHTMLEscapesUserInput(t *testing.T) {
+	s := NewService("key", "from@example.com", "Foodbank", "https://example.com")
+	name := `<script>alert("hi")</script>`
+	mobile := "<b>07700</b>"
+	address := "1 <i>High</i> St"
+	request := &model.RegistrationRequest{Name: name, Email: "a&b@example.com", Mobile: &mobile, Address: &address}
+
+	tests := []struct {
+		name string
+		html string
+	}{
+		{"admin notification", s.buildAdminEmailHTML(request, "https://example.com/approve", "https://example.com/reject", "7 days")},
+		{"verification code", s.buildVerificationEmailHTML(name, "123456")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, raw := range []string{"<script>", "<b>", "<i>", "a&b@"} {
+				if strings.Contains(tt.html, raw) {
+					t.Errorf("rendered HTML contains unescaped %q", raw)
+				}
+			}
+			if !strings.Contains(tt.html, "&lt;script&gt;") {
+				t.Error("rendered HTML is missing the escaped name")
+			}
+		})
+	}
+}
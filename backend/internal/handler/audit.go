@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -39,16 +44,31 @@ func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	tableName := query.Get("table")
+	filter, ok := parseAuditFilter(w, r)
+	if !ok {
+		return
+	}
 
-	var recordID *uuid.UUID
-	if rid := query.Get("record_id"); rid != "" {
-		if parsed, err := uuid.Parse(rid); err == nil {
-			recordID = &parsed
+	// Keyset cursors take precedence over offset for deep history
+	for _, c := range []struct {
+		param string
+		dst   **model.AuditCursor
+	}{{"after", &filter.After}, {"before", &filter.Before}} {
+		if v := query.Get(c.param); v != "" {
+			cursor, err := model.ParseAuditCursor(v)
+			if err != nil {
+				http.Error(w, "Invalid "+c.param+" cursor", http.StatusBadRequest)
+				return
+			}
+			*c.dst = cursor
 		}
 	}
+	if filter.After != nil && filter.Before != nil {
+		http.Error(w, "Use either after or before, not both", http.StatusBadRequest)
+		return
+	}
 
-	logs, total, err := h.auditRepo.List(r.Context(), tableName, recordID, limit, offset)
+	logs, total, err := h.auditRepo.List(r.Context(), filter, limit, offset)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -59,16 +79,115 @@ func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := model.AuditLogListResponse{
-		Logs:   logs,
-		Total:  total,
-		Limit:  limit,
-		Offset: offset,
+		Logs:       logs,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		Pagination: model.NewPagination(total, limit, offset),
+	}
+	if len(logs) > 0 {
+		// A full page may have more after it; a before page always does
+		if len(logs) == limit || filter.Before != nil {
+			response.NextCursor = model.EncodeAuditCursor(&logs[len(logs)-1])
+		}
+		if filter.After != nil || filter.Before != nil || offset > 0 {
+			response.PrevCursor = model.EncodeAuditCursor(&logs[0])
+		}
+	}
+	if filter.After != nil || filter.Before != nil {
+		// Offsets don't apply to keyset pages, so follow the cursors instead
+		response.HasNext = response.NextCursor != ""
+		response.HasPrev = response.PrevCursor != ""
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// parseAuditFilter reads the table, record_id, changed_by, from, to and
+// order query parameters shared by List and Export. It writes a 400 and
+// returns false when one is malformed.
+func parseAuditFilter(w http.ResponseWriter, r *http.Request) (model.AuditLogFilter, bool) {
+	query := r.URL.Query()
+
+	filter := model.AuditLogFilter{
+		TableName: query.Get("table"),
+		Order:     query.Get("order"),
+	}
+
+	if rid := query.Get("record_id"); rid != "" {
+		if parsed, err := uuid.Parse(rid); err == nil {
+			filter.RecordID = &parsed
+		}
+	}
+
+	if filter.Order != "" && filter.Order != "asc" && filter.Order != "desc" {
+		http.Error(w, "Invalid order (expected asc or desc)", http.StatusBadRequest)
+		return filter, false
+	}
+
+	if cb := query.Get("changed_by"); cb != "" {
+		parsed, err := uuid.Parse(cb)
+		if err != nil {
+			http.Error(w, "Invalid changed_by", http.StatusBadRequest)
+			return filter, false
+		}
+		filter.ChangedBy = &parsed
+	}
+
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return filter, false
+	}
+	if !from.IsZero() {
+		filter.From = &from
+	}
+	if !to.IsZero() {
+		filter.To = &to
+	}
+
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		http.Error(w, "Invalid date range (to is before from)", http.StatusBadRequest)
+		return filter, false
+	}
+
+	return filter, true
+}
+
+// Export streams the audit logs matching the List filters as a CSV file,
+// oldest first. Rows are written as they are read, so once the header has
+// gone out a failure can only be logged and the file is left truncated.
+func (h *AuditHandler) Export(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseAuditFilter(w, r)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("audit-log-%s.csv", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "changed_at", "table_name", "record_id", "record_name", "action",
+		"changed_by", "changed_by_name", "old_values", "new_values"})
+
+	err := h.auditRepo.ListForExport(r.Context(), filter, func(l *model.AuditLog) error {
+		return cw.Write([]string{
+			l.ID.String(), l.ChangedAt.Format(time.RFC3339), l.TableName, l.RecordID.String(),
+			l.RecordName, l.Action, l.ChangedBy.String(), l.ChangedByName,
+			string(l.OldValues), string(l.NewValues),
+		})
+	})
+	cw.Flush()
+	if err == nil {
+		err = cw.Error()
+	}
+	if err != nil {
+		log.Printf("Audit export failed: %v", err)
+	}
+}
+
 // GetByRecord returns audit logs for a specific record
 func (h *AuditHandler) GetByRecord(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "table")
@@ -93,3 +212,25 @@ func (h *AuditHandler) GetByRecord(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(logs)
 }
+
+// GetEntry returns a single audit entry by its id
+func (h *AuditHandler) GetEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid audit entry ID", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.auditRepo.GetByID(r.Context(), id)
+	if errors.Is(err, repository.ErrAuditEntryNotFound) {
+		http.Error(w, "Audit entry not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+func TestExportBoundsRowsByDate(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	auditRepo := repository.NewAuditRepository(db)
+
+	// One entry per moment, each on its own record so rows can be told apart
+	moments := []time.Time{
+		time.Date(2026, 1, 31, 23, 59, 59, 0, time.UTC),
+		time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 2, 14, 12, 0, 0, 0, time.UTC),
+		time.Date(2026, 2, 28, 23, 59, 59, 0, time.UTC),
+		time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	records := make([]string, len(moments))
+	for i, at := range moments {
+		id := uuid.New()
+		records[i] = id.String()
+		if err := auditRepo.Log(ctx, "clients", id, "UPDATE", nil, nil, model.SystemStaffID); err != nil {
+			t.Fatalf("log: %v", err)
+		}
+		if _, err := db.Exec(ctx, `UPDATE audit_log SET changed_at = $1 WHERE record_id = $2`, at, id); err != nil {
+			t.Fatalf("backdate: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/audit/export?from=2026-02-01T00:00:00Z&to=2026-02-28T23:59:59Z", nil)
+	rec := httptest.NewRecorder()
+	NewAuditHandler(auditRepo).Export(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse CSV: %v", err)
+	}
+	var got []string
+	for _, row := range rows[1:] {
+		got = append(got, row[3]) // record_id
+	}
+	// Both bounds are inclusive; oldest first
+	if want := records[1:4]; !reflect.DeepEqual(got, want) {
+		t.Errorf("exported records %v, want %v", got, want)
+	}
+}
@@ -1,29 +1,34 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/finchley-foodbank/foodbank/internal/barcode"
 	"github.com/finchley-foodbank/foodbank/internal/handler/middleware"
 	"github.com/finchley-foodbank/foodbank/internal/model"
 	"github.com/finchley-foodbank/foodbank/internal/repository"
 	"github.com/finchley-foodbank/foodbank/internal/service"
+	"github.com/finchley-foodbank/foodbank/internal/validate"
 )
 
 type ClientHandler struct {
 	clientService *service.ClientService
-	staffService  *service.StaffService
 }
 
-func NewClientHandler(clientService *service.ClientService, staffService *service.StaffService) *ClientHandler {
+func NewClientHandler(clientService *service.ClientService) *ClientHandler {
 	return &ClientHandler{
 		clientService: clientService,
-		staffService:  staffService,
 	}
 }
 
@@ -32,6 +37,15 @@ type ClientListResponse struct {
 	Total   int            `json:"total"`
 	Limit   int            `json:"limit"`
 	Offset  int            `json:"offset"`
+	model.Pagination
+}
+
+type AttendanceListResponse struct {
+	Attendance []model.AttendanceWithDetails `json:"attendance"`
+	Total      int                           `json:"total"`
+	Limit      int                           `json:"limit"`
+	Offset     int                           `json:"offset"`
+	model.Pagination
 }
 
 // Create registers a new client
@@ -43,21 +57,28 @@ func (h *ClientHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req model.CreateClientRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), decodeStatus(err))
 		return
 	}
 
-	if req.Name == "" || req.Address == "" {
-		http.Error(w, "Name and address are required", http.StatusBadRequest)
+	client, err := h.clientService.Create(r.Context(), &req, staffID)
+	if writeValidationError(w, err) {
 		return
 	}
-
-	if req.FamilySize < 1 {
-		req.FamilySize = 1
+	var dup *service.DuplicateClientError
+	if errors.As(err, &dup) {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":       "duplicate_client",
+			"message":     service.ErrDuplicateClient.Error(),
+			"existing_id": dup.ExistingID,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrBarcodeExhausted) {
+		http.Error(w, "Could not allocate a unique barcode, please try again", http.StatusServiceUnavailable)
+		return
 	}
-
-	client, err := h.clientService.Create(r.Context(), &req, staffID)
 	if err != nil {
 		http.Error(w, "Failed to create client", http.StatusInternalServerError)
 		return
@@ -91,6 +112,90 @@ func (h *ClientHandler) Get(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(client)
 }
 
+// Barcode image sizing: each module is scale pixels wide. QR modules are
+// square and far fewer, so they default larger.
+const (
+	defaultBarcodeScale   = 2
+	defaultQRScale        = 8
+	maxBarcodeScale       = 10
+	barcodeHeightPerScale = 40
+)
+
+// GetProfile returns a client with recent attendance and audit entries
+func (h *ClientHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := h.clientService.GetProfile(r.Context(), id)
+	if errors.Is(err, repository.ErrClientNotFound) {
+		http.Error(w, "Client not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+// BarcodeImage renders the client's barcode_id as a PNG for printed cards,
+// as format=code128 (the default) or format=qr
+func (h *ClientHandler) BarcodeImage(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	scale := defaultBarcodeScale
+	switch format {
+	case "", "code128":
+	case "qr":
+		scale = defaultQRScale
+	default:
+		http.Error(w, "Invalid format (expected code128 or qr)", http.StatusBadRequest)
+		return
+	}
+
+	if v := r.URL.Query().Get("scale"); v != "" {
+		scale, err = strconv.Atoi(v)
+		if err != nil || scale < 1 || scale > maxBarcodeScale {
+			http.Error(w, fmt.Sprintf("Invalid scale (expected 1-%d)", maxBarcodeScale), http.StatusBadRequest)
+			return
+		}
+	}
+
+	client, err := h.clientService.GetByID(r.Context(), id)
+	if errors.Is(err, repository.ErrClientNotFound) {
+		http.Error(w, "Client not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if format == "qr" {
+		err = barcode.WriteQRPNG(&buf, client.BarcodeID, scale)
+	} else {
+		err = barcode.WriteCode128PNG(&buf, client.BarcodeID, scale, barcodeHeightPerScale*scale)
+	}
+	if err != nil {
+		http.Error(w, "Failed to render barcode", http.StatusInternalServerError)
+		return
+	}
+
+	// A client's barcode never changes, so the image can be cached for a day
+	writeCacheable(w, r, "image/png", buf.Bytes(), 24*time.Hour)
+}
+
 // GetByBarcode returns a client by barcode ID
 func (h *ClientHandler) GetByBarcode(w http.ResponseWriter, r *http.Request) {
 	barcodeID := chi.URLParam(r, "code")
@@ -113,31 +218,62 @@ func (h *ClientHandler) GetByBarcode(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(client)
 }
 
+// BarcodeExists reports whether a barcode is registered, without returning
+// any client details (API key auth, for kiosk/scanner integrations)
+func (h *ClientHandler) BarcodeExists(w http.ResponseWriter, r *http.Request) {
+	barcodeID := chi.URLParam(r, "code")
+	if barcodeID == "" {
+		http.Error(w, "Barcode ID is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.clientService.LookupBarcode(r.Context(), barcodeID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
 // List returns paginated clients, with optional search
 func (h *ClientHandler) List(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	includeArchived := r.URL.Query().Get("include_archived") == "true"
 
 	if limit <= 0 {
 		limit = 20
 	}
 
+	params := &model.ClientSearchParams{
+		Query:           query,
+		IncludeNotes:    r.URL.Query().Get("include_notes") == "true",
+		IncludeArchived: includeArchived,
+		Limit:           limit,
+		Offset:          offset,
+	}
+	if err := parseClientFilters(r, params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var clients []model.Client
 	var total int
 	var err error
 
-	if query != "" {
-		params := &model.ClientSearchParams{
-			Query:  query,
-			Limit:  limit,
-			Offset: offset,
-		}
+	if query != "" || params.HasFilters() {
 		clients, total, err = h.clientService.Search(r.Context(), params)
 	} else {
-		clients, total, err = h.clientService.List(r.Context(), limit, offset)
+		clients, total, err = h.clientService.List(r.Context(), limit, offset, includeArchived, params.Sort)
 	}
 
+	if repository.IsStatementTimeout(r.Context(), err) {
+		http.Error(w, "Database query timed out", http.StatusServiceUnavailable)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -149,13 +285,132 @@ func (h *ClientHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(ClientListResponse{
-		Clients: clients,
-		Total:   total,
-		Limit:   limit,
-		Offset:  offset,
+		Clients:    clients,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		Pagination: model.NewPagination(total, limit, offset),
 	})
 }
 
+// parseClientFilters reads ?appointment_day=, the ?pref_*= booleans and
+// ?sort= into params
+func parseClientFilters(r *http.Request, params *model.ClientSearchParams) error {
+	q := r.URL.Query()
+	if sort := q.Get("sort"); sort != "" {
+		if !model.ValidClientSort(sort) {
+			return fmt.Errorf("Invalid sort (expected one of %s)", strings.Join(model.ClientSortKeys, ", "))
+		}
+		params.Sort = sort
+	}
+	if day := q.Get("appointment_day"); day != "" {
+		normalized, err := validate.AppointmentDay(day)
+		if err != nil {
+			return fmt.Errorf("Invalid appointment_day (expected Monday-Saturday)")
+		}
+		params.AppointmentDay = &normalized
+	}
+
+	prefs := []struct {
+		name string
+		dst  **bool
+	}{
+		{"pref_gluten_free", &params.PrefGlutenFree},
+		{"pref_halal", &params.PrefHalal},
+		{"pref_vegetarian", &params.PrefVegetarian},
+		{"pref_no_cooking", &params.PrefNoCooking},
+	}
+	for _, p := range prefs {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("Invalid %s (expected true or false)", p.name)
+		}
+		*p.dst = &b
+	}
+
+	ages := []struct {
+		name string
+		dst  **int
+	}{
+		{"child_age_min", &params.ChildAgeMin},
+		{"child_age_max", &params.ChildAgeMax},
+	}
+	for _, a := range ages {
+		v := q.Get(a.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("Invalid %s (expected a whole number of years)", a.name)
+		}
+		*a.dst = &n
+	}
+	if params.ChildAgeMin != nil && params.ChildAgeMax != nil && *params.ChildAgeMin > *params.ChildAgeMax {
+		return fmt.Errorf("child_age_min cannot be greater than child_age_max")
+	}
+	return nil
+}
+
+// Count returns {"count": N} for clients matching the same filters as List
+func (h *ClientHandler) Count(w http.ResponseWriter, r *http.Request) {
+	params := &model.ClientSearchParams{
+		Query:           r.URL.Query().Get("q"),
+		IncludeNotes:    r.URL.Query().Get("include_notes") == "true",
+		IncludeArchived: r.URL.Query().Get("include_archived") == "true",
+	}
+	if err := parseClientFilters(r, params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	count, err := h.clientService.Count(r.Context(), params)
+	if repository.IsStatementTimeout(r.Context(), err) {
+		http.Error(w, "Database query timed out", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to count clients", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"count": count})
+}
+
+// Export downloads the client roster, optionally narrowed by ?q=, as one CSV
+func (h *ClientHandler) Export(w http.ResponseWriter, r *http.Request) {
+	params := &model.ClientSearchParams{
+		Query:           r.URL.Query().Get("q"),
+		IncludeNotes:    r.URL.Query().Get("include_notes") == "true",
+		IncludeArchived: r.URL.Query().Get("include_archived") == "true",
+	}
+	if err := parseClientFilters(r, params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.clientService.ExportCSV(r.Context(), params)
+	if repository.IsStatementTimeout(r.Context(), err) {
+		http.Error(w, "Database query timed out", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to export clients", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("clients-%s.csv", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.Write(data)
+}
+
 // Update updates a client's details
 func (h *ClientHandler) Update(w http.ResponseWriter, r *http.Request) {
 	staffID, err := h.getStaffIDFromContext(r)
@@ -172,16 +427,107 @@ func (h *ClientHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req model.UpdateClientRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), decodeStatus(err))
 		return
 	}
 
+	// If-Unmodified-Since is the header form of expected_updated_at; the body
+	// field wins when both are sent
+	if ius := r.Header.Get("If-Unmodified-Since"); ius != "" && req.ExpectedUpdatedAt == nil {
+		t, err := http.ParseTime(ius)
+		if err != nil {
+			http.Error(w, "Invalid If-Unmodified-Since header", http.StatusBadRequest)
+			return
+		}
+		// HTTP dates only carry whole seconds, so a change within that
+		// second still counts as unmodified
+		t = t.Add(time.Second - time.Nanosecond)
+		req.ExpectedUpdatedAt = &t
+	}
+
 	client, err := h.clientService.Update(r.Context(), id, &req, staffID)
 	if errors.Is(err, repository.ErrClientNotFound) {
 		http.Error(w, "Client not found", http.StatusNotFound)
 		return
 	}
+	if errors.Is(err, repository.ErrStaleClient) {
+		http.Error(w, "Client has been modified by someone else; reload and try again", http.StatusConflict)
+		return
+	}
+	if writeValidationError(w, err) {
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(client)
+}
+
+// Archive soft-deletes a client; the record stays retrievable by ID
+func (h *ClientHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+// Unarchive restores an archived client
+func (h *ClientHandler) Unarchive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+func (h *ClientHandler) setArchived(w http.ResponseWriter, r *http.Request, archive bool) {
+	staffID, err := h.getStaffIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	var client *model.Client
+	if archive {
+		client, err = h.clientService.Archive(r.Context(), id, staffID)
+	} else {
+		client, err = h.clientService.Unarchive(r.Context(), id, staffID)
+	}
+	if errors.Is(err, repository.ErrClientNotFound) {
+		http.Error(w, "Client not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(client)
+}
+
+// RemovePhoto clears a client's photo
+func (h *ClientHandler) RemovePhoto(w http.ResponseWriter, r *http.Request) {
+	staffID, err := h.getStaffIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	client, err := h.clientService.RemovePhoto(r.Context(), id, staffID)
+	if errors.Is(err, repository.ErrClientNotFound) {
+		http.Error(w, "Client not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -191,6 +537,133 @@ func (h *ClientHandler) Update(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(client)
 }
 
+// maxBatchUpdateIDs caps how many clients a single batch update may touch
+const maxBatchUpdateIDs = 500
+
+// BatchUpdate applies the same partial update to a list of clients
+func (h *ClientHandler) BatchUpdate(w http.ResponseWriter, r *http.Request) {
+	staffID, err := h.getStaffIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.BatchUpdateClientsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), decodeStatus(err))
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		http.Error(w, "At least one client ID is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) > maxBatchUpdateIDs {
+		http.Error(w, fmt.Sprintf("Too many clients: %d (max %d)", len(req.IDs), maxBatchUpdateIDs), http.StatusBadRequest)
+		return
+	}
+	if req.Update.ExpectedUpdatedAt != nil {
+		http.Error(w, "expected_updated_at is not supported for batch updates", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.clientService.BatchUpdate(r.Context(), req.IDs, &req.Update, staffID)
+	if writeValidationError(w, err) {
+		return
+	}
+	if repository.IsStatementTimeout(r.Context(), err) {
+		http.Error(w, "Database query timed out", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// maxBulkAttendanceBarcodes caps how many barcodes a single bulk request may record
+const maxBulkAttendanceBarcodes = 500
+
+// BulkRecordAttendance records visits for a list of scanned barcodes
+func (h *ClientHandler) BulkRecordAttendance(w http.ResponseWriter, r *http.Request) {
+	staffID, err := h.getStaffIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req model.BulkAttendanceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), decodeStatus(err))
+		return
+	}
+
+	if len(req.Barcodes) == 0 {
+		http.Error(w, "At least one barcode is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Barcodes) > maxBulkAttendanceBarcodes {
+		http.Error(w, fmt.Sprintf("Too many barcodes: %d (max %d)", len(req.Barcodes), maxBulkAttendanceBarcodes), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.clientService.RecordAttendanceBulk(r.Context(), req.Barcodes, staffID)
+	if repository.IsStatementTimeout(r.Context(), err) {
+		http.Error(w, "Database query timed out", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// maxAttendanceImportBytes caps the body size of an attendance import
+const maxAttendanceImportBytes = 1 << 20
+
+// ImportAttendance records visits from an offline session. The body is a CSV
+// or plain list with one barcode per line and an optional scan time.
+func (h *ClientHandler) ImportAttendance(w http.ResponseWriter, r *http.Request) {
+	staffID, err := h.getStaffIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxAttendanceImportBytes)
+	resp, err := h.clientService.ImportAttendance(r.Context(), body, staffID)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		http.Error(w, fmt.Sprintf("Body too large (max %d bytes)", maxAttendanceImportBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if errors.Is(err, service.ErrTooManyAttendanceLines) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if repository.IsStatementTimeout(r.Context(), err) {
+		http.Error(w, "Database query timed out", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if len(resp.Results) == 0 {
+		http.Error(w, "At least one barcode is required", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
 // RecordAttendance records a client's visit
 func (h *ClientHandler) RecordAttendance(w http.ResponseWriter, r *http.Request) {
 	staffID, err := h.getStaffIDFromContext(r)
@@ -206,21 +679,189 @@ func (h *ClientHandler) RecordAttendance(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	attendance, err := h.clientService.RecordAttendance(r.Context(), clientID, staffID)
+	// Only admins may record a visit inside the cooldown
+	override := r.URL.Query().Get("override") == "true"
+	if override {
+		if staff := middleware.GetStaffFromContext(r.Context()); staff == nil || staff.Role != model.RoleAdmin {
+			http.Error(w, "Only admins can override the attendance cooldown", http.StatusForbidden)
+			return
+		}
+	}
+
+	// The body is optional; it only carries the override reason
+	var req model.RecordAttendanceRequest
+	if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, err.Error(), decodeStatus(err))
+		return
+	}
+
+	attendance, created, err := h.clientService.RecordAttendance(r.Context(), clientID, staffID, override, req.Reason)
+	if errors.Is(err, service.ErrOverrideReasonRequired) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if errors.Is(err, repository.ErrClientNotFound) {
 		http.Error(w, "Client not found", http.StatusNotFound)
 		return
 	}
+	var tooSoon *service.AttendanceTooSoonError
+	if errors.As(err, &tooSoon) {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":            "attendance_too_soon",
+			"last_visit_at":    tooSoon.LastVisit,
+			"next_eligible_at": tooSoon.NextEligible,
+		})
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// A repeat scan inside the dedup window returns the existing visit
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(attendance)
+}
+
+// VoidAttendance voids one of a client's attendance records
+func (h *ClientHandler) VoidAttendance(w http.ResponseWriter, r *http.Request) {
+	staffID, err := h.getStaffIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	clientID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+	attendanceID, err := uuid.Parse(chi.URLParam(r, "attendanceID"))
+	if err != nil {
+		http.Error(w, "Invalid attendance ID", http.StatusBadRequest)
+		return
+	}
+
+	attendance, err := h.clientService.VoidAttendance(r.Context(), clientID, attendanceID, staffID)
+	if errors.Is(err, repository.ErrAttendanceNotFound) {
+		http.Error(w, "Attendance not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(attendance)
 }
 
+// ListAppointments returns a client's appointment slots, primary first
+func (h *ClientHandler) ListAppointments(w http.ResponseWriter, r *http.Request) {
+	clientID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	appointments, err := h.clientService.ListAppointments(r.Context(), clientID)
+	if errors.Is(err, repository.ErrClientNotFound) {
+		http.Error(w, "Client not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if appointments == nil {
+		appointments = []model.ClientAppointment{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(appointments)
+}
+
+// AddAppointment adds an appointment slot for a client
+func (h *ClientHandler) AddAppointment(w http.ResponseWriter, r *http.Request) {
+	staffID, err := h.getStaffIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	clientID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+
+	var req model.CreateAppointmentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		http.Error(w, err.Error(), decodeStatus(err))
+		return
+	}
+
+	appointment, err := h.clientService.AddAppointment(r.Context(), clientID, &req, staffID)
+	if errors.Is(err, repository.ErrClientNotFound) {
+		http.Error(w, "Client not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repository.ErrDuplicateAppointment) {
+		http.Error(w, "Client already has this appointment slot", http.StatusConflict)
+		return
+	}
+	if writeValidationError(w, err) {
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(appointment)
+}
+
+// DeleteAppointment removes one of a client's appointment slots
+func (h *ClientHandler) DeleteAppointment(w http.ResponseWriter, r *http.Request) {
+	staffID, err := h.getStaffIDFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	clientID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid client ID", http.StatusBadRequest)
+		return
+	}
+	appointmentID, err := uuid.Parse(chi.URLParam(r, "appointmentID"))
+	if err != nil {
+		http.Error(w, "Invalid appointment ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.clientService.DeleteAppointment(r.Context(), clientID, appointmentID, staffID)
+	if errors.Is(err, repository.ErrAppointmentNotFound) {
+		http.Error(w, "Appointment not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // GetAttendanceHistory returns a client's attendance history
 func (h *ClientHandler) GetAttendanceHistory(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
@@ -235,7 +876,24 @@ func (h *ClientHandler) GetAttendanceHistory(w http.ResponseWriter, r *http.Requ
 		limit = 10
 	}
 
-	history, err := h.clientService.GetAttendanceHistory(r.Context(), clientID, limit)
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := model.AttendanceQuery{Limit: limit}
+	if !from.IsZero() {
+		q.From = &from
+	}
+	if !to.IsZero() {
+		q.To = &to
+	}
+	if q.From != nil && q.To != nil && q.To.Before(*q.From) {
+		http.Error(w, "to must not be before from", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.clientService.GetAttendanceHistory(r.Context(), clientID, q)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
@@ -249,24 +907,102 @@ func (h *ClientHandler) GetAttendanceHistory(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(history)
 }
 
-// getStaffIDFromContext retrieves the current staff member's ID from the auth context.
-// If the staff member doesn't exist, it creates them automatically.
-func (h *ClientHandler) getStaffIDFromContext(r *http.Request) (uuid.UUID, error) {
-	auth0ID := middleware.GetAuth0ID(r.Context())
-	if auth0ID == "" {
-		return uuid.Nil, errors.New("no auth0_id in context")
+// ListAttendance returns attendance across all clients for ?date=YYYY-MM-DD
+// (default today) or ?from=&to=, with day boundaries in the app timezone
+func (h *ClientHandler) ListAttendance(w http.ResponseWriter, r *http.Request) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if date := r.URL.Query().Get("date"); date != "" {
+		if from != "" || to != "" {
+			http.Error(w, "Use either date or from/to, not both", http.StatusBadRequest)
+			return
+		}
+		from, to = date, date
+	}
+	if from == "" && to != "" {
+		http.Error(w, "to requires from", http.StatusBadRequest)
+		return
 	}
 
-	email := middleware.GetAuth0Email(r.Context())
-	name := middleware.GetAuth0Name(r.Context())
-	if name == "" {
-		name = email
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	if offset < 0 {
+		offset = 0
 	}
 
-	// FindOrCreate will return existing staff or create new one
-	staff, _, err := h.staffService.FindOrCreate(r.Context(), auth0ID, name, email)
+	attendance, total, err := h.clientService.ListAttendanceByDate(r.Context(), from, to, limit, offset)
+	if errors.Is(err, service.ErrInvalidDateRange) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		return uuid.Nil, err
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if attendance == nil {
+		attendance = []model.AttendanceWithDetails{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(AttendanceListResponse{
+		Attendance: attendance,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		Pagination: model.NewPagination(total, limit, offset),
+	})
+}
+
+// AttendanceSummary returns visit totals per month for reporting
+func (h *ClientHandler) AttendanceSummary(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.clientService.AttendanceSummaryByMonth(r.Context(), from, to)
+	if errors.Is(err, service.ErrInvalidDateRange) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if summary == nil {
+		summary = []model.MonthlyAttendance{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// writeValidationError writes a 400 listing each invalid field when err is a
+// client validation error, and reports whether it did so
+func writeValidationError(w http.ResponseWriter, err error) bool {
+	var verr *service.ClientValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+	writeJSON(w, http.StatusBadRequest, model.ValidationErrorResponse{Errors: verr.Errors})
+	return true
+}
+
+// getStaffIDFromContext retrieves the current staff member's ID from the request context.
+// The staff record is resolved by the EnsureStaff middleware.
+func (h *ClientHandler) getStaffIDFromContext(r *http.Request) (uuid.UUID, error) {
+	staff := middleware.GetStaffFromContext(r.Context())
+	if staff == nil {
+		return uuid.Nil, errors.New("no staff in context")
 	}
 	return staff.ID, nil
 }
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/finchley-foodbank/foodbank/internal/handler/middleware"
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/service"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+func TestParseClientFiltersChildAge(t *testing.T) {
+	tests := []struct {
+		query   string
+		min     *int
+		max     *int
+		wantErr bool
+	}{
+		{query: "child_age_max=2", max: intPtr(2)},
+		{query: "child_age_min=5&child_age_max=8", min: intPtr(5), max: intPtr(8)},
+		{query: "child_age_min=abc", wantErr: true},
+		{query: "child_age_max=-1", wantErr: true},
+		{query: "child_age_min=9&child_age_max=3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/clients?"+tt.query, nil)
+			params := &model.ClientSearchParams{}
+			err := parseClientFilters(r, params)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !equalIntPtr(params.ChildAgeMin, tt.min) || !equalIntPtr(params.ChildAgeMax, tt.max) {
+				t.Errorf("range = %v..%v, want %v..%v", params.ChildAgeMin, params.ChildAgeMax, tt.min, tt.max)
+			}
+			// Without HasFilters List would take the unfiltered path
+			if !params.HasFilters() {
+				t.Error("HasFilters() = false with a child age bound set")
+			}
+		})
+	}
+}
+
+func TestBarcodeImage(t *testing.T) {
+	db := testdb.Open(t)
+	clients := service.NewClientService(repository.NewClientRepository(db), repository.NewAuditRepository(db), time.UTC)
+	client, err := clients.Create(context.Background(), &model.CreateClientRequest{
+		Name: "Card Holder", Address: "1 Card Lane", ConsentDataStorage: true,
+	}, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	h := NewClientHandler(clients)
+
+	tests := []struct {
+		name  string
+		id    string
+		query string
+		want  int
+	}{
+		{"code128 by default", client.ID.String(), "", http.StatusOK},
+		{"qr", client.ID.String(), "?format=qr&scale=4", http.StatusOK},
+		{"unknown format", client.ID.String(), "?format=pdf417", http.StatusBadRequest},
+		{"scale out of range", client.ID.String(), "?scale=99", http.StatusBadRequest},
+		{"unknown client", uuid.NewString(), "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withURLParam(httptest.NewRequest(http.MethodGet, "/api/clients/"+tt.id+"/barcode.png"+tt.query, nil), "id", tt.id)
+			rec := httptest.NewRecorder()
+			h.BarcodeImage(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "image/png" {
+				t.Errorf("Content-Type = %q, want image/png", ct)
+			}
+			img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+			if err != nil {
+				t.Fatalf("decode PNG: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() == 0 || b.Dy() == 0 {
+				t.Errorf("image is %v, want a non-empty barcode", b)
+			}
+		})
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	db := testdb.Open(t)
+	clients := service.NewClientService(repository.NewClientRepository(db), repository.NewAuditRepository(db), time.UTC)
+	for _, name := range []string{"Ann", "Bob", "Cat", "Dan", "Eve"} {
+		if _, err := clients.Create(context.Background(), &model.CreateClientRequest{
+			Name: name, Address: "1 Page Road", ConsentDataStorage: true,
+		}, model.SystemStaffID); err != nil {
+			t.Fatalf("create client: %v", err)
+		}
+	}
+	h := NewClientHandler(clients)
+
+	tests := []struct {
+		name        string
+		offset      string
+		wantClients int
+		want        model.Pagination
+	}{
+		{"first page", "0", 2, model.Pagination{HasNext: true, TotalPages: 3}},
+		{"middle page", "2", 2, model.Pagination{HasNext: true, HasPrev: true, TotalPages: 3}},
+		{"last page", "4", 1, model.Pagination{HasPrev: true, TotalPages: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.List(rec, httptest.NewRequest(http.MethodGet, "/api/clients?limit=2&offset="+tt.offset, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body)
+			}
+			var resp ClientListResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Total != 5 || len(resp.Clients) != tt.wantClients || resp.Pagination != tt.want {
+				t.Errorf("total %d, %d clients, %+v; want 5, %d clients, %+v",
+					resp.Total, len(resp.Clients), resp.Pagination, tt.wantClients, tt.want)
+			}
+		})
+	}
+}
+
+func TestListSort(t *testing.T) {
+	db := testdb.Open(t)
+	clients := service.NewClientService(repository.NewClientRepository(db), repository.NewAuditRepository(db), time.UTC)
+	names := []string{"Ann", "Cat", "Bob"}
+	for _, name := range names {
+		if _, err := clients.Create(context.Background(), &model.CreateClientRequest{
+			Name: name, Address: "1 Sort Street", ConsentDataStorage: true,
+		}, model.SystemStaffID); err != nil {
+			t.Fatalf("create client: %v", err)
+		}
+	}
+	h := NewClientHandler(clients)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"default is name ascending", "", []string{"Ann", "Bob", "Cat"}},
+		{"newest first", "?sort=-created_at", []string{"Bob", "Cat", "Ann"}},
+		// A search takes the Search path rather than List
+		{"newest first while searching", "?sort=-created_at&q=Sort", []string{"Bob", "Cat", "Ann"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.List(rec, httptest.NewRequest(http.MethodGet, "/api/clients"+tt.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body)
+			}
+			var resp ClientListResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			var got []string
+			for _, c := range resp.Clients {
+				got = append(got, c.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	h.List(rec, httptest.NewRequest(http.MethodGet, "/api/clients?sort=address;drop", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown sort: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestNewPaginationZeroLimit(t *testing.T) {
+	if got := model.NewPagination(7, 0, 0); got != (model.Pagination{TotalPages: 1}) {
+		t.Errorf("NewPagination(7, 0, 0) = %+v, want a single page", got)
+	}
+	if got := model.NewPagination(0, 0, 0); got != (model.Pagination{}) {
+		t.Errorf("NewPagination(0, 0, 0) = %+v, want no pages", got)
+	}
+}
+
+func TestUpdateRejectsStaleWrite(t *testing.T) {
+	db := testdb.Open(t)
+	clients := service.NewClientService(repository.NewClientRepository(db), repository.NewAuditRepository(db), time.UTC)
+	client, err := clients.Create(context.Background(), &model.CreateClientRequest{
+		Name: "Shared Client", Address: "1 Race Road", ConsentDataStorage: true,
+	}, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	h := NewClientHandler(clients)
+	staff := &model.Staff{ID: model.SystemStaffID, IsActive: true}
+
+	// Two staff both loaded the client at the same updated_at
+	readAt := client.UpdatedAt.Format(time.RFC3339Nano)
+	update := func(body string) *httptest.ResponseRecorder {
+		req := withURLParam(httptest.NewRequest(http.MethodPut, "/api/clients/"+client.ID.String(), strings.NewReader(body)), "id", client.ID.String())
+		req = req.WithContext(context.WithValue(req.Context(), middleware.StaffContextKey, staff))
+		rec := httptest.NewRecorder()
+		h.Update(rec, req)
+		return rec
+	}
+
+	if rec := update(`{"name":"First Edit","expected_updated_at":"` + readAt + `"}`); rec.Code != http.StatusOK {
+		t.Fatalf("first update: status = %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+	if rec := update(`{"name":"Second Edit","expected_updated_at":"` + readAt + `"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("conflicting update: status = %d, want 409 (body %s)", rec.Code, rec.Body)
+	}
+	got, err := clients.GetByID(context.Background(), client.ID)
+	if err != nil {
+		t.Fatalf("get client: %v", err)
+	}
+	if got.Name != "First Edit" {
+		t.Errorf("name = %q, want the first edit kept", got.Name)
+	}
+
+	// Without a precondition the last write still wins
+	if rec := update(`{"name":"Unconditional"}`); rec.Code != http.StatusOK {
+		t.Errorf("unconditional update: status = %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+}
+
+func intPtr(n int) *int { return &n }
+
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// bodyError is a request body decode failure. Its message is safe to return
+// to the client and status is the response code to use.
+type bodyError struct {
+	status int
+	msg    string
+	err    error
+}
+
+func (e *bodyError) Error() string { return e.msg }
+func (e *bodyError) Unwrap() error { return e.err }
+
+// decodeStatus returns the status for an error from decodeJSON or
+// decodeStrict: 413 when the body went over the LimitBody cap, else 400
+func decodeStatus(err error) int {
+	var be *bodyError
+	if errors.As(err, &be) {
+		return be.status
+	}
+	return http.StatusBadRequest
+}
+
+// decodeJSON decodes a JSON request body into v
+func decodeJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return newBodyError(err)
+	}
+	return nil
+}
+
+// decodeStrict decodes a JSON request body into v, rejecting fields v
+// doesn't have so a typo like {"roll":"admin"} fails instead of being
+// silently ignored. The error is safe to return to the client.
+func decodeStrict(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			return &bodyError{status: http.StatusBadRequest, msg: "unknown field " + field, err: err}
+		}
+		return newBodyError(err)
+	}
+	return nil
+}
+
+// newBodyError wraps a decode error. A chunked body has no Content-Length for
+// LimitBody to check up front, so going over the cap only shows up here as a
+// *http.MaxBytesError.
+func newBodyError(err error) *bodyError {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return &bodyError{
+			status: http.StatusRequestEntityTooLarge,
+			msg:    fmt.Sprintf("request body exceeds %d bytes", maxErr.Limit),
+			err:    err,
+		}
+	}
+	return &bodyError{status: http.StatusBadRequest, msg: "invalid request body", err: err}
+}
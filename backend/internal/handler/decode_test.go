@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/finchley-foodbank/foodbank/internal/handler/middleware"
+)
+
+func TestDecodeOversizedBody(t *testing.T) {
+	// Submit decodes before touching the service, so none is needed here
+	h := middleware.LimitBody(64, nil)(http.HandlerFunc(NewRegistrationRequestHandler(nil).Submit))
+	body := `{"name":"` + strings.Repeat("x", 200) + `","email":"a@example.com"}`
+
+	tests := []struct {
+		name          string
+		contentLength int64
+		want          int
+	}{
+		{"declared length", int64(len(body)), http.StatusRequestEntityTooLarge},
+		// -1 makes the server treat the body as chunked, with no length to check up front
+		{"chunked", -1, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/registration-requests", strings.NewReader(body))
+			req.ContentLength = tt.contentLength
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestDecodeStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"malformed", `{"name":`, http.StatusBadRequest},
+		{"unknown field", `{"nmae":"x"}`, http.StatusBadRequest},
+		{"too large", `{"name":"` + strings.Repeat("x", 100) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Body = http.MaxBytesReader(rec, req.Body, 32)
+			var v struct {
+				Name string `json:"name"`
+			}
+			err := decodeStrict(req, &v)
+			if err == nil {
+				t.Fatal("decode succeeded, want an error")
+			}
+			if got := decodeStatus(err); got != tt.want {
+				t.Errorf("decodeStatus = %d, want %d (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSubmitRejectsUnknownField(t *testing.T) {
+	// Submit decodes before touching the service, so none is needed here
+	req := httptest.NewRequest(http.MethodPost, "/api/registration-requests", strings.NewReader(`{"name":"Sam","emial":"sam@example.com"}`))
+	rec := httptest.NewRecorder()
+	NewRegistrationRequestHandler(nil).Submit(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 (body %s)", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), `unknown field \"emial\"`) {
+		t.Errorf("body %s does not name the unknown field", rec.Body)
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/finchley-foodbank/foodbank/internal/email"
+	"github.com/finchley-foodbank/foodbank/internal/handler/middleware"
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/redact"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+)
+
+type EmailHandler struct {
+	emailService *email.Service
+	emailLogRepo *repository.EmailLogRepository
+}
+
+func NewEmailHandler(emailService *email.Service, emailLogRepo *repository.EmailLogRepository) *EmailHandler {
+	return &EmailHandler{emailService: emailService, emailLogRepo: emailLogRepo}
+}
+
+// SendTest emails the requesting admin to confirm the email setup works
+// POST /api/admin/email-test
+func (h *EmailHandler) SendTest(w http.ResponseWriter, r *http.Request) {
+	staff := middleware.GetStaffFromContext(r.Context())
+	if staff == nil {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	if !h.emailService.IsConfigured() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"success": false,
+			"error":   "email service not configured",
+		})
+		return
+	}
+
+	if err := h.emailService.SendTestEmail(staff.Email); err != nil {
+		log.Printf("Test email to %s failed: %v", redact.Email(staff.Email), err)
+		writeJSON(w, http.StatusBadGateway, map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Test email sent to " + staff.Email,
+	})
+}
+
+// Log returns recent outgoing emails with their delivery status, newest first
+// GET /api/admin/email-log
+func (h *EmailHandler) Log(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, total, err := h.emailLogRepo.List(r.Context(), limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list email log")
+		return
+	}
+
+	if entries == nil {
+		entries = []model.EmailLog{}
+	}
+
+	writeJSON(w, http.StatusOK, model.EmailLogListResponse{
+		Entries:    entries,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		Pagination: model.NewPagination(total, limit, offset),
+	})
+}
@@ -1,19 +1,70 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/finchley-foodbank/foodbank/internal/service"
 )
 
-type HealthHandler struct{}
+// readyPingTimeout bounds the database ping in the readiness check
+const readyPingTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	db                         *pgxpool.Pool
+	registrationRequestService *service.RegistrationRequestService
+}
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+func NewHealthHandler(db *pgxpool.Pool, registrationRequestService *service.RegistrationRequestService) *HealthHandler {
+	return &HealthHandler{db: db, registrationRequestService: registrationRequestService}
 }
 
+// Health is a cheap liveness probe that never touches the database
 func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
 		"status": "ok",
 	})
 }
+
+// Ready is a readiness probe: it pings the database and reports pool usage
+// GET /api/health/ready
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyPingTimeout)
+	defer cancel()
+
+	stat := h.db.Stat()
+	pool := map[string]int32{
+		"acquired": stat.AcquiredConns(),
+		"idle":     stat.IdleConns(),
+		"total":    stat.TotalConns(),
+		"max":      stat.MaxConns(),
+	}
+
+	// Informational only: readiness does not depend on email being configured
+	notifications := "enabled"
+	if !h.registrationRequestService.NotificationsEnabled() {
+		notifications = "disabled"
+	}
+
+	if err := h.db.Ping(ctx); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status":                     "unavailable",
+			"database":                   "unavailable",
+			"pool":                       pool,
+			"registration_notifications": notifications,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":                     "ok",
+		"database":                   "connected",
+		"pool":                       pool,
+		"registration_notifications": notifications,
+	})
+}
@@ -1,21 +1,49 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/finchley-foodbank/foodbank/internal/handler/middleware"
 	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/redact"
 	"github.com/finchley-foodbank/foodbank/internal/service"
 )
 
 type ImportHandler struct {
-	importService *service.ImportService
+	importService   *service.ImportService
+	maxValidateRows int
+	maxImportRows   int
+	maxUploadBytes  int64
+	templateMaxAge  time.Duration
 }
 
-func NewImportHandler(importService *service.ImportService) *ImportHandler {
-	return &ImportHandler{importService: importService}
+func NewImportHandler(importService *service.ImportService, maxValidateRows, maxImportRows int, maxUploadBytes int64) *ImportHandler {
+	return &ImportHandler{
+		importService:   importService,
+		maxValidateRows: maxValidateRows,
+		maxImportRows:   maxImportRows,
+		maxUploadBytes:  maxUploadBytes,
+		templateMaxAge:  time.Hour,
+	}
+}
+
+// SetTemplateCacheMaxAge sets how long browsers may cache the CSV template
+func (h *ImportHandler) SetTemplateCacheMaxAge(d time.Duration) {
+	h.templateMaxAge = d
+}
+
+// checkRowLimit writes a 400 response and returns false if rows exceeds max.
+// A max of zero or less disables the limit.
+func checkRowLimit(w http.ResponseWriter, rows, max int, action string) bool {
+	if max > 0 && rows > max {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Too many rows to %s: %d (max %d)", action, rows, max))
+		return false
+	}
+	return true
 }
 
 // Template returns a CSV template for client imports
@@ -23,17 +51,16 @@ func NewImportHandler(importService *service.ImportService) *ImportHandler {
 func (h *ImportHandler) Template(w http.ResponseWriter, r *http.Request) {
 	template := h.importService.GenerateCSVTemplate()
 
-	w.Header().Set("Content-Type", "text/csv")
 	w.Header().Set("Content-Disposition", "attachment; filename=client-import-template.csv")
-	w.Write([]byte(template))
+	writeCacheable(w, r, "text/csv", []byte(template), h.templateMaxAge)
 }
 
 // Validate validates CSV data without importing
 // POST /api/admin/import/validate
 func (h *ImportHandler) Validate(w http.ResponseWriter, r *http.Request) {
 	var req model.ValidateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, decodeStatus(err), err.Error())
 		return
 	}
 
@@ -42,8 +69,7 @@ func (h *ImportHandler) Validate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.Clients) > 10000 {
-		writeError(w, http.StatusBadRequest, "Too many rows (max 10,000)")
+	if !checkRowLimit(w, len(req.Clients), h.maxValidateRows, "validate") {
 		return
 	}
 
@@ -57,6 +83,70 @@ func (h *ImportHandler) Validate(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
+// Upload parses a CSV file sent as multipart/form-data field "file" and
+// validates it like Validate. The parsed rows are returned in "clients" so
+// they can be passed straight to Import.
+// POST /api/admin/import/upload
+func (h *ImportHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadBytes)
+	if err := r.ParseMultipartForm(h.maxUploadBytes); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("File too large (max %d bytes)", h.maxUploadBytes))
+			return
+		}
+		writeError(w, http.StatusBadRequest, "Expected multipart/form-data with a file field")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Missing file")
+		return
+	}
+	defer file.Close()
+
+	rows, rowErrs, err := h.importService.ParseCSV(file)
+	if errors.Is(err, service.ErrInvalidCSV) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("CSV upload parse error: %v", err)
+		writeError(w, http.StatusBadRequest, "Could not read file")
+		return
+	}
+
+	if len(rows) == 0 && len(rowErrs) == 0 {
+		writeError(w, http.StatusBadRequest, "No clients to validate")
+		return
+	}
+
+	malformed := make(map[int]bool)
+	for _, e := range rowErrs {
+		malformed[e.Row] = true
+	}
+	if !checkRowLimit(w, len(rows)+len(malformed), h.maxValidateRows, "validate") {
+		return
+	}
+
+	result, err := h.importService.ValidateRows(r.Context(), rows)
+	if err != nil {
+		log.Printf("Validation error: %v", err)
+		writeError(w, http.StatusInternalServerError, "Validation failed")
+		return
+	}
+
+	// Unparseable rows count towards the total but are never valid
+	result.TotalRows += len(malformed)
+	result.Errors = append(rowErrs, result.Errors...)
+	result.Valid = len(result.Errors) == 0
+	result.Clients = rows
+
+	writeJSON(w, http.StatusOK, result)
+}
+
 // Import imports clients from validated CSV data
 // POST /api/admin/import/clients
 func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
@@ -68,8 +158,8 @@ func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req model.ImportRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, decodeStatus(err), err.Error())
 		return
 	}
 
@@ -78,8 +168,7 @@ func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.Clients) > 10000 {
-		writeError(w, http.StatusBadRequest, "Too many rows (max 10,000)")
+	if !checkRowLimit(w, len(req.Clients), h.maxImportRows, "import") {
 		return
 	}
 
@@ -89,8 +178,8 @@ func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
 		batchSize = 50
 	}
 
-	log.Printf("Starting import of %d clients by %s (batch size: %d, skip duplicates: %v)",
-		len(req.Clients), staff.Email, batchSize, req.SkipDuplicates)
+	log.Printf("Starting import of %d clients by %s (batch size: %d, skip duplicates: %v, dry run: %v)",
+		len(req.Clients), redact.Email(staff.Email), batchSize, req.SkipDuplicates, req.DryRun)
 
 	result, err := h.importService.ImportClients(
 		r.Context(),
@@ -98,6 +187,7 @@ func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
 		staff.ID,
 		batchSize,
 		req.SkipDuplicates,
+		req.DryRun,
 	)
 	if err != nil {
 		log.Printf("Import error: %v", err)
@@ -105,8 +195,8 @@ func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("Import completed: %d imported, %d skipped, %d failed",
-		result.Imported, result.Skipped, result.Failed)
+	log.Printf("Import completed: %d imported, %d skipped, %d failed (dry run: %v)",
+		result.Imported, result.Skipped, result.Failed, result.DryRun)
 
 	writeJSON(w, http.StatusOK, result)
 }
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/finchley-foodbank/foodbank/internal/handler/middleware"
+	"github.com/finchley-foodbank/foodbank/internal/service"
+)
+
+func TestTemplateCaching(t *testing.T) {
+	h := NewImportHandler(&service.ImportService{}, 0, 0, 0)
+	h.SetTemplateCacheMaxAge(10 * time.Minute)
+	// Routed as in main, where every API response defaults to no-store
+	template := middleware.NoStore(http.HandlerFunc(h.Template))
+
+	rec := httptest.NewRecorder()
+	template.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/admin/import/template", nil))
+	if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+		t.Fatalf("status = %d with %d bytes, want 200 and the template", rec.Code, rec.Body.Len())
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "private, max-age=600" {
+		t.Errorf("Cache-Control = %q, want private, max-age=600", got)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/import/template", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	template.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("revalidation: status = %d with %d bytes, want an empty 304", rec.Code, rec.Body.Len())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/import/template", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	rec = httptest.NewRecorder()
+	template.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("stale ETag: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestDynamicResponsesAreNoStore(t *testing.T) {
+	dynamic := middleware.NoStore(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}))
+	rec := httptest.NewRecorder()
+	dynamic.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/clients", nil))
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/finchley-foodbank/foodbank/internal/service"
+)
+
+type MaintenanceHandler struct {
+	maintenanceService *service.MaintenanceService
+}
+
+func NewMaintenanceHandler(maintenanceService *service.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{maintenanceService: maintenanceService}
+}
+
+// Cleanup runs the expired-data cleanup immediately (admin only)
+// POST /api/admin/maintenance/cleanup
+func (h *MaintenanceHandler) Cleanup(w http.ResponseWriter, r *http.Request) {
+	result, err := h.maintenanceService.Cleanup(r.Context())
+	if err != nil {
+		log.Printf("Manual cleanup failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "cleanup failed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+// AccessLogSampleRule logs only 1 in Every requests whose path starts with Prefix
+type AccessLogSampleRule struct {
+	Prefix string
+	Every  uint64
+
+	counter atomic.Uint64
+}
+
+// ParseAccessLogSampling parses "prefix:N,prefix:N" into sample rules,
+// skipping malformed entries
+func ParseAccessLogSampling(spec string) []*AccessLogSampleRule {
+	var rules []*AccessLogSampleRule
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		idx := strings.LastIndex(part, ":")
+		if idx <= 0 {
+			log.Printf("Ignoring access log sample rule %q: expected prefix:N", part)
+			continue
+		}
+		every, err := strconv.ParseUint(part[idx+1:], 10, 64)
+		if err != nil || every == 0 {
+			log.Printf("Ignoring access log sample rule %q: N must be a positive integer", part)
+			continue
+		}
+		rules = append(rules, &AccessLogSampleRule{Prefix: part[:idx], Every: every})
+	}
+	return rules
+}
+
+// sample reports whether this request should be logged; the first matching
+// rule wins and unmatched paths are always logged
+func sample(rules []*AccessLogSampleRule, path string) bool {
+	for _, rule := range rules {
+		if strings.HasPrefix(path, rule.Prefix) {
+			return (rule.counter.Add(1)-1)%rule.Every == 0
+		}
+	}
+	return true
+}
+
+// accessLogState is shared through the request context so inner middleware
+// can attach the staff ID once it is known
+type accessLogState struct {
+	staffID uuid.UUID
+}
+
+type accessLogKey struct{}
+
+// setAccessLogStaffID records the staff member handling the request, if the
+// access logger is active
+func setAccessLogStaffID(ctx context.Context, id uuid.UUID) {
+	if state, ok := ctx.Value(accessLogKey{}).(*accessLogState); ok {
+		state.staffID = id
+	}
+}
+
+// JSONAccessLog writes one structured log line per request through logger.
+// It replaces chi's text logger and should run after RequestID. Server errors
+// are always logged; other requests are subject to the sample rules.
+func JSONAccessLog(logger *slog.Logger, rules []*AccessLogSampleRule) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			state := &accessLogState{}
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, state)))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status < http.StatusInternalServerError && !sample(rules, r.URL.Path) {
+				return
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.String("request_id", chimiddleware.GetReqID(r.Context())),
+			}
+			if state.staffID != uuid.Nil {
+				attrs = append(attrs, slog.String("staff_id", state.staffID.String()))
+			}
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "http_request", attrs...)
+		})
+	}
+}
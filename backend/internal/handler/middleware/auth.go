@@ -10,6 +10,8 @@ import (
 	jwtmiddleware "github.com/auth0/go-jwt-middleware/v2"
 	"github.com/auth0/go-jwt-middleware/v2/jwks"
 	"github.com/auth0/go-jwt-middleware/v2/validator"
+
+	"github.com/finchley-foodbank/foodbank/internal/redact"
 )
 
 type contextKey string
@@ -18,6 +20,7 @@ const (
 	Auth0IDKey    contextKey = "auth0_id"
 	Auth0EmailKey contextKey = "auth0_email"
 	Auth0NameKey  contextKey = "auth0_name"
+	Auth0RoleKey  contextKey = "auth0_role"
 )
 
 type CustomClaims struct {
@@ -25,13 +28,17 @@ type CustomClaims struct {
 	Name           string `json:"name"`
 	NamespacedEmail string `json:"https://foodbank.app/email"`
 	NamespacedName  string `json:"https://foodbank.app/name"`
+	NamespacedRole  string `json:"https://foodbank.app/role"`
 }
 
 func (c CustomClaims) Validate(ctx context.Context) error {
 	return nil
 }
 
-func NewAuthMiddleware(domain, audience string) (func(http.Handler) http.Handler, error) {
+// NewAuthMiddleware validates Auth0 JWTs and stores the subject and profile
+// claims in the request context. With trustRoleClaim the namespaced role
+// claim is stored too, letting RequireAdmin/RequireRole skip the staff record.
+func NewAuthMiddleware(domain, audience string, trustRoleClaim bool) (func(http.Handler) http.Handler, error) {
 	issuerURL, err := url.Parse("https://" + domain + "/")
 	if err != nil {
 		return nil, err
@@ -40,9 +47,10 @@ func NewAuthMiddleware(domain, audience string) (func(http.Handler) http.Handler
 	log.Printf("Auth middleware: issuer=%s audience=%s", issuerURL.String(), audience)
 
 	provider := jwks.NewCachingProvider(issuerURL, 5*time.Minute)
+	keyFunc := newResilientKeyFunc(provider.KeyFunc)
 
 	jwtValidator, err := validator.New(
-		provider.KeyFunc,
+		keyFunc.KeyFunc,
 		validator.RS256,
 		issuerURL.String(),
 		[]string{audience},
@@ -55,6 +63,15 @@ func NewAuthMiddleware(domain, audience string) (func(http.Handler) http.Handler
 	}
 
 	errorHandler := func(w http.ResponseWriter, r *http.Request, err error) {
+		// Keys could not be fetched at all: the token may be fine, so ask the client to retry
+		if state, ok := r.Context().Value(keyFetchStateKey{}).(*keyFetchState); ok && state.failed {
+			log.Printf("JWT validation unavailable, JWKS fetch failed: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"auth_unavailable"}`))
+			return
+		}
+
 		log.Printf("JWT validation error: %v", err)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusUnauthorized)
@@ -64,10 +81,13 @@ func NewAuthMiddleware(domain, audience string) (func(http.Handler) http.Handler
 	middleware := jwtmiddleware.New(jwtValidator.ValidateToken, jwtmiddleware.WithErrorHandler(errorHandler))
 
 	return func(next http.Handler) http.Handler {
-		return middleware.CheckJWT(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checkJWT := middleware.CheckJWT(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Debug: check what's in context
 			rawClaims := r.Context().Value(jwtmiddleware.ContextKey{})
-			log.Printf("Context claims type: %T, value: %v", rawClaims, rawClaims)
+			// Claims carry user identifiers, so only dump them when redaction is off
+			if !redact.Enabled() {
+				log.Printf("Context claims type: %T, value: %v", rawClaims, rawClaims)
+			}
 
 			claims, ok := rawClaims.(*validator.ValidatedClaims)
 			if !ok {
@@ -90,10 +110,17 @@ func NewAuthMiddleware(domain, audience string) (func(http.Handler) http.Handler
 				}
 				ctx = context.WithValue(ctx, Auth0EmailKey, email)
 				ctx = context.WithValue(ctx, Auth0NameKey, name)
+				if trustRoleClaim && customClaims.NamespacedRole != "" {
+					ctx = context.WithValue(ctx, Auth0RoleKey, customClaims.NamespacedRole)
+				}
 			}
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		}))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			checkJWT.ServeHTTP(w, r.WithContext(withKeyFetchState(r.Context())))
+		})
 	}, nil
 }
 
@@ -117,3 +144,12 @@ func GetAuth0Name(ctx context.Context) string {
 	}
 	return ""
 }
+
+// GetAuth0Role returns the role claim from the token, or "" when the token
+// has none or the claim isn't trusted
+func GetAuth0Role(ctx context.Context) string {
+	if role, ok := ctx.Value(Auth0RoleKey).(string); ok {
+		return role
+	}
+	return ""
+}
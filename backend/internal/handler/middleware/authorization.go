@@ -2,9 +2,13 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
 	"github.com/finchley-foodbank/foodbank/internal/service"
 )
 
@@ -35,25 +39,68 @@ func LoadStaff(staffService *service.StaffService) func(http.Handler) http.Handl
 
 			staff, err := staffService.GetByAuth0ID(r.Context(), auth0ID)
 			if err != nil {
-				// User not found in database - let them through for auto-registration via /api/me
+				// User not found (or database unavailable) - let them through so
+				// EnsureStaff can reject them, or recovery auth can still apply
 				next.ServeHTTP(w, r)
 				return
 			}
 
 			// Add staff to context
+			setAccessLogStaffID(r.Context(), staff.ID)
 			ctx := context.WithValue(r.Context(), StaffContextKey, staff)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// EnsureStaff middleware is the single place that decides what happens to
+// authenticated users without a staff record. Staff are only created through
+// invitation or registration approval, so unknown users are rejected with
+// 403 not_registered on every route it guards. Known users have placeholder
+// name/email values filled in from their token claims.
+// This should be used after LoadStaff.
+func EnsureStaff(staffService *service.StaffService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			staff := GetStaffFromContext(ctx)
+
+			if staff == nil {
+				// LoadStaff swallows lookup errors, so distinguish "not registered" from a DB failure here
+				_, err := staffService.GetByAuth0ID(ctx, GetAuth0ID(ctx))
+				if err != nil && !errors.Is(err, repository.ErrStaffNotFound) {
+					log.Printf("EnsureStaff: failed to look up staff: %v", err)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusInternalServerError)
+					w.Write([]byte(`{"error":"internal server error"}`))
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error":"not_registered"}`))
+				return
+			}
+
+			synced, err := staffService.SyncFromClaims(ctx, staff, GetAuth0Name(ctx), GetAuth0Email(ctx))
+			if err != nil {
+				// Keep serving with the stored record; the sync is best-effort
+				log.Printf("EnsureStaff: failed to sync staff %s from claims: %v", staff.ID, err)
+			} else if synced != staff {
+				ctx = context.WithValue(ctx, StaffContextKey, synced)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 // RequireActive middleware blocks deactivated users from accessing protected routes
 func RequireActive(staffService *service.StaffService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			staff := GetStaffFromContext(r.Context())
 
-			// If no staff in context, they might be a new user - let them through for auto-registration
+			// If no staff in context, leave the decision to EnsureStaff (or recovery auth)
 			if staff == nil {
 				next.ServeHTTP(w, r)
 				return
@@ -72,7 +119,30 @@ func RequireActive(staffService *service.StaffService) func(http.Handler) http.H
 	}
 }
 
-// RequireAdmin middleware ensures the user has admin role
+// RequireVerifiedEmail middleware blocks staff who have not verified their
+// email. Newly created accounts keep access for the grace period (measured
+// from staff.CreatedAt) so invitees have time to complete verification.
+func RequireVerifiedEmail(grace time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			staff := GetStaffFromContext(r.Context())
+
+			// If no staff in context, leave the decision to EnsureStaff
+			if staff == nil || staff.EmailVerified || time.Since(staff.CreatedAt) < grace {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"email_not_verified","message":"Please verify your email address to continue."}`))
+		})
+	}
+}
+
+// RequireAdmin middleware ensures the user has admin role. The staff record
+// decides: a role claim is only written to Auth0 when the user is created, so
+// after a demotion it can still say admin until the token expires.
 func RequireAdmin(staffService *service.StaffService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -97,7 +167,8 @@ func RequireAdmin(staffService *service.StaffService) func(http.Handler) http.Ha
 	}
 }
 
-// RequireRole middleware ensures the user has one of the specified roles
+// RequireRole middleware ensures the user has one of the specified roles.
+// As with RequireAdmin, the staff record decides rather than the role claim.
 func RequireRole(staffService *service.StaffService, roles ...string) func(http.Handler) http.Handler {
 	roleSet := make(map[string]bool)
 	for _, role := range roles {
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+)
+
+func requestAs(claimRole, staffRole string) *http.Request {
+	ctx := context.Background()
+	if claimRole != "" {
+		ctx = context.WithValue(ctx, Auth0RoleKey, claimRole)
+	}
+	if staffRole != "" {
+		ctx = context.WithValue(ctx, StaffContextKey, &model.Staff{Role: staffRole, IsActive: true})
+	}
+	return httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestRequireAdminUsesStaffRecord(t *testing.T) {
+	tests := []struct {
+		name      string
+		claimRole string
+		staffRole string
+		want      int
+	}{
+		{"demoted admin with stale claim", model.RoleAdmin, model.RoleStaff, http.StatusForbidden},
+		{"promoted before token refresh", model.RoleStaff, model.RoleAdmin, http.StatusOK},
+		{"admin claim without staff record", model.RoleAdmin, "", http.StatusForbidden},
+		{"admin without claim", "", model.RoleAdmin, http.StatusOK},
+		{"staff without claim", "", model.RoleStaff, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			RequireAdmin(nil)(okHandler).ServeHTTP(rec, requestAs(tt.claimRole, tt.staffRole))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireRoleUsesStaffRecord(t *testing.T) {
+	rec := httptest.NewRecorder()
+	RequireRole(nil, model.RoleAdmin)(okHandler).ServeHTTP(rec, requestAs(model.RoleAdmin, model.RoleStaff))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("demoted admin: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec = httptest.NewRecorder()
+	RequireRole(nil, model.RoleAdmin, model.RoleStaff)(okHandler).ServeHTTP(rec, requestAs("", model.RoleStaff))
+	if rec.Code != http.StatusOK {
+		t.Errorf("staff in role set: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	const grace = 72 * time.Hour
+	tests := []struct {
+		name  string
+		staff *model.Staff
+		want  int
+	}{
+		{"verified", &model.Staff{EmailVerified: true, CreatedAt: time.Now().Add(-30 * 24 * time.Hour)}, http.StatusOK},
+		{"unverified within grace", &model.Staff{CreatedAt: time.Now().Add(-time.Hour)}, http.StatusOK},
+		{"unverified after grace", &model.Staff{CreatedAt: time.Now().Add(-grace - time.Hour)}, http.StatusForbidden},
+		{"no staff record", nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/clients", nil)
+			if tt.staff != nil {
+				req = req.WithContext(context.WithValue(req.Context(), StaffContextKey, tt.staff))
+			}
+			rec := httptest.NewRecorder()
+			RequireVerifiedEmail(grace)(okHandler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusForbidden && !strings.Contains(rec.Body.String(), `"error":"email_not_verified"`) {
+				t.Errorf("body = %s, want error email_not_verified", rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// LimitBody caps request bodies at defaultLimit bytes, or at overrides[path]
+// for the listed paths (e.g. import and restore, which take large files).
+// Requests declaring a larger Content-Length get 413 straight away; streamed
+// bodies fail with *http.MaxBytesError once the cap has been read, which the
+// handlers' decode helpers also answer with 413.
+// It runs before routing, so overrides are matched on the exact URL path.
+func LimitBody(defaultLimit int64, overrides map[string]int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := defaultLimit
+			if n, ok := overrides[r.URL.Path]; ok {
+				limit = n
+			}
+
+			if r.ContentLength > limit {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				fmt.Fprintf(w, `{"error":"request_too_large","message":"Request body exceeds %d bytes."}`, limit)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
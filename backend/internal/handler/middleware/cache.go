@@ -0,0 +1,12 @@
+package middleware
+
+import "net/http"
+
+// NoStore marks responses as uncacheable unless the handler sets its own
+// Cache-Control, so client data never lingers in browser or proxy caches
+func NoStore(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		next.ServeHTTP(w, r)
+	})
+}
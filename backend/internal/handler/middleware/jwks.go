@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// keyFetchRetryDelay is the pause before retrying a failed JWKS fetch
+const keyFetchRetryDelay = 250 * time.Millisecond
+
+// keyFetchState is placed in the request context so the JWT error handler
+// can tell a JWKS outage apart from a bad token
+type keyFetchState struct {
+	failed bool
+}
+
+type keyFetchStateKey struct{}
+
+func withKeyFetchState(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keyFetchStateKey{}, &keyFetchState{})
+}
+
+// resilientKeyFunc wraps a JWKS key function with one retry and a fallback
+// to the last key set fetched successfully
+type resilientKeyFunc struct {
+	fetch func(ctx context.Context) (interface{}, error)
+
+	mu    sync.RWMutex
+	stale interface{}
+}
+
+func newResilientKeyFunc(fetch func(ctx context.Context) (interface{}, error)) *resilientKeyFunc {
+	return &resilientKeyFunc{fetch: fetch}
+}
+
+func (k *resilientKeyFunc) KeyFunc(ctx context.Context) (interface{}, error) {
+	keys, err := k.fetch(ctx)
+	if err != nil {
+		select {
+		case <-ctx.Done():
+		case <-time.After(keyFetchRetryDelay):
+			keys, err = k.fetch(ctx)
+		}
+	}
+	if err == nil {
+		k.mu.Lock()
+		k.stale = keys
+		k.mu.Unlock()
+		return keys, nil
+	}
+
+	k.mu.RLock()
+	stale := k.stale
+	k.mu.RUnlock()
+	if stale != nil {
+		log.Printf("JWKS fetch failed, using cached keys: %v", err)
+		return stale, nil
+	}
+
+	if state, ok := ctx.Value(keyFetchStateKey{}).(*keyFetchState); ok {
+		state.failed = true
+	}
+	return nil, err
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a per-client-IP token bucket. Each IP may burst up to limit
+// requests, and tokens refill evenly so limit requests are allowed per window.
+type RateLimiter struct {
+	limit  float64
+	window time.Duration
+	now    func() time.Time
+
+	// trustedProxies may set X-Forwarded-For; see ClientIP
+	trustedProxies []*net.IPNet
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter allows limit requests per IP per window. A limit of zero or
+// less disables limiting.
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   float64(limit),
+		window:  window,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// SetTrustedProxies lets requests arriving from these proxies be keyed on
+// their X-Forwarded-For address instead of the proxy's own
+func (l *RateLimiter) SetTrustedProxies(proxies []*net.IPNet) {
+	l.trustedProxies = proxies
+}
+
+// ParseTrustedProxies parses IP addresses and CIDR ranges, e.g. from the
+// TRUSTED_PROXIES setting. A bare IP matches only itself.
+func ParseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			proxies = append(proxies, ipNet)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q (expected IP or CIDR)", entry)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return proxies, nil
+}
+
+// Allow takes a token for key, reporting whether one was available and, if
+// not, how long until the next token
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	rate := l.limit / l.window.Seconds() // tokens per second
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.limit, last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * rate
+		if b.tokens > l.limit {
+			b.tokens = l.limit
+		}
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops buckets idle long enough to have refilled completely
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimit rejects requests over the limiter's budget with 429
+func RateLimit(limiter *RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, wait := limiter.Allow(ClientIP(r, limiter.trustedProxies))
+			if !ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"error":"rate_limited","message":"Too many requests. Please try again later."}`))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns the caller's IP. X-Forwarded-For is only honoured when
+// the connection comes from one of trusted. Its entries are then read from
+// the right, skipping further trusted proxies (e.g. the platform's edge
+// proxy in front of nginx), and the first untrusted address is the caller.
+// Entries left of that are client-supplied and could be spoofed. Anyone
+// else could set the header themselves, so their connection's address is used.
+func ClientIP(r *http.Request, trusted []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !ipInNets(net.ParseIP(host), trusted) {
+		return host
+	}
+	client := host
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		parts := strings.Split(xff, ",")
+		for i := len(parts) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(parts[i]))
+			if ip == nil {
+				break
+			}
+			client = ip.String()
+			if !ipInNets(ip, trusted) {
+				break
+			}
+		}
+	}
+	return client
+}
+
+func ipInNets(ip net.IP, nets []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source for RateLimiter.now
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestLimiter(limit int, window time.Duration) (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)}
+	l := NewRateLimiter(limit, window)
+	l.now = clock.now
+	return l, clock
+}
+
+func TestRateLimiterRejectsOverLimit(t *testing.T) {
+	l, _ := newTestLimiter(3, time.Hour)
+
+	for i := 1; i <= 3; i++ {
+		if ok, _ := l.Allow("203.0.113.7"); !ok {
+			t.Fatalf("request %d rejected, want allowed", i)
+		}
+	}
+	ok, wait := l.Allow("203.0.113.7")
+	if ok {
+		t.Fatal("request 4 allowed, want rejected")
+	}
+	if wait != 20*time.Minute {
+		t.Errorf("retry after %v, want 20m (one token per window/limit)", wait)
+	}
+
+	// Other callers have their own bucket
+	if ok, _ := l.Allow("198.51.100.2"); !ok {
+		t.Error("a different IP was rejected")
+	}
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	l, clock := newTestLimiter(3, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		l.Allow("203.0.113.7")
+	}
+
+	clock.t = clock.t.Add(19 * time.Minute)
+	if ok, _ := l.Allow("203.0.113.7"); ok {
+		t.Fatal("allowed before a token refilled")
+	}
+
+	clock.t = clock.t.Add(time.Minute)
+	if ok, _ := l.Allow("203.0.113.7"); !ok {
+		t.Fatal("rejected after one token refilled")
+	}
+	if ok, _ := l.Allow("203.0.113.7"); ok {
+		t.Fatal("allowed a second request on one refilled token")
+	}
+
+	// A full window refills the bucket, but never beyond the limit
+	clock.t = clock.t.Add(3 * time.Hour)
+	for i := 1; i <= 3; i++ {
+		if ok, _ := l.Allow("203.0.113.7"); !ok {
+			t.Fatalf("request %d after a full refill rejected", i)
+		}
+	}
+	if ok, _ := l.Allow("203.0.113.7"); ok {
+		t.Error("bucket refilled beyond its limit")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	l, _ := newTestLimiter(2, time.Hour)
+	handler := RateLimit(l)(okHandler)
+
+	codes := make([]int, 3)
+	for i := range codes {
+		req := httptest.NewRequest(http.MethodPost, "/api/registration-requests", nil)
+		req.RemoteAddr = "203.0.113.7:5000"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		codes[i] = rec.Code
+		if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Error("429 without Retry-After")
+		}
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("status codes = %v, want [200 200 429]", codes)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"172.16.0.0/12", "fdaa::/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		want       string
+	}{
+		{"direct caller", "203.0.113.7:5000", "", "203.0.113.7"},
+		{"untrusted caller spoofing the header", "203.0.113.7:5000", "198.51.100.1", "203.0.113.7"},
+		{"nginx on the private network", "[fdaa:0:1::2]:4000", "203.0.113.7", "203.0.113.7"},
+		{"edge proxy then nginx", "[fdaa:0:1::2]:4000", "203.0.113.7, 172.16.4.5", "203.0.113.7"},
+		{"spoofed entry left of the real caller", "[fdaa:0:1::2]:4000", "198.51.100.1, 203.0.113.7, 172.16.4.5", "203.0.113.7"},
+		{"trusted proxy without the header", "172.16.4.5:4000", "", "172.16.4.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := ClientIP(req, trusted); got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
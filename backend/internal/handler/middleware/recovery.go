@@ -5,6 +5,8 @@ import (
 	"crypto/subtle"
 	"net/http"
 
+	"github.com/google/uuid"
+
 	"github.com/finchley-foodbank/foodbank/internal/model"
 	"github.com/finchley-foodbank/foodbank/internal/service"
 )
@@ -23,6 +25,19 @@ func IsRecoveryMode(ctx context.Context) bool {
 	return false
 }
 
+// GetActorID returns the staff ID a change should be audited under: the
+// signed-in staff member, or model.SystemStaffID for requests authenticated
+// only by the recovery token. ok is false when there is neither.
+func GetActorID(ctx context.Context) (id uuid.UUID, ok bool) {
+	if staff := GetStaffFromContext(ctx); staff != nil {
+		return staff.ID, true
+	}
+	if IsRecoveryMode(ctx) {
+		return model.SystemStaffID, true
+	}
+	return uuid.Nil, false
+}
+
 // RecoveryAuth middleware allows access via recovery token OR normal admin auth
 // This enables database restore operations even when the database is unavailable
 func RecoveryAuth(recoveryToken string, staffService *service.StaffService) func(http.Handler) http.Handler {
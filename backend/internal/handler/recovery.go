@@ -1,13 +1,14 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/finchley-foodbank/foodbank/internal/handler/middleware"
+	"github.com/finchley-foodbank/foodbank/internal/redact"
 	"github.com/finchley-foodbank/foodbank/internal/service"
 )
 
@@ -37,17 +38,23 @@ func (h *RecoveryHandler) Backup(w http.ResponseWriter, r *http.Request) {
 
 	switch format {
 	case "json":
-		backup, err := h.backupService.CreateBackup(ctx, createdBy)
+		// Streamed straight to the response, so a failure part way through
+		// can only be logged; the truncated file won't parse or verify.
+		// Open the snapshot first so an outage still gets a proper error.
+		stream, err := h.backupService.BeginStreamBackup(ctx, createdBy)
 		if err != nil {
 			log.Printf("Backup failed: %v", err)
 			writeError(w, http.StatusInternalServerError, "backup failed")
 			return
 		}
+		defer stream.Close(ctx)
 
 		filename := fmt.Sprintf("foodbank-backup-%s.json", time.Now().Format("2006-01-02"))
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-		json.NewEncoder(w).Encode(backup)
+		if err := stream.Write(ctx, w); err != nil {
+			log.Printf("Backup failed: %v", err)
+		}
 
 	case "csv":
 		zipData, err := h.backupService.ExportCSV(ctx)
@@ -69,13 +76,28 @@ func (h *RecoveryHandler) Backup(w http.ResponseWriter, r *http.Request) {
 }
 
 // Restore imports data from a JSON backup
-// POST /api/admin/restore
+// POST /api/admin/restore?mode=replace|merge
 // Body: JSON backup file
+// mode defaults to "replace" (wipe then import); "merge" upserts by id and
+// keeps existing rows that are not in the backup.
 func (h *RecoveryHandler) Restore(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	mode := service.RestoreMode(r.URL.Query().Get("mode"))
+	if mode == "" {
+		mode = service.RestoreModeReplace
+	}
+	if mode != service.RestoreModeReplace && mode != service.RestoreModeMerge {
+		writeError(w, http.StatusBadRequest, "invalid mode: must be replace or merge")
+		return
+	}
+
 	var backup service.Backup
-	if err := json.NewDecoder(r.Body).Decode(&backup); err != nil {
+	if err := decodeJSON(r, &backup); err != nil {
+		if status := decodeStatus(err); status != http.StatusBadRequest {
+			writeError(w, status, err.Error())
+			return
+		}
 		writeError(w, http.StatusBadRequest, "invalid backup file format")
 		return
 	}
@@ -86,9 +108,40 @@ func (h *RecoveryHandler) Restore(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("Starting restore from backup created at %s by %s", backup.CreatedAt, backup.CreatedBy)
+	restoredBy, ok := middleware.GetActorID(ctx)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
 
-	if err := h.backupService.RestoreBackup(ctx, &backup); err != nil {
+	log.Printf("Starting %s restore from backup created at %s by %s", mode, backup.CreatedAt, redact.Email(backup.CreatedBy))
+
+	result, err := h.backupService.RestoreBackup(ctx, &backup, mode, restoredBy)
+	if errors.Is(err, service.ErrBackupChecksumMismatch) {
+		writeError(w, http.StatusBadRequest, "invalid backup: checksum mismatch (file may be corrupted or modified)")
+		return
+	}
+	var refErr *service.BackupReferenceError
+	if errors.As(err, &refErr) {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":  "invalid backup: references missing staff or clients",
+			"issues": refErr.Issues,
+		})
+		return
+	}
+	var conflictErr *service.BackupConflictError
+	if errors.As(err, &conflictErr) {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":  "backup rows conflict with existing staff, clients or registration requests",
+			"issues": conflictErr.Issues,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrBackupTooNew) || errors.Is(err, service.ErrUnsupportedBackupVersion) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid backup: %v", err))
+		return
+	}
+	if err != nil {
 		log.Printf("Restore failed: %v", err)
 		writeError(w, http.StatusInternalServerError, fmt.Sprintf("restore failed: %v", err))
 		return
@@ -98,6 +151,8 @@ func (h *RecoveryHandler) Restore(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"message": "Database restored successfully",
+		"mode":    result.Mode,
+		"tables":  result.Tables,
 		"stats": map[string]int{
 			"staff":                 len(backup.Staff),
 			"clients":               len(backup.Clients),
@@ -109,6 +164,30 @@ func (h *RecoveryHandler) Restore(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Validate checks an uploaded backup file without touching the database
+// POST /api/admin/backup/validate
+// Body: JSON backup file
+func (h *RecoveryHandler) Validate(w http.ResponseWriter, r *http.Request) {
+	var backup service.Backup
+	if err := decodeJSON(r, &backup); err != nil {
+		if status := decodeStatus(err); status != http.StatusBadRequest {
+			writeError(w, status, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, &service.BackupValidationReport{
+			Valid:  false,
+			Stats:  map[string]int{},
+			Errors: []service.BackupIssue{{Table: "backup", Message: fmt.Sprintf("invalid backup file format: %v", errors.Unwrap(err))}},
+		})
+		return
+	}
+
+	report := service.ValidateBackup(&backup)
+	log.Printf("Validated backup created at %s: valid=%v, %d issue(s)", backup.CreatedAt, report.Valid, len(report.Errors))
+
+	writeJSON(w, http.StatusOK, report)
+}
+
 // Status checks database connectivity
 // GET /api/admin/recovery/status
 func (h *RecoveryHandler) Status(w http.ResponseWriter, r *http.Request) {
@@ -1,9 +1,12 @@
 package handler
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -12,6 +15,7 @@ import (
 	"github.com/finchley-foodbank/foodbank/internal/model"
 	"github.com/finchley-foodbank/foodbank/internal/repository"
 	"github.com/finchley-foodbank/foodbank/internal/service"
+	"github.com/finchley-foodbank/foodbank/internal/validate"
 )
 
 type RegistrationRequestHandler struct {
@@ -25,8 +29,8 @@ func NewRegistrationRequestHandler(svc *service.RegistrationRequestService) *Reg
 // Submit creates a new registration request (public endpoint)
 func (h *RegistrationRequestHandler) Submit(w http.ResponseWriter, r *http.Request) {
 	var req model.CreateRegistrationRequestRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeStrict(r, &req); err != nil {
+		writeError(w, decodeStatus(err), err.Error())
 		return
 	}
 
@@ -35,6 +39,10 @@ func (h *RegistrationRequestHandler) Submit(w http.ResponseWriter, r *http.Reque
 		writeError(w, http.StatusBadRequest, "name and email are required")
 		return
 	}
+	if err := validate.Email(req.Email); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid email")
+		return
+	}
 
 	request, err := h.service.Submit(r.Context(), req)
 	if err != nil {
@@ -56,17 +64,77 @@ func (h *RegistrationRequestHandler) Submit(w http.ResponseWriter, r *http.Reque
 	})
 }
 
-// List returns all pending registration requests (admin only)
+// List returns a page of registration requests (admin only).
+// ?status= filters by status (default pending, "all" for every status).
+// The body stays a plain array, as existing callers expect; the total
+// number of matching requests is sent in X-Total-Count.
 func (h *RegistrationRequestHandler) List(w http.ResponseWriter, r *http.Request) {
-	requests, err := h.service.ListPending(r.Context())
+	status, ok := parseRequestStatus(w, r)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	requests, total, err := h.service.List(r.Context(), status, limit, offset)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to list requests")
 		return
 	}
 
+	if requests == nil {
+		requests = []model.RegistrationRequest{}
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
 	writeJSON(w, http.StatusOK, requests)
 }
 
+// parseRequestStatus reads ?status=, defaulting to pending and mapping "all"
+// to no filter. It writes a 400 and returns false for unknown values.
+func parseRequestStatus(w http.ResponseWriter, r *http.Request) (string, bool) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		return model.RequestStatusPending, true
+	case "all":
+		return "", true
+	case model.RequestStatusPending, model.RequestStatusApproved, model.RequestStatusRejected:
+		return status, true
+	}
+	writeError(w, http.StatusBadRequest, "invalid status, use pending, approved, rejected or all")
+	return "", false
+}
+
+// Export downloads registration requests as CSV (admin only).
+// ?status= filters by status (default pending); ?status=all exports every request.
+func (h *RegistrationRequestHandler) Export(w http.ResponseWriter, r *http.Request) {
+	status, ok := parseRequestStatus(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.ExportCSV(r.Context(), status)
+	if err != nil {
+		log.Printf("Registration request export failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to export requests")
+		return
+	}
+
+	filename := fmt.Sprintf("registration-requests-%s.csv", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.Write(data)
+}
+
 // CountPending returns the count of pending requests (admin only)
 func (h *RegistrationRequestHandler) CountPending(w http.ResponseWriter, r *http.Request) {
 	count, err := h.service.CountPending(r.Context())
@@ -107,6 +175,10 @@ func (h *RegistrationRequestHandler) ApproveByID(w http.ResponseWriter, r *http.
 			writeError(w, http.StatusServiceUnavailable, "Auth0 not configured")
 			return
 		}
+		if errors.Is(err, service.ErrAuth0Unavailable) {
+			writeError(w, http.StatusServiceUnavailable, "auth0_unavailable")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -149,6 +221,78 @@ func (h *RegistrationRequestHandler) RejectByID(w http.ResponseWriter, r *http.R
 	writeJSON(w, http.StatusOK, map[string]string{"message": "Request rejected"})
 }
 
+// Resend regenerates a pending request's approval link and re-notifies admins (admin only)
+func (h *RegistrationRequestHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request ID")
+		return
+	}
+
+	request, err := h.service.ResendApproval(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrRegistrationRequestNotFound) {
+			writeError(w, http.StatusNotFound, "request not found")
+			return
+		}
+		if errors.Is(err, service.ErrRequestNotPending) {
+			writeError(w, http.StatusBadRequest, "request is not pending")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, request)
+}
+
+// ResendDecision re-sends the applicant's decision email for a reviewed request (admin only)
+func (h *RegistrationRequestHandler) ResendDecision(w http.ResponseWriter, r *http.Request) {
+	currentStaff := middleware.GetStaffFromContext(r.Context())
+	if currentStaff == nil {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request ID")
+		return
+	}
+
+	ticketURL, err := h.service.ResendDecision(r.Context(), id, currentStaff.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRegistrationRequestNotFound) {
+			writeError(w, http.StatusNotFound, "request not found")
+			return
+		}
+		if errors.Is(err, service.ErrRequestStillPending) || errors.Is(err, service.ErrNoDecisionEmail) ||
+			errors.Is(err, service.ErrStaffInactive) || errors.Is(err, service.ErrStaffAlreadyVerified) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			writeError(w, http.StatusNotFound, "approved staff member not found")
+			return
+		}
+		if errors.Is(err, service.ErrAuth0NotConfigured) {
+			writeError(w, http.StatusServiceUnavailable, "Auth0 not configured")
+			return
+		}
+		if errors.Is(err, service.ErrAuth0Unavailable) {
+			writeError(w, http.StatusServiceUnavailable, "auth0_unavailable")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message":    "Decision email re-sent",
+		"ticket_url": ticketURL,
+	})
+}
+
 // GetByToken retrieves a registration request by token (public - for email links)
 func (h *RegistrationRequestHandler) GetByToken(w http.ResponseWriter, r *http.Request) {
 	token := chi.URLParam(r, "token")
@@ -196,6 +340,10 @@ func (h *RegistrationRequestHandler) ApproveByToken(w http.ResponseWriter, r *ht
 			writeError(w, http.StatusServiceUnavailable, "service temporarily unavailable")
 			return
 		}
+		if errors.Is(err, service.ErrAuth0Unavailable) {
+			writeError(w, http.StatusServiceUnavailable, "auth0_unavailable")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/finchley-foodbank/foodbank/internal/service"
+)
+
+type ReportHandler struct {
+	reportService *service.ReportService
+}
+
+func NewReportHandler(reportService *service.ReportService) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+// AttendanceHeatmap returns visit counts by weekday and hour (admin only)
+// GET /api/reports/attendance/heatmap?from=&to=
+func (h *ReportHandler) AttendanceHeatmap(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	heatmap, err := h.reportService.AttendanceHeatmap(r.Context(), from, to)
+	if errors.Is(err, service.ErrInvalidDateRange) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Attendance heatmap failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to build heatmap")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, heatmap)
+}
+
+// Export downloads a ZIP of client visit totals and attendance (admin only)
+// GET /api/reports/export?from=&to=
+func (h *ReportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	zipData, err := h.reportService.ExportReport(r.Context(), from, to)
+	if errors.Is(err, service.ErrInvalidDateRange) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Report export failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to export report")
+		return
+	}
+
+	filename := fmt.Sprintf("foodbank-report-%s.zip", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(zipData)))
+	w.Write(zipData)
+}
+
+// parseDateRange reads optional RFC3339 ?from= and ?to= parameters; an
+// absent one is left zero. The error is suitable for a 400 response.
+func parseDateRange(r *http.Request) (from, to time.Time, err error) {
+	if s := r.URL.Query().Get("from"); s != "" {
+		if from, err = time.Parse(time.RFC3339, s); err != nil {
+			return from, to, errors.New("Invalid from date (expected RFC3339)")
+		}
+	}
+	if s := r.URL.Query().Get("to"); s != "" {
+		if to, err = time.Parse(time.RFC3339, s); err != nil {
+			return from, to, errors.New("Invalid to date (expected RFC3339)")
+		}
+	}
+	return from, to, nil
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseDateRange(t *testing.T) {
+	day := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		query    string
+		from, to time.Time
+		wantErr  bool
+	}{
+		{query: ""},
+		{query: "from=2026-03-01T09:00:00Z", from: day},
+		{query: "to=2026-03-01T09:00:00Z", to: day},
+		{query: "from=2026-03-01", wantErr: true},
+		{query: "from=2026-03-01T09:00:00Z&to=yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			from, to, err := parseDateRange(httptest.NewRequest("GET", "/?"+tt.query, nil))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !from.Equal(tt.from) || !to.Equal(tt.to) {
+				t.Errorf("range = %v..%v, want %v..%v", from, to, tt.from, tt.to)
+			}
+		})
+	}
+}
@@ -1,16 +1,20 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
 	"github.com/finchley-foodbank/foodbank/internal/handler/middleware"
 	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
 	"github.com/finchley-foodbank/foodbank/internal/service"
+	"github.com/finchley-foodbank/foodbank/internal/validate"
 )
 
 type StaffHandler struct {
@@ -34,19 +38,10 @@ func writeError(w http.ResponseWriter, status int, message string) {
 }
 
 // Me returns the current user's staff profile.
-// Returns 403 if the user is authenticated but not registered in the system.
+// Unregistered users are rejected with 403 not_registered by EnsureStaff.
 func (h *StaffHandler) Me(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	auth0ID := middleware.GetAuth0ID(ctx)
-
-	if auth0ID == "" {
-		writeError(w, http.StatusUnauthorized, "unauthorized")
-		return
-	}
-
-	// Look up the staff member by Auth0 ID
-	staff, err := h.staffService.GetByAuth0ID(ctx, auth0ID)
-	if err != nil {
+	staff := middleware.GetStaffFromContext(r.Context())
+	if staff == nil {
 		// User is authenticated but not registered in our system
 		writeError(w, http.StatusForbidden, "not_registered")
 		return
@@ -61,6 +56,51 @@ func (h *StaffHandler) Me(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, staff)
 }
 
+// MyActivity returns the current user's own recent audit entries. The actor
+// always comes from the session, never from the request, so staff can only
+// see their own actions here.
+func (h *StaffHandler) MyActivity(w http.ResponseWriter, r *http.Request) {
+	staff := middleware.GetStaffFromContext(r.Context())
+	if staff == nil {
+		writeError(w, http.StatusForbidden, "not_registered")
+		return
+	}
+	if !staff.IsActive {
+		writeError(w, http.StatusForbidden, "account_inactive")
+		return
+	}
+
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+	offset := 0
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	logs, total, err := h.staffService.ListActivity(r.Context(), staff.ID, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list activity")
+		return
+	}
+	if logs == nil {
+		logs = []model.AuditLog{}
+	}
+
+	writeJSON(w, http.StatusOK, model.AuditLogListResponse{
+		Logs:       logs,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		Pagination: model.NewPagination(total, limit, offset),
+	})
+}
+
 // Get returns a staff member by ID.
 func (h *StaffHandler) Get(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
@@ -72,37 +112,40 @@ func (h *StaffHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	staff, err := h.staffService.GetByID(r.Context(), id)
 	if err != nil {
-		writeError(w, http.StatusNotFound, "staff not found")
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			writeError(w, http.StatusNotFound, "staff not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
 	writeJSON(w, http.StatusOK, staff)
 }
 
-// List returns all staff members.
-// Admins see all staff (including deactivated), regular staff see only active.
+// List returns active staff members. Deactivated staff are only available
+// to admins, through ListDeactivated.
 func (h *StaffHandler) List(w http.ResponseWriter, r *http.Request) {
-	currentStaff := middleware.GetStaffFromContext(r.Context())
-
-	var staff []model.Staff
-	var err error
-
-	// Admins can see all staff including deactivated
-	if currentStaff != nil && currentStaff.Role == model.RoleAdmin {
-		// Check for ?all=true query param
-		if r.URL.Query().Get("all") == "true" {
-			staff, err = h.staffService.ListAll(r.Context())
-		} else {
-			staff, err = h.staffService.List(r.Context())
-		}
-	} else {
-		staff, err = h.staffService.List(r.Context())
+	staff, err := h.staffService.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
 	}
 
+	writeJSON(w, http.StatusOK, staff)
+}
+
+// ListDeactivated returns only deactivated staff. Access is restricted to
+// admins by the route.
+func (h *StaffHandler) ListDeactivated(w http.ResponseWriter, r *http.Request) {
+	staff, err := h.staffService.ListInactive(r.Context())
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
+	if staff == nil {
+		staff = []model.Staff{}
+	}
 
 	writeJSON(w, http.StatusOK, staff)
 }
@@ -117,14 +160,18 @@ func (h *StaffHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req model.UpdateStaffRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeStrict(r, &req); err != nil {
+		writeError(w, decodeStatus(err), err.Error())
 		return
 	}
 
 	staff, err := h.staffService.Update(r.Context(), id, req.Name, req.Email, req.Mobile, req.Address, req.Theme, req.BackgroundImage)
 	if err != nil {
-		writeError(w, http.StatusNotFound, "staff not found")
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			writeError(w, http.StatusNotFound, "staff not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
@@ -140,8 +187,8 @@ func (h *StaffHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req model.InviteStaffRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeStrict(r, &req); err != nil {
+		writeError(w, decodeStatus(err), err.Error())
 		return
 	}
 
@@ -150,6 +197,10 @@ func (h *StaffHandler) Create(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "name, email, and role are required")
 		return
 	}
+	if err := validate.Email(req.Email); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid email")
+		return
+	}
 
 	staff, ticketURL, err := h.staffService.InviteStaff(r.Context(), req, currentStaff.ID)
 	if err != nil {
@@ -157,10 +208,18 @@ func (h *StaffHandler) Create(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusBadRequest, err.Error())
 			return
 		}
+		if errors.Is(err, service.ErrStaffEmailExists) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
 		if errors.Is(err, service.ErrAuth0NotConfigured) {
 			writeError(w, http.StatusServiceUnavailable, "Auth0 Management API not configured")
 			return
 		}
+		if errors.Is(err, service.ErrAuth0Unavailable) {
+			writeError(w, http.StatusServiceUnavailable, "auth0_unavailable")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -208,6 +267,12 @@ func (h *StaffHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 
 // Reactivate reactivates a staff member (admin only).
 func (h *StaffHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	currentStaff := middleware.GetStaffFromContext(r.Context())
+	if currentStaff == nil {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
 	idStr := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
@@ -215,8 +280,12 @@ func (h *StaffHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.staffService.ReactivateStaff(r.Context(), id)
+	err = h.staffService.ReactivateStaff(r.Context(), id, currentStaff.ID)
 	if err != nil {
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			writeError(w, http.StatusNotFound, "staff not found")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -224,6 +293,10 @@ func (h *StaffHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
 	// Return the updated staff record
 	staff, err := h.staffService.GetByID(r.Context(), id)
 	if err != nil {
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			writeError(w, http.StatusNotFound, "staff not found")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -247,8 +320,8 @@ func (h *StaffHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req model.UpdateRoleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeStrict(r, &req); err != nil {
+		writeError(w, decodeStatus(err), err.Error())
 		return
 	}
 
@@ -266,6 +339,10 @@ func (h *StaffHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusBadRequest, "cannot demote the last admin")
 			return
 		}
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			writeError(w, http.StatusNotFound, "staff not found")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -311,6 +388,176 @@ func (h *StaffHandler) EnrollMFA(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"ticket_url": ticketURL})
 }
 
+// GetStaffMFA returns another staff member's MFA status (admin only).
+func (h *StaffHandler) GetStaffMFA(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid staff ID")
+		return
+	}
+
+	status, err := h.staffService.GetStaffMFAStatus(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			writeError(w, http.StatusNotFound, "staff not found")
+			return
+		}
+		if errors.Is(err, service.ErrAuth0NotConfigured) {
+			writeError(w, http.StatusServiceUnavailable, "MFA management not available")
+			return
+		}
+		if errors.Is(err, service.ErrAuth0Unavailable) {
+			writeError(w, http.StatusServiceUnavailable, "auth0_unavailable")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// GetOnboarding returns a staff member's onboarding checklist (admin only).
+func (h *StaffHandler) GetOnboarding(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid staff ID")
+		return
+	}
+
+	status, err := h.staffService.GetOnboardingStatus(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			writeError(w, http.StatusNotFound, "staff not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to get onboarding status")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// ResetStaffMFA clears another staff member's MFA enrollments (admin only).
+func (h *StaffHandler) ResetStaffMFA(w http.ResponseWriter, r *http.Request) {
+	currentStaff := middleware.GetStaffFromContext(r.Context())
+	if currentStaff == nil {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid staff ID")
+		return
+	}
+
+	err = h.staffService.ResetStaffMFA(r.Context(), id, currentStaff.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			writeError(w, http.StatusNotFound, "staff not found")
+			return
+		}
+		if errors.Is(err, service.ErrAuth0NotConfigured) {
+			writeError(w, http.StatusServiceUnavailable, "MFA management not available")
+			return
+		}
+		if errors.Is(err, service.ErrAuth0Unavailable) {
+			writeError(w, http.StatusServiceUnavailable, "auth0_unavailable")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "MFA reset"})
+}
+
+// VerifyEmail marks a staff member's email as verified (admin only).
+func (h *StaffHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	h.setEmailVerification(w, r, h.staffService.MarkEmailVerified)
+}
+
+// UnverifyEmail clears a staff member's email verification so they must
+// verify again (admin only).
+func (h *StaffHandler) UnverifyEmail(w http.ResponseWriter, r *http.Request) {
+	h.setEmailVerification(w, r, h.staffService.ClearEmailVerification)
+}
+
+// setEmailVerification applies an email verification change to the staff
+// member in the URL on behalf of the current admin
+func (h *StaffHandler) setEmailVerification(w http.ResponseWriter, r *http.Request, apply func(ctx context.Context, id, changedBy uuid.UUID) (*model.Staff, error)) {
+	currentStaff := middleware.GetStaffFromContext(r.Context())
+	if currentStaff == nil {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid staff ID")
+		return
+	}
+
+	staff, err := apply(r.Context(), id, currentStaff.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			writeError(w, http.StatusNotFound, "staff not found")
+			return
+		}
+		if errors.Is(err, service.ErrCannotUnverifySelf) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, staff)
+}
+
+// ResendInvite re-sends the password-set email to an invited staff member (admin only).
+func (h *StaffHandler) ResendInvite(w http.ResponseWriter, r *http.Request) {
+	currentStaff := middleware.GetStaffFromContext(r.Context())
+	if currentStaff == nil {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid staff ID")
+		return
+	}
+
+	ticketURL, err := h.staffService.ResendInvite(r.Context(), id, currentStaff.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrStaffNotFound) {
+			writeError(w, http.StatusNotFound, "staff not found")
+			return
+		}
+		if errors.Is(err, service.ErrStaffAlreadyVerified) || errors.Is(err, service.ErrStaffInactive) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrAuth0NotConfigured) {
+			writeError(w, http.StatusServiceUnavailable, "Auth0 Management API not configured")
+			return
+		}
+		if errors.Is(err, service.ErrAuth0Unavailable) {
+			writeError(w, http.StatusServiceUnavailable, "auth0_unavailable")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"ticket_url": ticketURL,
+		"message":    "Invitation re-sent",
+	})
+}
+
 // DisableMFA disables MFA for the current user.
 func (h *StaffHandler) DisableMFA(w http.ResponseWriter, r *http.Request) {
 	auth0ID := middleware.GetAuth0ID(r.Context())
@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/finchley-foodbank/foodbank/internal/handler/middleware"
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/service"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+// withURLParam sets a chi URL parameter on r, as the router would
+func withURLParam(r *http.Request, key, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestGetStaffMFAWithoutAuth0(t *testing.T) {
+	// The Auth0 check comes before any lookup, so no repository is needed
+	h := NewStaffHandler(service.NewStaffService(nil, nil, nil))
+
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/", nil), "id", uuid.NewString())
+	rec := httptest.NewRecorder()
+	h.GetStaffMFA(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503 (body %s)", rec.Code, rec.Body)
+	}
+}
+
+func TestMeIncludesEmailVerification(t *testing.T) {
+	verifiedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	staff := &model.Staff{ID: uuid.New(), Name: "Vera", IsActive: true, EmailVerified: true, EmailVerifiedAt: &verifiedAt}
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.StaffContextKey, staff))
+	rec := httptest.NewRecorder()
+	NewStaffHandler(nil).Me(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body["email_verified"] != true || body["email_verified_at"] != "2026-01-02T03:04:05Z" {
+		t.Errorf("email_verified = %v, email_verified_at = %v; want true and the verification time",
+			body["email_verified"], body["email_verified_at"])
+	}
+}
+
+func TestMyActivityOnlyShowsOwnActions(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	staffRepo := repository.NewStaffRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	clients := service.NewClientService(repository.NewClientRepository(db), auditRepo, time.UTC)
+
+	var actors []*model.Staff
+	for _, name := range []string{"Alice", "Bert"} {
+		staff, err := staffRepo.CreateWithRole(ctx, "auth0|"+name, name, name+"@example.com", model.RoleStaff, nil, nil, &model.SystemStaffID)
+		if err != nil {
+			t.Fatalf("create staff: %v", err)
+		}
+		if _, err := clients.Create(ctx, &model.CreateClientRequest{
+			Name: name + "'s Client", Address: "1 Audit Row", ConsentDataStorage: true,
+		}, staff.ID); err != nil {
+			t.Fatalf("create client: %v", err)
+		}
+		actors = append(actors, staff)
+	}
+	alice, bert := actors[0], actors[1]
+
+	// A staff_id in the query is ignored; only the session decides whose
+	// activity is listed
+	req := httptest.NewRequest(http.MethodGet, "/api/me/activity?staff_id="+bert.ID.String(), nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.StaffContextKey, alice))
+	rec := httptest.NewRecorder()
+	NewStaffHandler(service.NewStaffService(staffRepo, auditRepo, nil)).MyActivity(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+	var resp model.AuditLogListResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Total != 1 || len(resp.Logs) != 1 {
+		t.Fatalf("got %d of %d entries, want Alice's one client creation", len(resp.Logs), resp.Total)
+	}
+	if e := resp.Logs[0]; e.ChangedBy != alice.ID || e.Action != "CREATE" {
+		t.Errorf("entry %s by %v, want Alice's CREATE", e.Action, e.ChangedBy)
+	}
+}
+
+func TestSetEmailVerification(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	staffRepo := repository.NewStaffRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	staffService := service.NewStaffService(staffRepo, auditRepo, nil)
+	staffService.SetRequireEmailVerification(true)
+	h := NewStaffHandler(staffService)
+
+	admin, err := staffRepo.CreateWithRole(ctx, "auth0|admin", "Ada Admin", "ada@example.com", model.RoleAdmin, nil, nil, &model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+	if err := staffRepo.SetEmailVerified(ctx, admin.ID); err != nil {
+		t.Fatalf("verify admin: %v", err)
+	}
+	target, err := staffRepo.CreateWithRole(ctx, "auth0|target", "Tom Target", "tom@example.com", model.RoleStaff, nil, nil, &admin.ID)
+	if err != nil {
+		t.Fatalf("create staff: %v", err)
+	}
+
+	call := func(method string, handle http.HandlerFunc, id uuid.UUID) *httptest.ResponseRecorder {
+		req := withURLParam(httptest.NewRequest(method, "/api/staff/"+id.String()+"/verify-email", nil), "id", id.String())
+		req = req.WithContext(context.WithValue(req.Context(), middleware.StaffContextKey, admin))
+		rec := httptest.NewRecorder()
+		handle(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		handle       http.HandlerFunc
+		wantVerified bool
+		wantAction   string
+	}{
+		{"verify", http.MethodPost, h.VerifyEmail, true, "EMAIL_VERIFIED"},
+		{"unverify", http.MethodDelete, h.UnverifyEmail, false, "EMAIL_UNVERIFIED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := call(tt.method, tt.handle, target.ID)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body)
+			}
+			var got model.Staff
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.EmailVerified != tt.wantVerified {
+				t.Errorf("email_verified = %v, want %v", got.EmailVerified, tt.wantVerified)
+			}
+			entries, err := auditRepo.GetRecentByRecordID(ctx, "staff", target.ID, 1)
+			if err != nil {
+				t.Fatalf("audit lookup: %v", err)
+			}
+			if len(entries) != 1 || entries[0].Action != tt.wantAction || entries[0].ChangedBy != admin.ID {
+				t.Errorf("audit entries = %+v, want %s by the admin", entries, tt.wantAction)
+			}
+		})
+	}
+
+	t.Run("admin cannot unverify themselves", func(t *testing.T) {
+		rec := call(http.MethodDelete, h.UnverifyEmail, admin.ID)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400 (body %s)", rec.Code, rec.Body)
+		}
+		still, err := staffRepo.GetByID(ctx, admin.ID)
+		if err != nil {
+			t.Fatalf("get admin: %v", err)
+		}
+		if !still.EmailVerified {
+			t.Error("admin's own verification was cleared")
+		}
+	})
+}
+
+func TestListDeactivated(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	staffRepo := repository.NewStaffRepository(db)
+	staffService := service.NewStaffService(staffRepo, repository.NewAuditRepository(db), nil)
+	// Wired as in main.go
+	h := middleware.RequireRole(staffService, model.RoleAdmin)(http.HandlerFunc(NewStaffHandler(staffService).ListDeactivated))
+
+	admin, err := staffRepo.CreateWithRole(ctx, "auth0|admin", "Ada Admin", "ada@example.com", model.RoleAdmin, nil, nil, &model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+	active, err := staffRepo.CreateWithRole(ctx, "auth0|active", "Active Al", "al@example.com", model.RoleStaff, nil, nil, &admin.ID)
+	if err != nil {
+		t.Fatalf("create staff: %v", err)
+	}
+	leaver, err := staffRepo.CreateWithRole(ctx, "auth0|leaver", "Lee Leaver", "lee@example.com", model.RoleStaff, nil, nil, &admin.ID)
+	if err != nil {
+		t.Fatalf("create staff: %v", err)
+	}
+	if err := staffService.DeactivateStaff(ctx, leaver.ID, admin.ID); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+
+	as := func(staff *model.Staff) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/staff/deactivated", nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.StaffContextKey, staff))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := as(active); rec.Code != http.StatusForbidden {
+		t.Errorf("as staff: status = %d, want 403", rec.Code)
+	}
+
+	rec := as(admin)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("as admin: status = %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+	var got []model.Staff
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != leaver.ID || got[0].IsActive {
+		t.Errorf("deactivated = %+v, want only Lee", got)
+	}
+}
+
+func TestUpdateRoleDecodesStrictly(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	staffRepo := repository.NewStaffRepository(db)
+	h := NewStaffHandler(service.NewStaffService(staffRepo, repository.NewAuditRepository(db), nil))
+
+	admin, err := staffRepo.CreateWithRole(ctx, "auth0|admin", "Ada Admin", "ada@example.com", model.RoleAdmin, nil, nil, &model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+	target, err := staffRepo.CreateWithRole(ctx, "auth0|target", "Tom Target", "tom@example.com", model.RoleStaff, nil, nil, &admin.ID)
+	if err != nil {
+		t.Fatalf("create staff: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		want     int
+		wantRole string
+	}{
+		// A typo must not silently leave the role unchanged
+		{"misspelt field", `{"roll":"admin"}`, http.StatusBadRequest, model.RoleStaff},
+		{"correct body", `{"role":"admin"}`, http.StatusOK, model.RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withURLParam(httptest.NewRequest(http.MethodPut, "/api/staff/"+target.ID.String()+"/role", strings.NewReader(tt.body)), "id", target.ID.String())
+			req = req.WithContext(context.WithValue(req.Context(), middleware.StaffContextKey, admin))
+			rec := httptest.NewRecorder()
+			h.UpdateRole(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusBadRequest && !strings.Contains(rec.Body.String(), "roll") {
+				t.Errorf("body %s does not name the unknown field", rec.Body)
+			}
+			got, err := staffRepo.GetByID(ctx, target.ID)
+			if err != nil {
+				t.Fatalf("get staff: %v", err)
+			}
+			if got.Role != tt.wantRole {
+				t.Errorf("role = %q, want %q", got.Role, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestStaffLookupFailureIsNotNotFound(t *testing.T) {
+	// Nothing listens on port 1, so every query fails with a connection error
+	db, err := pgxpool.New(context.Background(), "postgres://foodbank@127.0.0.1:1/foodbank?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	defer db.Close()
+	h := NewStaffHandler(service.NewStaffService(repository.NewStaffRepository(db), repository.NewAuditRepository(db), nil))
+	admin := &model.Staff{ID: uuid.New(), Role: model.RoleAdmin, IsActive: true}
+	id := uuid.NewString()
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		handle http.HandlerFunc
+	}{
+		{"get", http.MethodGet, "", h.Get},
+		{"update", http.MethodPut, `{"name":"New Name"}`, h.Update},
+		{"reactivate", http.MethodPost, "", h.Reactivate},
+		{"update role", http.MethodPut, `{"role":"admin"}`, h.UpdateRole},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withURLParam(httptest.NewRequest(tt.method, "/api/staff/"+id, strings.NewReader(tt.body)), "id", id)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.StaffContextKey, admin))
+			rec := httptest.NewRecorder()
+			tt.handle(rec, req)
+
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want 500 (body %s)", rec.Code, rec.Body)
+			}
+		})
+	}
+}
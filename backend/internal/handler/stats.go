@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/finchley-foodbank/foodbank/internal/service"
+)
+
+type StatsHandler struct {
+	statsService *service.StatsService
+}
+
+func NewStatsHandler(statsService *service.StatsService) *StatsHandler {
+	return &StatsHandler{statsService: statsService}
+}
+
+// Dashboard returns the admin dashboard counts in one response (admin only)
+// GET /api/admin/stats
+func (h *StatsHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.statsService.Dashboard(r.Context())
+	if err != nil {
+		log.Printf("Dashboard stats failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to load stats")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/service"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+func TestDashboardStats(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	clientRepo := repository.NewClientRepository(db)
+	clients := service.NewClientService(clientRepo, repository.NewAuditRepository(db), time.UTC)
+	client, err := clients.Create(ctx, &model.CreateClientRequest{
+		Name: "Counted Client", Address: "1 Stats Street", ConsentDataStorage: true,
+	}, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	if _, _, err := clients.RecordAttendance(ctx, client.ID, model.SystemStaffID, false, ""); err != nil {
+		t.Fatalf("record attendance: %v", err)
+	}
+
+	h := NewStatsHandler(service.NewStatsService(clientRepo, repository.NewStaffRepository(db), repository.NewRegistrationRequestRepository(db)))
+	get := func() map[string]any {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.Dashboard(rec, httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return body
+	}
+
+	body := get()
+	for _, field := range []string{"clients_total", "clients_active", "staff_active", "staff_admins", "pending_requests", "attendance_last_7_days"} {
+		if _, ok := body[field].(float64); !ok {
+			t.Errorf("%s = %#v, want a number", field, body[field])
+		}
+	}
+	if body["clients_total"] != 1.0 || body["attendance_last_7_days"] != 1.0 {
+		t.Errorf("clients_total %v, attendance_last_7_days %v; want 1 and 1", body["clients_total"], body["attendance_last_7_days"])
+	}
+
+	// A second poll within the TTL is served from cache
+	if _, err := clients.Create(ctx, &model.CreateClientRequest{
+		Name: "Late Client", Address: "2 Stats Street", ConsentDataStorage: true,
+	}, model.SystemStaffID); err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	if again := get(); again["clients_total"] != 1.0 || again["generated_at"] != body["generated_at"] {
+		t.Errorf("second poll = %v, want the cached stats", again)
+	}
+}
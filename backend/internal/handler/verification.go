@@ -1,9 +1,9 @@
 package handler
 
 import (
-	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/finchley-foodbank/foodbank/internal/handler/middleware"
 	"github.com/finchley-foodbank/foodbank/internal/model"
@@ -32,7 +32,14 @@ func (h *VerificationHandler) SendCode(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, service.ErrAlreadyVerified):
 			writeError(w, http.StatusBadRequest, "email already verified")
 		case errors.Is(err, service.ErrRateLimited):
-			writeError(w, http.StatusTooManyRequests, "too many requests, please wait before trying again")
+			body := map[string]interface{}{"error": "too many requests, please wait before trying again"}
+			var rlErr *service.RateLimitedError
+			if errors.As(err, &rlErr) {
+				seconds := int(rlErr.RetryAfter.Seconds()) + 1
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				body["retry_after_seconds"] = seconds
+			}
+			writeJSON(w, http.StatusTooManyRequests, body)
 		case errors.Is(err, service.ErrEmailNotConfigured):
 			writeError(w, http.StatusServiceUnavailable, "email service not available")
 		default:
@@ -53,8 +60,8 @@ func (h *VerificationHandler) VerifyCode(w http.ResponseWriter, r *http.Request)
 	}
 
 	var req model.VerifyCodeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, decodeStatus(err), err.Error())
 		return
 	}
 
@@ -76,9 +83,17 @@ func (h *VerificationHandler) VerifyCode(w http.ResponseWriter, r *http.Request)
 		case errors.Is(err, service.ErrCodeExpired):
 			writeError(w, http.StatusGone, "verification code has expired")
 		case errors.Is(err, service.ErrInvalidCode):
-			writeError(w, http.StatusBadRequest, "invalid verification code")
+			body := map[string]interface{}{"error": "invalid verification code"}
+			var codeErr *service.InvalidCodeError
+			if errors.As(err, &codeErr) {
+				body["remaining_attempts"] = codeErr.RemainingAttempts
+			}
+			writeJSON(w, http.StatusBadRequest, body)
 		case errors.Is(err, service.ErrTooManyAttempts):
-			writeError(w, http.StatusTooManyRequests, "too many incorrect attempts, please request a new code")
+			writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+				"error":              "too many incorrect attempts, please request a new code",
+				"remaining_attempts": 0,
+			})
 		default:
 			writeError(w, http.StatusInternalServerError, "failed to verify code")
 		}
@@ -11,6 +11,46 @@ type Attendance struct {
 	ClientID   uuid.UUID `json:"client_id"`
 	VerifiedBy uuid.UUID `json:"verified_by"`
 	VerifiedAt time.Time `json:"verified_at"`
+	// OverrideReason is set when an admin recorded the visit inside the cooldown
+	OverrideReason *string `json:"override_reason,omitempty"`
+	// VoidedAt is set when the visit was recorded in error and voided
+	VoidedAt *time.Time `json:"voided_at,omitempty"`
+	VoidedBy *uuid.UUID `json:"voided_by,omitempty"`
+}
+
+// AttendanceCheck holds the dedup and cooldown rules applied, under the
+// client's advisory lock, before a visit is recorded. Both windows are
+// measured from At to the client's nearest existing visit.
+type AttendanceCheck struct {
+	// At is when the visit happened; nil means now
+	At *time.Time
+	// DedupWindow returns an existing visit this close instead of recording
+	// another (0 disables deduplication)
+	DedupWindow time.Duration
+	// Cooldown blocks a visit this close, outside DedupWindow, unless
+	// Override is set (0 = unlimited)
+	Cooldown       time.Duration
+	Override       bool
+	OverrideReason *string // stored only when the override was needed
+}
+
+// AttendanceOutcome reports what happened to a checked visit
+type AttendanceOutcome struct {
+	// Attendance is the new visit, or the existing one for a duplicate; nil
+	// when the visit was too soon
+	Attendance *Attendance
+	Created    bool
+	Duplicate  bool
+	TooSoon    bool
+	// Overridden is set when the visit was recorded despite the cooldown
+	Overridden bool
+	// Nearest is the client's existing visit closest to the checked time
+	Nearest *Attendance
+}
+
+// RecordAttendanceRequest is the optional body for recording a visit
+type RecordAttendanceRequest struct {
+	Reason string `json:"reason"`
 }
 
 type AttendanceWithDetails struct {
@@ -18,3 +58,90 @@ type AttendanceWithDetails struct {
 	ClientName   string `json:"client_name"`
 	VerifiedName string `json:"verified_by_name"`
 }
+
+// BulkAttendanceRequest records visits for many scanned barcodes at once
+type BulkAttendanceRequest struct {
+	Barcodes []string `json:"barcodes"`
+}
+
+// BulkAttendanceResult is the outcome for a single barcode in a bulk request
+type BulkAttendanceResult struct {
+	Barcode    string      `json:"barcode"`
+	Status     string      `json:"status"` // recorded, duplicate, not_found, too_soon, error
+	ClientID   *uuid.UUID  `json:"client_id,omitempty"`
+	Attendance *Attendance `json:"attendance,omitempty"`
+}
+
+type BulkAttendanceResponse struct {
+	Results    []BulkAttendanceResult `json:"results"`
+	Recorded   int                    `json:"recorded"`
+	Duplicates int                    `json:"duplicates"`
+	NotFound   int                    `json:"not_found"`
+	TooSoon    int                    `json:"too_soon"`
+	Failed     int                    `json:"failed"`
+}
+
+// AttendanceImportVisit is a resolved visit to record from an offline import
+type AttendanceImportVisit struct {
+	ClientID   uuid.UUID
+	VerifiedAt time.Time
+}
+
+// AttendanceImportResult is the outcome for one line of an attendance import
+type AttendanceImportResult struct {
+	Line       int         `json:"line"`
+	Barcode    string      `json:"barcode"`
+	Status     string      `json:"status"` // recorded, not_found, duplicate, too_soon, invalid
+	Message    string      `json:"message,omitempty"`
+	ClientID   *uuid.UUID  `json:"client_id,omitempty"`
+	Attendance *Attendance `json:"attendance,omitempty"`
+}
+
+type AttendanceImportResponse struct {
+	Results    []AttendanceImportResult `json:"results"`
+	Matched    int                      `json:"matched"`
+	Unmatched  int                      `json:"unmatched"`
+	Duplicates int                      `json:"duplicates"`
+	TooSoon    int                      `json:"too_soon"`
+	Invalid    int                      `json:"invalid"`
+}
+
+// AttendanceQuery filters a client's attendance history. From and To are
+// optional bounds on verified_at (inclusive).
+type AttendanceQuery struct {
+	Limit int
+	From  *time.Time
+	To    *time.Time
+}
+
+// AttendanceRangeQuery pages through all attendance with verified_at in
+// [From, To)
+type AttendanceRangeQuery struct {
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// MonthlyAttendance is the visit total for a single calendar month
+type MonthlyAttendance struct {
+	Month         time.Time `json:"month"`
+	Visits        int       `json:"visits"`
+	UniqueClients int       `json:"unique_clients"`
+}
+
+// HeatmapCell is the visit count for one weekday/hour slot.
+// DayOfWeek follows Postgres dow: 0 = Sunday ... 6 = Saturday.
+type HeatmapCell struct {
+	DayOfWeek int `json:"day_of_week"`
+	Hour      int `json:"hour"`
+	Visits    int `json:"visits"`
+}
+
+// AttendanceHeatmap holds all 7x24 weekday/hour slots for a date range
+type AttendanceHeatmap struct {
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Timezone string        `json:"timezone"`
+	Cells    []HeatmapCell `json:"cells"`
+}
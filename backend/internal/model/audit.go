@@ -1,7 +1,10 @@
 package model
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,4 +29,59 @@ type AuditLogListResponse struct {
 	Total  int        `json:"total"`
 	Limit  int        `json:"limit"`
 	Offset int        `json:"offset"`
+	Pagination
+	// Keyset cursors for ?after= / ?before=; empty when there is no such page
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// ErrInvalidAuditCursor is returned for cursors not produced by EncodeAuditCursor
+var ErrInvalidAuditCursor = errors.New("invalid audit cursor")
+
+// AuditCursor is a position in the audit log ordered by (changed_at, id)
+type AuditCursor struct {
+	ChangedAt time.Time
+	ID        uuid.UUID
+}
+
+// EncodeAuditCursor returns an opaque cursor string for entry
+func EncodeAuditCursor(entry *AuditLog) string {
+	raw := entry.ChangedAt.UTC().Format(time.RFC3339Nano) + "|" + entry.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseAuditCursor decodes a cursor produced by EncodeAuditCursor
+func ParseAuditCursor(s string) (*AuditCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidAuditCursor
+	}
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidAuditCursor
+	}
+	changedAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return nil, ErrInvalidAuditCursor
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidAuditCursor
+	}
+	return &AuditCursor{ChangedAt: changedAt, ID: parsedID}, nil
+}
+
+// AuditLogFilter narrows and orders an audit log listing. Zero values
+// mean "no filter"; Order is "asc" or "desc" (default desc).
+type AuditLogFilter struct {
+	TableName string
+	RecordID  *uuid.UUID
+	ChangedBy *uuid.UUID
+	From      *time.Time
+	To        *time.Time
+	Order     string
+	// After/Before select the page following/preceding a cursor in Order;
+	// when set, the offset is ignored
+	After  *AuditCursor
+	Before *AuditCursor
 }
@@ -6,6 +6,10 @@ import (
 	"github.com/google/uuid"
 )
 
+// MaxDietaryNotesLength is the longest free-text dietary note accepted.
+// Notes are stored as entered and must be escaped when rendered as HTML.
+const MaxDietaryNotesLength = 500
+
 type Client struct {
 	ID              uuid.UUID `json:"id"`
 	BarcodeID       string    `json:"barcode_id"`
@@ -22,44 +26,156 @@ type Client struct {
 	PrefHalal       bool      `json:"pref_halal"`
 	PrefVegetarian  bool      `json:"pref_vegetarian"`
 	PrefNoCooking   bool      `json:"pref_no_cooking"`
-	CreatedAt       time.Time `json:"created_at"`
-	CreatedBy       uuid.UUID `json:"created_by"`
+	DietaryNotes    *string   `json:"dietary_notes,omitempty"`
+	// Data-protection consent; ConsentRecordedAt is when either flag was last set
+	ConsentDataStorage bool       `json:"consent_data_storage"`
+	ConsentPhoto       bool       `json:"consent_photo"`
+	ConsentRecordedAt  *time.Time `json:"consent_recorded_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	CreatedBy          uuid.UUID  `json:"created_by"`
+	ArchivedAt         *time.Time `json:"archived_at,omitempty"`
+	ArchivedBy         *uuid.UUID `json:"archived_by,omitempty"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	// VisitCount is the total attendance count; nil when not computed
+	VisitCount *int `json:"visit_count,omitempty"`
+	// CreatedByName is the creator's name; nil when not joined or the staff row is gone
+	CreatedByName *string `json:"created_by_name,omitempty"`
 }
 
 type CreateClientRequest struct {
-	Name            string  `json:"name"`
-	Address         string  `json:"address"`
-	FamilySize      int     `json:"family_size"`
-	NumChildren     int     `json:"num_children"`
-	ChildrenAges    *string `json:"children_ages,omitempty"`
-	Reason          *string `json:"reason,omitempty"`
-	PhotoURL        *string `json:"photo_url,omitempty"`
-	AppointmentDay  *string `json:"appointment_day,omitempty"`
-	AppointmentTime *string `json:"appointment_time,omitempty"`
-	PrefGlutenFree  bool    `json:"pref_gluten_free"`
-	PrefHalal       bool    `json:"pref_halal"`
-	PrefVegetarian  bool    `json:"pref_vegetarian"`
-	PrefNoCooking   bool    `json:"pref_no_cooking"`
+	Name               string  `json:"name"`
+	Address            string  `json:"address"`
+	FamilySize         *int    `json:"family_size,omitempty"` // defaults to 1 when omitted
+	NumChildren        int     `json:"num_children"`
+	ChildrenAges       *string `json:"children_ages,omitempty"`
+	Reason             *string `json:"reason,omitempty"`
+	PhotoURL           *string `json:"photo_url,omitempty"`
+	AppointmentDay     *string `json:"appointment_day,omitempty"`
+	AppointmentTime    *string `json:"appointment_time,omitempty"`
+	PrefGlutenFree     bool    `json:"pref_gluten_free"`
+	PrefHalal          bool    `json:"pref_halal"`
+	PrefVegetarian     bool    `json:"pref_vegetarian"`
+	PrefNoCooking      bool    `json:"pref_no_cooking"`
+	DietaryNotes       *string `json:"dietary_notes,omitempty"`
+	ConsentDataStorage bool    `json:"consent_data_storage"`
+	ConsentPhoto       bool    `json:"consent_photo"`
 }
 
 type UpdateClientRequest struct {
-	Name            *string `json:"name,omitempty"`
-	Address         *string `json:"address,omitempty"`
-	FamilySize      *int    `json:"family_size,omitempty"`
-	NumChildren     *int    `json:"num_children,omitempty"`
-	ChildrenAges    *string `json:"children_ages,omitempty"`
-	Reason          *string `json:"reason,omitempty"`
-	PhotoURL        *string `json:"photo_url,omitempty"`
-	AppointmentDay  *string `json:"appointment_day,omitempty"`
-	AppointmentTime *string `json:"appointment_time,omitempty"`
-	PrefGlutenFree  *bool   `json:"pref_gluten_free,omitempty"`
-	PrefHalal       *bool   `json:"pref_halal,omitempty"`
-	PrefVegetarian  *bool   `json:"pref_vegetarian,omitempty"`
-	PrefNoCooking   *bool   `json:"pref_no_cooking,omitempty"`
+	Name               *string `json:"name,omitempty"`
+	Address            *string `json:"address,omitempty"`
+	FamilySize         *int    `json:"family_size,omitempty"`
+	NumChildren        *int    `json:"num_children,omitempty"`
+	ChildrenAges       *string `json:"children_ages,omitempty"`
+	Reason             *string `json:"reason,omitempty"`
+	PhotoURL           *string `json:"photo_url,omitempty"`
+	AppointmentDay     *string `json:"appointment_day,omitempty"`
+	AppointmentTime    *string `json:"appointment_time,omitempty"`
+	PrefGlutenFree     *bool   `json:"pref_gluten_free,omitempty"`
+	PrefHalal          *bool   `json:"pref_halal,omitempty"`
+	PrefVegetarian     *bool   `json:"pref_vegetarian,omitempty"`
+	PrefNoCooking      *bool   `json:"pref_no_cooking,omitempty"`
+	DietaryNotes       *string `json:"dietary_notes,omitempty"`
+	ConsentDataStorage *bool   `json:"consent_data_storage,omitempty"`
+	ConsentPhoto       *bool   `json:"consent_photo,omitempty"`
+	// ExpectedUpdatedAt is the updated_at the caller last read; when set, the
+	// update fails with a conflict if the client has changed since
+	ExpectedUpdatedAt *time.Time `json:"expected_updated_at,omitempty"`
+}
+
+// ClientAppointment is one of a client's recurring weekly slots. The primary
+// slot is mirrored in the client's AppointmentDay/AppointmentTime.
+type ClientAppointment struct {
+	ID        uuid.UUID `json:"id"`
+	ClientID  uuid.UUID `json:"client_id"`
+	Day       string    `json:"day"`
+	Time      *string   `json:"time,omitempty"`
+	IsPrimary bool      `json:"is_primary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateAppointmentRequest is the input for adding an appointment slot.
+// A client's first slot is always primary.
+type CreateAppointmentRequest struct {
+	Day     string  `json:"day"`
+	Time    *string `json:"time,omitempty"`
+	Primary bool    `json:"primary"`
+}
+
+// ClientProfile bundles everything the client detail screen shows so it can
+// be fetched in one request. Attendance and Audit hold the newest entries only.
+type ClientProfile struct {
+	Client     *Client                 `json:"client"`
+	Attendance []AttendanceWithDetails `json:"attendance"`
+	Audit      []AuditLog              `json:"audit"`
+}
+
+// BarcodeLookupResponse reports whether a barcode belongs to an active client
+// without exposing any personal details
+type BarcodeLookupResponse struct {
+	Exists   bool       `json:"exists"`
+	ClientID *uuid.UUID `json:"client_id,omitempty"`
+}
+
+// BatchUpdateClientsRequest applies the same partial update to many clients
+type BatchUpdateClientsRequest struct {
+	IDs    []uuid.UUID         `json:"ids"`
+	Update UpdateClientRequest `json:"update"`
+}
+
+// BatchUpdateResult is the outcome for a single client in a batch update
+type BatchUpdateResult struct {
+	ID     uuid.UUID `json:"id"`
+	Status string    `json:"status"` // updated, not_found
+	Client *Client   `json:"client,omitempty"`
+}
+
+type BatchUpdateClientsResponse struct {
+	Results  []BatchUpdateResult `json:"results"`
+	Updated  int                 `json:"updated"`
+	NotFound int                 `json:"not_found"`
 }
 
 type ClientSearchParams struct {
-	Query  string `json:"query"`
+	Query           string `json:"query"`
+	IncludeNotes    bool   `json:"include_notes"` // also match dietary_notes
+	IncludeArchived bool   `json:"include_archived"`
+	// Optional exact filters; nil means "any"
+	AppointmentDay *string `json:"appointment_day,omitempty"`
+	PrefGlutenFree *bool   `json:"pref_gluten_free,omitempty"`
+	PrefHalal      *bool   `json:"pref_halal,omitempty"`
+	PrefVegetarian *bool   `json:"pref_vegetarian,omitempty"`
+	PrefNoCooking  *bool   `json:"pref_no_cooking,omitempty"`
+	// ChildAgeMin/ChildAgeMax match clients with at least one child whose age
+	// falls in the (inclusive) range; either bound may be left open
+	ChildAgeMin *int `json:"child_age_min,omitempty"`
+	ChildAgeMax *int `json:"child_age_max,omitempty"`
+	// Sort is one of ClientSortKeys; empty sorts by name
+	Sort   string `json:"sort,omitempty"`
 	Limit  int    `json:"limit"`
 	Offset int    `json:"offset"`
 }
+
+// ClientSortKeys are the accepted client list orderings. A leading "-" sorts
+// descending; "appointment" orders by weekday then time, unbooked clients last.
+var ClientSortKeys = []string{"name", "-name", "created_at", "-created_at", "appointment"}
+
+// ValidClientSort reports whether sort is empty or one of ClientSortKeys
+func ValidClientSort(sort string) bool {
+	if sort == "" {
+		return true
+	}
+	for _, k := range ClientSortKeys {
+		if k == sort {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFilters reports whether any of the optional exact filters are set
+func (p *ClientSearchParams) HasFilters() bool {
+	return p.AppointmentDay != nil || p.PrefGlutenFree != nil || p.PrefHalal != nil ||
+		p.PrefVegetarian != nil || p.PrefNoCooking != nil ||
+		p.ChildAgeMin != nil || p.ChildAgeMax != nil
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Email delivery statuses
+const (
+	EmailStatusSent   = "sent"
+	EmailStatusFailed = "failed"
+)
+
+// EmailLog records one outgoing email and whether Resend accepted it
+type EmailLog struct {
+	ID          uuid.UUID `json:"id"`
+	Recipient   string    `json:"recipient"`
+	MessageType string    `json:"message_type"`
+	MessageID   *string   `json:"message_id,omitempty"`
+	Status      string    `json:"status"`
+	Error       *string   `json:"error,omitempty"`
+	Attempts    int       `json:"attempts"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// EmailLogListResponse is a page of email log entries, newest first
+type EmailLogListResponse struct {
+	Entries []EmailLog `json:"entries"`
+	Total   int        `json:"total"`
+	Limit   int        `json:"limit"`
+	Offset  int        `json:"offset"`
+	Pagination
+}
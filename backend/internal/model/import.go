@@ -4,19 +4,22 @@ import "github.com/google/uuid"
 
 // ImportClientRow represents a single row from the CSV import
 type ImportClientRow struct {
-	RowNumber       int     `json:"row_number"`
-	Name            string  `json:"name"`
-	Address         string  `json:"address"`
-	FamilySize      int     `json:"family_size"`
-	NumChildren     int     `json:"num_children"`
-	ChildrenAges    *string `json:"children_ages,omitempty"`
-	Reason          *string `json:"reason,omitempty"`
-	AppointmentDay  *string `json:"appointment_day,omitempty"`
-	AppointmentTime *string `json:"appointment_time,omitempty"`
-	PrefGlutenFree  bool    `json:"pref_gluten_free"`
-	PrefHalal       bool    `json:"pref_halal"`
-	PrefVegetarian  bool    `json:"pref_vegetarian"`
-	PrefNoCooking   bool    `json:"pref_no_cooking"`
+	RowNumber          int     `json:"row_number"`
+	Name               string  `json:"name"`
+	Address            string  `json:"address"`
+	FamilySize         int     `json:"family_size"`
+	NumChildren        int     `json:"num_children"`
+	ChildrenAges       *string `json:"children_ages,omitempty"`
+	Reason             *string `json:"reason,omitempty"`
+	AppointmentDay     *string `json:"appointment_day,omitempty"`
+	AppointmentTime    *string `json:"appointment_time,omitempty"`
+	PrefGlutenFree     bool    `json:"pref_gluten_free"`
+	PrefHalal          bool    `json:"pref_halal"`
+	PrefVegetarian     bool    `json:"pref_vegetarian"`
+	PrefNoCooking      bool    `json:"pref_no_cooking"`
+	DietaryNotes       *string `json:"dietary_notes,omitempty"`
+	ConsentDataStorage bool    `json:"consent_data_storage"`
+	ConsentPhoto       bool    `json:"consent_photo"`
 }
 
 // ValidationError represents an error in a specific row/field
@@ -42,6 +45,8 @@ type ValidationResult struct {
 	ValidRows int                 `json:"valid_rows"`
 	Errors    []ValidationError   `json:"errors"`
 	Warnings  []ValidationWarning `json:"warnings"`
+	// Clients holds the parsed rows for an uploaded file, ready to import
+	Clients []ImportClientRow `json:"clients,omitempty"`
 }
 
 // ImportRequest is the request body for importing clients
@@ -49,6 +54,8 @@ type ImportRequest struct {
 	Clients        []ImportClientRow `json:"clients"`
 	SkipDuplicates bool              `json:"skip_duplicates"`
 	BatchSize      int               `json:"batch_size"`
+	// DryRun runs the import exactly as normal but rolls every batch back
+	DryRun bool `json:"dry_run"`
 }
 
 // ImportedClient represents a successfully imported client
@@ -73,6 +80,7 @@ type BatchResult struct {
 // ImportResult contains the complete results of an import operation
 type ImportResult struct {
 	Success         bool             `json:"success"`
+	DryRun          bool             `json:"dry_run"`
 	Total           int              `json:"total"`
 	Imported        int              `json:"imported"`
 	Skipped         int              `json:"skipped"`
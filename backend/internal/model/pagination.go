@@ -0,0 +1,24 @@
+package model
+
+// Pagination is computed paging metadata embedded in offset-paged list
+// responses so clients don't have to do the page arithmetic themselves
+type Pagination struct {
+	HasNext    bool `json:"has_next"`
+	HasPrev    bool `json:"has_prev"`
+	TotalPages int  `json:"total_pages"`
+}
+
+// NewPagination derives paging metadata from a total, page size and offset.
+// A non-positive limit is treated as a single page holding every result.
+func NewPagination(total, limit, offset int) Pagination {
+	p := Pagination{HasPrev: offset > 0}
+	if limit <= 0 {
+		if total > 0 {
+			p.TotalPages = 1
+		}
+		return p
+	}
+	p.TotalPages = (total + limit - 1) / limit
+	p.HasNext = offset+limit < total
+	return p
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClientVisitSummary is a client's reporting columns with visit totals
+// computed over the report's date range
+type ClientVisitSummary struct {
+	ID              uuid.UUID
+	BarcodeID       string
+	Name            string
+	Address         string
+	FamilySize      int
+	NumChildren     int
+	AppointmentDay  *string
+	AppointmentTime *string
+	PrefGlutenFree  bool
+	PrefHalal       bool
+	PrefVegetarian  bool
+	PrefNoCooking   bool
+	CreatedAt       time.Time
+	ArchivedAt      *time.Time
+	VisitCount      int
+	LastVisit       *time.Time
+}
+
+// AttendanceReportRow is a single visit joined to its client and to the
+// staff member who verified it (including deactivated staff)
+type AttendanceReportRow struct {
+	ID             uuid.UUID
+	ClientID       uuid.UUID
+	BarcodeID      string
+	ClientName     string
+	VerifiedByName string
+	VerifiedAt     time.Time
+}
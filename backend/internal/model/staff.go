@@ -23,6 +23,9 @@ type Staff struct {
 	CreatedBy       *uuid.UUID `json:"created_by,omitempty"`
 	DeactivatedAt   *time.Time `json:"deactivated_at,omitempty"`
 	DeactivatedBy   *uuid.UUID `json:"deactivated_by,omitempty"`
+	// NameIsPlaceholder marks a name that was not supplied by the user and
+	// may be replaced from Auth0 claims
+	NameIsPlaceholder bool `json:"-"`
 }
 
 const (
@@ -30,6 +33,11 @@ const (
 	RoleStaff = "staff"
 )
 
+// SystemStaffID is the seeded, inactive staff row that changes are
+// attributed to when no staff member is signed in: recovery-token requests
+// and token-based registration approvals. It is never listed or reactivated.
+var SystemStaffID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
 // InviteStaffRequest is used to invite a new staff member
 type InviteStaffRequest struct {
 	Name    string  `json:"name"`
@@ -53,7 +61,7 @@ type UpdateStaffRequest struct {
 	Mobile          *string `json:"mobile,omitempty"`
 	Address         *string `json:"address,omitempty"`
 	Theme           string  `json:"theme"`
-	BackgroundImage string  `json:"background_image"`
+	BackgroundImage *string `json:"background_image,omitempty"` // nil keeps the existing value
 }
 
 // UpdateRoleRequest is used to change a staff member's role
@@ -66,3 +74,19 @@ type MFAStatus struct {
 	Enrolled bool     `json:"enrolled"`
 	Factors  []string `json:"factors"`
 }
+
+// StaffOnboardingStatus summarises a staff member's onboarding checklist.
+// Fields sourced from Auth0 are nil when Auth0 is unconfigured or unreachable,
+// as reported by Auth0Available. MFAEnrolled is also nil when only the MFA
+// lookup failed; Complete is then false until enrollment can be confirmed.
+type StaffOnboardingStatus struct {
+	StaffID         uuid.UUID  `json:"staff_id"`
+	LoggedIn        *bool      `json:"logged_in"`
+	LastLoginAt     *time.Time `json:"last_login_at,omitempty"`
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	MFAEnrolled     *bool      `json:"mfa_enrolled"`
+	MFAFactors      []string   `json:"mfa_factors,omitempty"`
+	Auth0Available  bool       `json:"auth0_available"`
+	Complete        bool       `json:"complete"`
+}
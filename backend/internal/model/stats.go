@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// DashboardStats are the headline counts shown on the admin dashboard
+type DashboardStats struct {
+	ClientsTotal        int       `json:"clients_total"`
+	ClientsActive       int       `json:"clients_active"`
+	StaffActive         int       `json:"staff_active"`
+	StaffAdmins         int       `json:"staff_admins"`
+	PendingRequests     int       `json:"pending_requests"`
+	AttendanceLast7Days int       `json:"attendance_last_7_days"`
+	GeneratedAt         time.Time `json:"generated_at"`
+}
@@ -0,0 +1,12 @@
+package model
+
+// FieldError describes why a single request field is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorResponse is the 400 body listing every invalid field
+type ValidationErrorResponse struct {
+	Errors []FieldError `json:"errors"`
+}
@@ -0,0 +1,49 @@
+// Package redact masks personal data before it is written to logs.
+package redact
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+var enabled atomic.Bool
+
+func init() {
+	enabled.Store(true)
+}
+
+// SetEnabled turns redaction on or off (on by default)
+func SetEnabled(on bool) {
+	enabled.Store(on)
+}
+
+// Enabled reports whether redaction is on
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Email masks the local part of an email address, keeping its first
+// character and the domain: "jane@example.com" becomes "j***@example.com".
+// Values without an "@" are returned unchanged.
+func Email(email string) string {
+	if !Enabled() {
+		return email
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+	if at == 0 {
+		return "***" + email[at:]
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// Emails masks each address in a list
+func Emails(emails []string) []string {
+	masked := make([]string, len(emails))
+	for i, e := range emails {
+		masked[i] = Email(e)
+	}
+	return masked
+}
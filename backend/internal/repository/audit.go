@@ -3,23 +3,49 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/finchley-foodbank/foodbank/internal/model"
 )
 
+var ErrAuditEntryNotFound = errors.New("audit entry not found")
+
 type AuditRepository struct {
 	db *pgxpool.Pool
+	// diffTables lists tables whose entries store only changed fields
+	diffTables map[string]bool
 }
 
 func NewAuditRepository(db *pgxpool.Pool) *AuditRepository {
-	return &AuditRepository{db: db}
+	return &AuditRepository{db: db, diffTables: map[string]bool{}}
+}
+
+// SetDiffTables switches the given tables from full snapshots to storing
+// only the fields that changed. Call once at startup.
+func (r *AuditRepository) SetDiffTables(tables []string) {
+	r.diffTables = make(map[string]bool, len(tables))
+	for _, t := range tables {
+		r.diffTables[t] = true
+	}
 }
 
 // Log creates a new audit log entry
 func (r *AuditRepository) Log(ctx context.Context, tableName string, recordID uuid.UUID, action string, oldValues, newValues interface{}, changedBy uuid.UUID) error {
+	return logAudit(ctx, r.db, r.diffTables[tableName], tableName, recordID, action, oldValues, newValues, changedBy)
+}
+
+// LogTx creates a new audit log entry within an existing transaction
+func (r *AuditRepository) LogTx(ctx context.Context, tx pgx.Tx, tableName string, recordID uuid.UUID, action string, oldValues, newValues interface{}, changedBy uuid.UUID) error {
+	return logAudit(ctx, tx, r.diffTables[tableName], tableName, recordID, action, oldValues, newValues, changedBy)
+}
+
+func logAudit(ctx context.Context, q querier, diff bool, tableName string, recordID uuid.UUID, action string, oldValues, newValues interface{}, changedBy uuid.UUID) error {
 	var oldJSON, newJSON []byte
 	var err error
 
@@ -37,7 +63,15 @@ func (r *AuditRepository) Log(ctx context.Context, tableName string, recordID uu
 		}
 	}
 
-	_, err = r.db.Exec(ctx, `
+	// Inserts and deletes keep the full record; only updates are reduced
+	if diff && oldJSON != nil && newJSON != nil {
+		oldJSON, newJSON, err = diffAuditValues(oldJSON, newJSON)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = q.Exec(ctx, `
 		INSERT INTO audit_log (table_name, record_id, action, old_values, new_values, changed_by)
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`, tableName, recordID, action, oldJSON, newJSON, changedBy)
@@ -45,30 +79,119 @@ func (r *AuditRepository) Log(ctx context.Context, tableName string, recordID uu
 	return err
 }
 
-// List returns audit logs with pagination and optional filtering
-func (r *AuditRepository) List(ctx context.Context, tableName string, recordID *uuid.UUID, limit, offset int) ([]model.AuditLog, int, error) {
-	// Build query based on filters
+// diffAuditValues reduces two JSON objects to the fields whose values differ,
+// including fields present on only one side. Non-object values are returned
+// unchanged.
+func diffAuditValues(oldJSON, newJSON []byte) ([]byte, []byte, error) {
+	var oldMap, newMap map[string]interface{}
+	if json.Unmarshal(oldJSON, &oldMap) != nil || json.Unmarshal(newJSON, &newMap) != nil {
+		return oldJSON, newJSON, nil
+	}
+
+	oldDiff := map[string]interface{}{}
+	newDiff := map[string]interface{}{}
+	for k, ov := range oldMap {
+		nv, ok := newMap[k]
+		if !ok || !reflect.DeepEqual(ov, nv) {
+			oldDiff[k] = ov
+			if ok {
+				newDiff[k] = nv
+			}
+		}
+	}
+	for k, nv := range newMap {
+		if _, ok := oldMap[k]; !ok {
+			newDiff[k] = nv
+		}
+	}
+
+	oldOut, err := json.Marshal(oldDiff)
+	if err != nil {
+		return nil, nil, err
+	}
+	newOut, err := json.Marshal(newDiff)
+	if err != nil {
+		return nil, nil, err
+	}
+	return oldOut, newOut, nil
+}
+
+// auditListFrom builds the FROM/WHERE shared by List and ListForExport,
+// returning the clause, its arguments and the next free placeholder number.
+// Cursors and order are left to the caller.
+func auditListFrom(filter model.AuditLogFilter) (string, []interface{}, int) {
 	baseQuery := `
 		FROM audit_log a
 		LEFT JOIN staff s ON a.changed_by = s.id
 		LEFT JOIN clients c ON a.table_name = 'clients' AND a.record_id = c.id
+		LEFT JOIN attendance att ON a.table_name = 'attendance' AND a.record_id = att.id
+		LEFT JOIN clients ac ON ac.id = att.client_id
+		LEFT JOIN staff rs ON a.table_name = 'staff' AND a.record_id = rs.id
 		WHERE 1=1
 	`
 	args := []interface{}{}
 	argNum := 1
 
-	if tableName != "" {
-		baseQuery += ` AND a.table_name = $` + string(rune('0'+argNum))
-		args = append(args, tableName)
+	if filter.TableName != "" {
+		baseQuery += fmt.Sprintf(" AND a.table_name = $%d", argNum)
+		args = append(args, filter.TableName)
+		argNum++
+	}
+
+	if filter.RecordID != nil {
+		baseQuery += fmt.Sprintf(" AND a.record_id = $%d", argNum)
+		args = append(args, *filter.RecordID)
+		argNum++
+	}
+
+	if filter.ChangedBy != nil {
+		baseQuery += fmt.Sprintf(" AND a.changed_by = $%d", argNum)
+		args = append(args, *filter.ChangedBy)
+		argNum++
+	}
+
+	if filter.From != nil {
+		baseQuery += fmt.Sprintf(" AND a.changed_at >= $%d", argNum)
+		args = append(args, *filter.From)
 		argNum++
 	}
 
-	if recordID != nil {
-		baseQuery += ` AND a.record_id = $` + string(rune('0'+argNum))
-		args = append(args, *recordID)
+	if filter.To != nil {
+		baseQuery += fmt.Sprintf(" AND a.changed_at <= $%d", argNum)
+		args = append(args, *filter.To)
 		argNum++
 	}
 
+	return baseQuery, args, argNum
+}
+
+// auditListColumns are the columns scanned by scanAuditListRow
+const auditListColumns = `
+		SELECT a.id, a.table_name, a.record_id, a.action, a.old_values, a.new_values,
+		       a.changed_by, a.changed_at, COALESCE(s.name, '') as changed_by_name,
+		       COALESCE(c.name, ac.name, rs.name, '') as record_name
+	`
+
+func scanAuditListRow(rows pgx.Rows) (model.AuditLog, error) {
+	var log model.AuditLog
+	err := rows.Scan(
+		&log.ID, &log.TableName, &log.RecordID, &log.Action,
+		&log.OldValues, &log.NewValues, &log.ChangedBy, &log.ChangedAt,
+		&log.ChangedByName, &log.RecordName,
+	)
+	return log, err
+}
+
+// List returns audit logs with pagination and optional filtering
+func (r *AuditRepository) List(ctx context.Context, filter model.AuditLogFilter, limit, offset int) ([]model.AuditLog, int, error) {
+	baseQuery, args, argNum := auditListFrom(filter)
+
+	// Order is validated by the handler; anything but "asc" falls back to newest-first
+	order := "DESC"
+	if filter.Order == "asc" {
+		order = "ASC"
+	}
+
 	// Get total count
 	var total int
 	countQuery := "SELECT COUNT(*) " + baseQuery
@@ -77,12 +200,33 @@ func (r *AuditRepository) List(ctx context.Context, tableName string, recordID *
 		return nil, 0, err
 	}
 
+	// Keyset pagination: rows strictly past the cursor in the listing order.
+	// A before cursor scans backwards and the page is reversed afterwards.
+	cursor, forward := filter.After, true
+	if cursor == nil && filter.Before != nil {
+		cursor, forward = filter.Before, false
+	}
+	scanOrder := order
+	if cursor != nil {
+		cmp := "<"
+		if (order == "ASC") == forward {
+			cmp = ">"
+		}
+		if !forward {
+			if order == "ASC" {
+				scanOrder = "DESC"
+			} else {
+				scanOrder = "ASC"
+			}
+		}
+		baseQuery += fmt.Sprintf(" AND (a.changed_at, a.id) %s ($%d, $%d)", cmp, argNum, argNum+1)
+		args = append(args, cursor.ChangedAt, cursor.ID)
+		argNum += 2
+		offset = 0
+	}
+
 	// Get paginated results
-	selectQuery := `
-		SELECT a.id, a.table_name, a.record_id, a.action, a.old_values, a.new_values,
-		       a.changed_by, a.changed_at, COALESCE(s.name, '') as changed_by_name,
-		       COALESCE(c.name, '') as record_name
-	` + baseQuery + ` ORDER BY a.changed_at DESC LIMIT $` + string(rune('0'+argNum)) + ` OFFSET $` + string(rune('0'+argNum+1))
+	selectQuery := auditListColumns + baseQuery + fmt.Sprintf(" ORDER BY a.changed_at %s, a.id %s LIMIT $%d OFFSET $%d", scanOrder, scanOrder, argNum, argNum+1)
 	args = append(args, limit, offset)
 
 	rows, err := r.db.Query(ctx, selectQuery, args...)
@@ -93,23 +237,65 @@ func (r *AuditRepository) List(ctx context.Context, tableName string, recordID *
 
 	var logs []model.AuditLog
 	for rows.Next() {
-		var log model.AuditLog
-		err := rows.Scan(
-			&log.ID, &log.TableName, &log.RecordID, &log.Action,
-			&log.OldValues, &log.NewValues, &log.ChangedBy, &log.ChangedAt,
-			&log.ChangedByName, &log.RecordName,
-		)
+		log, err := scanAuditListRow(rows)
 		if err != nil {
 			return nil, 0, err
 		}
 		logs = append(logs, log)
 	}
 
+	if scanOrder != order {
+		for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+			logs[i], logs[j] = logs[j], logs[i]
+		}
+	}
+
 	return logs, total, nil
 }
 
+// ListForExport calls fn for every audit log matching filter, oldest first
+// unless filter.Order is "desc". Rows are streamed from the database rather
+// than collected, so exporting a large range doesn't buffer the whole log.
+// Cursors in filter are ignored.
+func (r *AuditRepository) ListForExport(ctx context.Context, filter model.AuditLogFilter, fn func(*model.AuditLog) error) error {
+	baseQuery, args, _ := auditListFrom(filter)
+
+	order := "ASC"
+	if filter.Order == "desc" {
+		order = "DESC"
+	}
+
+	rows, err := r.db.Query(ctx, auditListColumns+baseQuery+fmt.Sprintf(" ORDER BY a.changed_at %s, a.id %s", order, order), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		log, err := scanAuditListRow(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListByActor returns the audit logs written by one staff member, newest first
+func (r *AuditRepository) ListByActor(ctx context.Context, staffID uuid.UUID, limit, offset int) ([]model.AuditLog, int, error) {
+	return r.List(ctx, model.AuditLogFilter{ChangedBy: &staffID}, limit, offset)
+}
+
 // GetByRecordID returns all audit logs for a specific record
 func (r *AuditRepository) GetByRecordID(ctx context.Context, tableName string, recordID uuid.UUID) ([]model.AuditLog, error) {
+	return r.GetRecentByRecordID(ctx, tableName, recordID, 0)
+}
+
+// GetRecentByRecordID returns the newest limit audit logs for a record;
+// a limit of 0 returns them all
+func (r *AuditRepository) GetRecentByRecordID(ctx context.Context, tableName string, recordID uuid.UUID, limit int) ([]model.AuditLog, error) {
 	rows, err := r.db.Query(ctx, `
 		SELECT a.id, a.table_name, a.record_id, a.action, a.old_values, a.new_values,
 		       a.changed_by, a.changed_at, COALESCE(s.name, '') as changed_by_name,
@@ -118,7 +304,8 @@ func (r *AuditRepository) GetByRecordID(ctx context.Context, tableName string, r
 		LEFT JOIN staff s ON a.changed_by = s.id
 		WHERE a.table_name = $1 AND a.record_id = $2
 		ORDER BY a.changed_at DESC
-	`, tableName, recordID)
+		LIMIT NULLIF($3, 0)
+	`, tableName, recordID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -140,3 +327,28 @@ func (r *AuditRepository) GetByRecordID(ctx context.Context, tableName string, r
 
 	return logs, nil
 }
+
+// GetByID returns a single audit entry with the staff and client names joined
+func (r *AuditRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.AuditLog, error) {
+	var log model.AuditLog
+	err := r.db.QueryRow(ctx, `
+		SELECT a.id, a.table_name, a.record_id, a.action, a.old_values, a.new_values,
+		       a.changed_by, a.changed_at, COALESCE(s.name, '') as changed_by_name,
+		       COALESCE(c.name, '') as record_name
+		FROM audit_log a
+		LEFT JOIN staff s ON a.changed_by = s.id
+		LEFT JOIN clients c ON a.table_name = 'clients' AND a.record_id = c.id
+		WHERE a.id = $1
+	`, id).Scan(
+		&log.ID, &log.TableName, &log.RecordID, &log.Action,
+		&log.OldValues, &log.NewValues, &log.ChangedBy, &log.ChangedAt,
+		&log.ChangedByName, &log.RecordName,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAuditEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -15,29 +18,103 @@ import (
 
 var ErrClientNotFound = errors.New("client not found")
 
+// ErrAttendanceNotFound is returned when an attendance record doesn't exist
+// or has already been voided
+var ErrAttendanceNotFound = errors.New("attendance not found")
+
+// ErrStaleClient is returned when an update's expected updated_at is older
+// than the stored one, i.e. someone else changed the client in between
+var ErrStaleClient = errors.New("client has been modified since it was read")
+
+// clientOrderBy maps each model.ClientSortKeys value to its ORDER BY clause.
+// Sort keys are only ever looked up here, never interpolated.
+var clientOrderBy = map[string]string{
+	"name":        "name ASC, id ASC",
+	"-name":       "name DESC, id ASC",
+	"created_at":  "created_at ASC, id ASC",
+	"-created_at": "created_at DESC, id ASC",
+	"appointment": "array_position(ARRAY['Monday','Tuesday','Wednesday','Thursday','Friday','Saturday'], appointment_day) ASC NULLS LAST, " +
+		"appointment_time ASC NULLS LAST, name ASC, id ASC",
+}
+
+// clientOrderClause returns the ORDER BY clause for sort, defaulting to name
+func clientOrderClause(sort string) string {
+	if clause, ok := clientOrderBy[sort]; ok {
+		return clause
+	}
+	return clientOrderBy["name"]
+}
+
+// visitCountColumn selects a client's total attendance count for model.Client.VisitCount
+const visitCountColumn = `(SELECT COUNT(*) FROM attendance a WHERE a.client_id = clients.id AND a.voided_at IS NULL) AS visit_count`
+
+// createdByNameColumn selects the creating staff member's name for
+// model.Client.CreatedByName. It behaves as a LEFT JOIN: deactivated staff
+// still resolve, and a creator row that no longer exists yields NULL.
+const createdByNameColumn = `(SELECT s.name FROM staff s WHERE s.id = clients.created_by) AS created_by_name`
+
 type ClientRepository struct {
 	db *pgxpool.Pool
+	// statementTimeout limits each statement in transactions begun here
+	statementTimeout time.Duration
 }
 
 func NewClientRepository(db *pgxpool.Pool) *ClientRepository {
 	return &ClientRepository{db: db}
 }
 
+// SetStatementTimeout aborts any statement in a transaction begun by this
+// repository, and any search or listing, that runs longer than d. Zero
+// disables the limit.
+func (r *ClientRepository) SetStatementTimeout(d time.Duration) {
+	r.statementTimeout = d
+}
+
+// withStatementTimeout runs the read-only fn in a transaction limited by the
+// statement timeout, or straight on the pool when there is no limit
+func (r *ClientRepository) withStatementTimeout(ctx context.Context, fn func(q querier) error) error {
+	if r.statementTimeout <= 0 {
+		return fn(r.db)
+	}
+	tx, err := beginTx(ctx, r.db, r.statementTimeout)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
 func (r *ClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Client, error) {
+	return getClientByID(ctx, r.db, id)
+}
+
+// GetByIDTx is GetByID within an existing transaction
+func (r *ClientRepository) GetByIDTx(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*model.Client, error) {
+	return getClientByID(ctx, tx, id)
+}
+
+func getClientByID(ctx context.Context, q querier, id uuid.UUID) (*model.Client, error) {
 	query := `
 		SELECT id, barcode_id, name, address, family_size, num_children, children_ages,
 		       reason, photo_url, appointment_day, appointment_time,
-		       pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking,
-		       created_at, created_by
+		       pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking, dietary_notes,
+		       consent_data_storage, consent_photo, consent_recorded_at,
+		       created_at, created_by, archived_at, archived_by, updated_at,
+		       ` + visitCountColumn + `, ` + createdByNameColumn + `
 		FROM clients
 		WHERE id = $1`
 
 	var c model.Client
-	err := r.db.QueryRow(ctx, query, id).Scan(
+	err := q.QueryRow(ctx, query, id).Scan(
 		&c.ID, &c.BarcodeID, &c.Name, &c.Address, &c.FamilySize, &c.NumChildren, &c.ChildrenAges,
 		&c.Reason, &c.PhotoURL, &c.AppointmentDay, &c.AppointmentTime,
-		&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking,
-		&c.CreatedAt, &c.CreatedBy,
+		&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking, &c.DietaryNotes,
+		&c.ConsentDataStorage, &c.ConsentPhoto, &c.ConsentRecordedAt,
+		&c.CreatedAt, &c.CreatedBy, &c.ArchivedAt, &c.ArchivedBy, &c.UpdatedAt, &c.VisitCount, &c.CreatedByName,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrClientNotFound
@@ -52,8 +129,9 @@ func (r *ClientRepository) GetByBarcodeID(ctx context.Context, barcodeID string)
 	query := `
 		SELECT id, barcode_id, name, address, family_size, num_children, children_ages,
 		       reason, photo_url, appointment_day, appointment_time,
-		       pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking,
-		       created_at, created_by
+		       pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking, dietary_notes,
+		       consent_data_storage, consent_photo, consent_recorded_at,
+		       created_at, created_by, archived_at, archived_by, updated_at
 		FROM clients
 		WHERE barcode_id = $1`
 
@@ -61,8 +139,9 @@ func (r *ClientRepository) GetByBarcodeID(ctx context.Context, barcodeID string)
 	err := r.db.QueryRow(ctx, query, barcodeID).Scan(
 		&c.ID, &c.BarcodeID, &c.Name, &c.Address, &c.FamilySize, &c.NumChildren, &c.ChildrenAges,
 		&c.Reason, &c.PhotoURL, &c.AppointmentDay, &c.AppointmentTime,
-		&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking,
-		&c.CreatedAt, &c.CreatedBy,
+		&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking, &c.DietaryNotes,
+		&c.ConsentDataStorage, &c.ConsentPhoto, &c.ConsentRecordedAt,
+		&c.CreatedAt, &c.CreatedBy, &c.ArchivedAt, &c.ArchivedBy, &c.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrClientNotFound
@@ -73,27 +152,139 @@ func (r *ClientRepository) GetByBarcodeID(ctx context.Context, barcodeID string)
 	return &c, nil
 }
 
+// FindByNameAddress returns the ID of a client whose name and address match
+// ignoring case and surrounding whitespace, or ErrClientNotFound
+func (r *ClientRepository) FindByNameAddress(ctx context.Context, name, address string) (uuid.UUID, error) {
+	return findClientByNameAddress(ctx, r.db, name, address)
+}
+
+// FindByNameAddressTx is FindByNameAddress within an existing transaction
+func (r *ClientRepository) FindByNameAddressTx(ctx context.Context, tx pgx.Tx, name, address string) (uuid.UUID, error) {
+	return findClientByNameAddress(ctx, tx, name, address)
+}
+
+func findClientByNameAddress(ctx context.Context, q querier, name, address string) (uuid.UUID, error) {
+	query := `
+		SELECT id FROM clients
+		WHERE LOWER(TRIM(name)) = LOWER(TRIM($1))
+		  AND LOWER(TRIM(address)) = LOWER(TRIM($2))
+		LIMIT 1`
+
+	var id uuid.UUID
+	err := q.QueryRow(ctx, query, name, address).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, ErrClientNotFound
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// GetIDByBarcodeID returns the ID of the active (non-archived) client with the given barcode
+func (r *ClientRepository) GetIDByBarcodeID(ctx context.Context, barcodeID string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx, `SELECT id FROM clients WHERE barcode_id = $1 AND archived_at IS NULL`, barcodeID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, ErrClientNotFound
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
 func (r *ClientRepository) Create(ctx context.Context, req *model.CreateClientRequest, barcodeID string, createdBy uuid.UUID) (*model.Client, error) {
+	return createClient(ctx, r.db, req, barcodeID, createdBy)
+}
+
+// CreateIfNoDuplicate creates the client unless one with the same name and
+// address (compared as FindByNameAddress does) already exists, in which case
+// it returns a nil client and the existing client's ID. The check and insert
+// run under the name and address's advisory lock, so concurrent creates and
+// imports of the same person can't both succeed.
+func (r *ClientRepository) CreateIfNoDuplicate(ctx context.Context, req *model.CreateClientRequest, barcodeID string, createdBy uuid.UUID) (*model.Client, uuid.UUID, error) {
+	tx, err := beginTx(ctx, r.db, r.statementTimeout)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.LockNameAddressesTx(ctx, tx, [][2]string{{req.Name, req.Address}}); err != nil {
+		return nil, uuid.Nil, err
+	}
+	existingID, err := findClientByNameAddress(ctx, tx, req.Name, req.Address)
+	if err == nil {
+		return nil, existingID, nil
+	}
+	if !errors.Is(err, ErrClientNotFound) {
+		return nil, uuid.Nil, err
+	}
+
+	c, err := createClient(ctx, tx, req, barcodeID, createdBy)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, uuid.Nil, err
+	}
+	return c, uuid.Nil, nil
+}
+
+// LockNameAddressesTx takes the advisory lock for each name and address
+// pair, normalized as FindByNameAddress compares them, for the rest of tx.
+// Locks are taken in sorted order so concurrent callers can't deadlock.
+func (r *ClientRepository) LockNameAddressesTx(ctx context.Context, tx pgx.Tx, pairs [][2]string) error {
+	keys := make([]string, len(pairs))
+	for i, p := range pairs {
+		keys[i] = "client:" + strings.ToLower(strings.TrimSpace(p[0])) + "|" + strings.ToLower(strings.TrimSpace(p[1]))
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createClient(ctx context.Context, q querier, req *model.CreateClientRequest, barcodeID string, createdBy uuid.UUID) (*model.Client, error) {
+	// The appointment, if any, is also recorded as the client's primary slot
 	query := `
-		INSERT INTO clients (barcode_id, name, address, family_size, num_children, children_ages,
-		                     reason, photo_url, appointment_day, appointment_time,
-		                     pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
-		RETURNING id, barcode_id, name, address, family_size, num_children, children_ages,
-		          reason, photo_url, appointment_day, appointment_time,
-		          pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking,
-		          created_at, created_by`
+		WITH c AS (
+			INSERT INTO clients (barcode_id, name, address, family_size, num_children, children_ages,
+			                     reason, photo_url, appointment_day, appointment_time,
+			                     pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking,
+			                     dietary_notes, created_by,
+			                     consent_data_storage, consent_photo, consent_recorded_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+			        $17, $18, CASE WHEN $17 OR $18 THEN NOW() END)
+			RETURNING id, barcode_id, name, address, family_size, num_children, children_ages,
+			          reason, photo_url, appointment_day, appointment_time,
+			          pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking, dietary_notes,
+			          consent_data_storage, consent_photo, consent_recorded_at,
+			          created_at, created_by, archived_at, archived_by, updated_at
+		), slot AS (
+			INSERT INTO client_appointments (client_id, day, time, is_primary)
+			SELECT id, appointment_day, appointment_time, TRUE
+			FROM c
+			WHERE appointment_day IS NOT NULL AND appointment_day <> ''
+		)
+		SELECT * FROM c`
 
 	var c model.Client
-	err := r.db.QueryRow(ctx, query,
+	err := q.QueryRow(ctx, query,
 		barcodeID, req.Name, req.Address, req.FamilySize, req.NumChildren, req.ChildrenAges,
 		req.Reason, req.PhotoURL, req.AppointmentDay, req.AppointmentTime,
-		req.PrefGlutenFree, req.PrefHalal, req.PrefVegetarian, req.PrefNoCooking, createdBy,
+		req.PrefGlutenFree, req.PrefHalal, req.PrefVegetarian, req.PrefNoCooking,
+		req.DietaryNotes, createdBy,
+		req.ConsentDataStorage, req.ConsentPhoto,
 	).Scan(
 		&c.ID, &c.BarcodeID, &c.Name, &c.Address, &c.FamilySize, &c.NumChildren, &c.ChildrenAges,
 		&c.Reason, &c.PhotoURL, &c.AppointmentDay, &c.AppointmentTime,
-		&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking,
-		&c.CreatedAt, &c.CreatedBy,
+		&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking, &c.DietaryNotes,
+		&c.ConsentDataStorage, &c.ConsentPhoto, &c.ConsentRecordedAt,
+		&c.CreatedAt, &c.CreatedBy, &c.ArchivedAt, &c.ArchivedBy, &c.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -102,6 +293,52 @@ func (r *ClientRepository) Create(ctx context.Context, req *model.CreateClientRe
 }
 
 func (r *ClientRepository) Update(ctx context.Context, id uuid.UUID, req *model.UpdateClientRequest) (*model.Client, error) {
+	if !changesAppointment(req) {
+		return updateClient(ctx, r.db, id, req)
+	}
+
+	tx, err := beginTx(ctx, r.db, r.statementTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	c, err := r.UpdateTx(ctx, tx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// UpdateTx is Update within an existing transaction
+func (r *ClientRepository) UpdateTx(ctx context.Context, tx pgx.Tx, id uuid.UUID, req *model.UpdateClientRequest) (*model.Client, error) {
+	c, err := updateClient(ctx, tx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	if changesAppointment(req) {
+		if err := copyClientAppointmentToPrimary(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// changesAppointment reports whether req edits the legacy appointment
+// fields, which must be mirrored in the client's primary appointment slot
+func changesAppointment(req *model.UpdateClientRequest) bool {
+	return req.AppointmentDay != nil || req.AppointmentTime != nil
+}
+
+// Begin starts a transaction for multi-client operations
+func (r *ClientRepository) Begin(ctx context.Context) (pgx.Tx, error) {
+	return beginTx(ctx, r.db, r.statementTimeout)
+}
+
+func updateClient(ctx context.Context, q querier, id uuid.UUID, req *model.UpdateClientRequest) (*model.Client, error) {
 	// Build dynamic update query
 	setClauses := []string{}
 	args := []interface{}{id}
@@ -137,7 +374,11 @@ func (r *ClientRepository) Update(ctx context.Context, id uuid.UUID, req *model.
 		args = append(args, *req.Reason)
 		argNum++
 	}
-	if req.PhotoURL != nil {
+	// Withdrawing photo consent removes the photo in the same update
+	withdrawPhoto := req.ConsentPhoto != nil && !*req.ConsentPhoto
+	if withdrawPhoto {
+		setClauses = append(setClauses, "photo_url = NULL")
+	} else if req.PhotoURL != nil {
 		setClauses = append(setClauses, fmt.Sprintf("photo_url = $%d", argNum))
 		args = append(args, *req.PhotoURL)
 		argNum++
@@ -172,30 +413,72 @@ func (r *ClientRepository) Update(ctx context.Context, id uuid.UUID, req *model.
 		args = append(args, *req.PrefNoCooking)
 		argNum++
 	}
+	if req.DietaryNotes != nil {
+		setClauses = append(setClauses, fmt.Sprintf("dietary_notes = $%d", argNum))
+		args = append(args, *req.DietaryNotes)
+		argNum++
+	}
+	// consent_recorded_at only moves when a consent flag actually changes
+	consentChanged := []string{}
+	if req.ConsentDataStorage != nil {
+		setClauses = append(setClauses, fmt.Sprintf("consent_data_storage = $%d", argNum))
+		consentChanged = append(consentChanged, fmt.Sprintf("consent_data_storage IS DISTINCT FROM $%d", argNum))
+		args = append(args, *req.ConsentDataStorage)
+		argNum++
+	}
+	if req.ConsentPhoto != nil {
+		setClauses = append(setClauses, fmt.Sprintf("consent_photo = $%d", argNum))
+		consentChanged = append(consentChanged, fmt.Sprintf("consent_photo IS DISTINCT FROM $%d", argNum))
+		args = append(args, *req.ConsentPhoto)
+		argNum++
+	}
+	if len(consentChanged) > 0 {
+		setClauses = append(setClauses, fmt.Sprintf(
+			"consent_recorded_at = CASE WHEN %s THEN NOW() ELSE consent_recorded_at END",
+			strings.Join(consentChanged, " OR ")))
+	}
 
 	if len(setClauses) == 0 {
-		return r.GetByID(ctx, id)
+		return getClientByID(ctx, q, id)
+	}
+	setClauses = append(setClauses, "updated_at = NOW()")
+
+	// Optimistic concurrency: only update if nobody else has changed the
+	// client since the caller read it
+	where := "id = $1"
+	if req.ExpectedUpdatedAt != nil {
+		where += fmt.Sprintf(" AND updated_at <= $%d", argNum)
+		args = append(args, *req.ExpectedUpdatedAt)
 	}
 
 	query := fmt.Sprintf(`
 		UPDATE clients
 		SET %s
-		WHERE id = $1
+		WHERE %s
 		RETURNING id, barcode_id, name, address, family_size, num_children, children_ages,
 		          reason, photo_url, appointment_day, appointment_time,
-		          pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking,
-		          created_at, created_by`,
-		strings.Join(setClauses, ", "))
+		          pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking, dietary_notes,
+		          consent_data_storage, consent_photo, consent_recorded_at,
+		          created_at, created_by, archived_at, archived_by, updated_at`,
+		strings.Join(setClauses, ", "), where)
 
 	var c model.Client
-	err := r.db.QueryRow(ctx, query, args...).Scan(
+	err := q.QueryRow(ctx, query, args...).Scan(
 		&c.ID, &c.BarcodeID, &c.Name, &c.Address, &c.FamilySize, &c.NumChildren, &c.ChildrenAges,
 		&c.Reason, &c.PhotoURL, &c.AppointmentDay, &c.AppointmentTime,
-		&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking,
-		&c.CreatedAt, &c.CreatedBy,
+		&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking, &c.DietaryNotes,
+		&c.ConsentDataStorage, &c.ConsentPhoto, &c.ConsentRecordedAt,
+		&c.CreatedAt, &c.CreatedBy, &c.ArchivedAt, &c.ArchivedBy, &c.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
-		return nil, ErrClientNotFound
+		if req.ExpectedUpdatedAt == nil {
+			return nil, ErrClientNotFound
+		}
+		// Distinguish a missing client from one that changed under us
+		if _, err := getClientByID(ctx, q, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrStaleClient
 	}
 	if err != nil {
 		return nil, err
@@ -203,17 +486,189 @@ func (r *ClientRepository) Update(ctx context.Context, id uuid.UUID, req *model.
 	return &c, nil
 }
 
+// clientSearchVector is the weighted full-text document for a client, name
+// ranking above address. It must match the idx_clients_search expression.
+const clientSearchVector = `(setweight(to_tsvector('simple', name), 'A') || setweight(to_tsvector('simple', address), 'B'))`
+
+// minFullTextQueryLen is the shortest query searched with full text; anything
+// shorter is too ambiguous to rank and uses ILIKE instead
+const minFullTextQueryLen = 3
+
+// clientTSQuery turns a search query into a prefix-matching tsquery, e.g.
+// "John Smi" -> "john:* & smi:*". It returns "" when the query should use
+// ILIKE instead: too short, no words, or a (possibly partial) barcode.
+func clientTSQuery(query string) string {
+	query = strings.TrimSpace(query)
+	if len(query) < minFullTextQueryLen || strings.HasPrefix(strings.ToUpper(query), "FFB-") {
+		return ""
+	}
+	// Only letters and digits survive, so nothing can be read as tsquery syntax
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	terms := make([]string, len(words))
+	for i, w := range words {
+		terms[i] = strings.ToLower(w) + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// searchWhere builds the WHERE clause and its arguments for a client search,
+// plus an ORDER BY expression ranking the matches ("" when unranked).
+// Queries clientTSQuery accepts are matched with full text, plus the ILIKE
+// substring match so mid-word, address and partial barcode searches still
+// find clients; those rank below full-text hits. Anything else uses ILIKE
+// alone. Every arm of the match has an index (idx_clients_search and the
+// trigram indexes from migration 020), so the OR is answered with a bitmap
+// index scan rather than a sequential scan. The query arguments come first;
+// optional filters follow.
+func searchWhere(params *model.ClientSearchParams) (string, []interface{}, string) {
+	var (
+		where, rank string
+		args        []interface{}
+	)
+	if tsq := clientTSQuery(params.Query); tsq != "" {
+		args = []interface{}{tsq, "%" + params.Query + "%"}
+		where = clientSearchVector + " @@ to_tsquery('simple', $1) OR name ILIKE $2 OR address ILIKE $2 OR barcode_id ILIKE $2"
+		if params.IncludeNotes {
+			where += " OR dietary_notes ILIKE $2"
+		}
+		// Substring-only matches score 0 and so sort after full-text hits
+		rank = "ts_rank(" + clientSearchVector + ", to_tsquery('simple', $1)) DESC"
+	} else {
+		args = []interface{}{"%" + params.Query + "%"}
+		where = "name ILIKE $1 OR address ILIKE $1 OR barcode_id ILIKE $1"
+		if params.IncludeNotes {
+			where += " OR dietary_notes ILIKE $1"
+		}
+	}
+	argNum := len(args) + 1
+	where = "(" + where + ")"
+	if !params.IncludeArchived {
+		where += " AND archived_at IS NULL"
+	}
+
+	if params.AppointmentDay != nil {
+		where += fmt.Sprintf(" AND lower(appointment_day) = lower($%d)", argNum)
+		args = append(args, *params.AppointmentDay)
+		argNum++
+	}
+
+	prefs := []struct {
+		column string
+		value  *bool
+	}{
+		{"pref_gluten_free", params.PrefGlutenFree},
+		{"pref_halal", params.PrefHalal},
+		{"pref_vegetarian", params.PrefVegetarian},
+		{"pref_no_cooking", params.PrefNoCooking},
+	}
+	for _, p := range prefs {
+		if p.value != nil {
+			where += fmt.Sprintf(" AND %s = $%d", p.column, argNum)
+			args = append(args, *p.value)
+			argNum++
+		}
+	}
+
+	if params.ChildAgeMin != nil || params.ChildAgeMax != nil {
+		// Only well-formed lists are parsed, so legacy free text can't fail the cast
+		ageWhere := []string{}
+		if params.ChildAgeMin != nil {
+			ageWhere = append(ageWhere, fmt.Sprintf("age >= $%d", argNum))
+			args = append(args, *params.ChildAgeMin)
+			argNum++
+		}
+		if params.ChildAgeMax != nil {
+			ageWhere = append(ageWhere, fmt.Sprintf("age <= $%d", argNum))
+			args = append(args, *params.ChildAgeMax)
+			argNum++
+		}
+		where += fmt.Sprintf(` AND EXISTS (
+			SELECT 1 FROM unnest(CASE WHEN children_ages ~ $%d
+				THEN regexp_split_to_array(trim(children_ages), '\s*,\s*')::int[] END) AS age
+			WHERE %s)`, argNum, strings.Join(ageWhere, " AND "))
+		args = append(args, childrenAgesPattern)
+	}
+
+	return where, args, rank
+}
+
+// childrenAgesPattern matches a children_ages value that is safe to cast to
+// int[] once split on commas
+const childrenAgesPattern = `^\s*\d{1,9}(\s*,\s*\d{1,9})*\s*$`
+
+// Count returns how many clients match params without fetching any rows
+func (r *ClientRepository) Count(ctx context.Context, params *model.ClientSearchParams) (int, error) {
+	var count int
+	err := r.withStatementTimeout(ctx, func(q querier) error {
+		var err error
+		count, err = countClients(ctx, q, params)
+		return err
+	})
+	return count, err
+}
+
+func countClients(ctx context.Context, q querier, params *model.ClientSearchParams) (int, error) {
+	where, args, _ := searchWhere(params)
+
+	var count int
+	err := q.QueryRow(ctx, `SELECT COUNT(*) FROM clients WHERE `+where, args...).Scan(&count)
+	return count, err
+}
+
+// CountTotals returns the number of clients and how many of them are not archived
+func (r *ClientRepository) CountTotals(ctx context.Context) (total, active int, err error) {
+	err = r.db.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE archived_at IS NULL)
+		FROM clients`,
+	).Scan(&total, &active)
+	return total, active, err
+}
+
+// CountAttendanceSince returns how many visits (excluding voided ones) have
+// been recorded since the given time
+func (r *ClientRepository) CountAttendanceSince(ctx context.Context, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM attendance WHERE verified_at >= $1 AND voided_at IS NULL`, since,
+	).Scan(&count)
+	return count, err
+}
+
 func (r *ClientRepository) Search(ctx context.Context, params *model.ClientSearchParams) ([]model.Client, int, error) {
-	// Search by name or address using ILIKE
-	searchPattern := "%" + params.Query + "%"
+	var (
+		clients []model.Client
+		total   int
+	)
+	err := r.withStatementTimeout(ctx, func(q querier) error {
+		var err error
+		clients, total, err = searchClients(ctx, q, params)
+		return err
+	})
+	return clients, total, err
+}
+
+func searchClients(ctx context.Context, q querier, params *model.ClientSearchParams) ([]model.Client, int, error) {
+	// Search by name, address or barcode, narrowed by any filters
+	where, args, rank := searchWhere(params)
+
+	// Best matches first unless the caller picked an order
+	order := clientOrderClause(params.Sort)
+	if rank != "" && params.Sort == "" {
+		order = rank + ", " + order
+	}
 
 	countQuery := `
 		SELECT COUNT(*)
 		FROM clients
-		WHERE name ILIKE $1 OR address ILIKE $1 OR barcode_id ILIKE $1`
+		WHERE ` + where
 
 	var total int
-	err := r.db.QueryRow(ctx, countQuery, searchPattern).Scan(&total)
+	err := q.QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -221,14 +676,16 @@ func (r *ClientRepository) Search(ctx context.Context, params *model.ClientSearc
 	query := `
 		SELECT id, barcode_id, name, address, family_size, num_children, children_ages,
 		       reason, photo_url, appointment_day, appointment_time,
-		       pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking,
-		       created_at, created_by
+		       pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking, dietary_notes,
+		       consent_data_storage, consent_photo, consent_recorded_at,
+		       created_at, created_by, archived_at, archived_by, updated_at,
+		       ` + visitCountColumn + `, ` + createdByNameColumn + `
 		FROM clients
-		WHERE name ILIKE $1 OR address ILIKE $1 OR barcode_id ILIKE $1
-		ORDER BY name ASC
-		LIMIT $2 OFFSET $3`
+		WHERE ` + where + fmt.Sprintf(`
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d`, order, len(args)+1, len(args)+2)
 
-	rows, err := r.db.Query(ctx, query, searchPattern, params.Limit, params.Offset)
+	rows, err := q.Query(ctx, query, append(args, params.Limit, params.Offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -240,8 +697,9 @@ func (r *ClientRepository) Search(ctx context.Context, params *model.ClientSearc
 		err := rows.Scan(
 			&c.ID, &c.BarcodeID, &c.Name, &c.Address, &c.FamilySize, &c.NumChildren, &c.ChildrenAges,
 			&c.Reason, &c.PhotoURL, &c.AppointmentDay, &c.AppointmentTime,
-			&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking,
-			&c.CreatedAt, &c.CreatedBy,
+			&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking, &c.DietaryNotes,
+			&c.ConsentDataStorage, &c.ConsentPhoto, &c.ConsentRecordedAt,
+			&c.CreatedAt, &c.CreatedBy, &c.ArchivedAt, &c.ArchivedBy, &c.UpdatedAt, &c.VisitCount, &c.CreatedByName,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -251,10 +709,77 @@ func (r *ClientRepository) Search(ctx context.Context, params *model.ClientSearc
 	return clients, total, rows.Err()
 }
 
-func (r *ClientRepository) List(ctx context.Context, limit, offset int) ([]model.Client, int, error) {
-	countQuery := `SELECT COUNT(*) FROM clients`
+// ListAll returns every client matching params, ignoring Limit/Offset.
+// An empty query matches all clients.
+func (r *ClientRepository) ListAll(ctx context.Context, params *model.ClientSearchParams) ([]model.Client, error) {
+	var clients []model.Client
+	err := r.withStatementTimeout(ctx, func(q querier) error {
+		var err error
+		clients, err = listAllClients(ctx, q, params)
+		return err
+	})
+	return clients, err
+}
+
+func listAllClients(ctx context.Context, q querier, params *model.ClientSearchParams) ([]model.Client, error) {
+	where, args, _ := searchWhere(params)
+	query := `
+		SELECT id, barcode_id, name, address, family_size, num_children, children_ages,
+		       reason, photo_url, appointment_day, appointment_time,
+		       pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking, dietary_notes,
+		       consent_data_storage, consent_photo, consent_recorded_at,
+		       created_at, created_by, archived_at, archived_by, updated_at
+		FROM clients
+		WHERE ` + where + `
+		ORDER BY ` + clientOrderClause(params.Sort)
+
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []model.Client
+	for rows.Next() {
+		var c model.Client
+		err := rows.Scan(
+			&c.ID, &c.BarcodeID, &c.Name, &c.Address, &c.FamilySize, &c.NumChildren, &c.ChildrenAges,
+			&c.Reason, &c.PhotoURL, &c.AppointmentDay, &c.AppointmentTime,
+			&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking, &c.DietaryNotes,
+			&c.ConsentDataStorage, &c.ConsentPhoto, &c.ConsentRecordedAt,
+			&c.CreatedAt, &c.CreatedBy, &c.ArchivedAt, &c.ArchivedBy, &c.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, c)
+	}
+	return clients, rows.Err()
+}
+
+// List returns a page of clients ordered by sort (see model.ClientSortKeys)
+func (r *ClientRepository) List(ctx context.Context, limit, offset int, includeArchived bool, sort string) ([]model.Client, int, error) {
+	var (
+		clients []model.Client
+		total   int
+	)
+	err := r.withStatementTimeout(ctx, func(q querier) error {
+		var err error
+		clients, total, err = listClients(ctx, q, limit, offset, includeArchived, sort)
+		return err
+	})
+	return clients, total, err
+}
+
+func listClients(ctx context.Context, q querier, limit, offset int, includeArchived bool, sort string) ([]model.Client, int, error) {
+	where := ""
+	if !includeArchived {
+		where = " WHERE archived_at IS NULL"
+	}
+
+	countQuery := `SELECT COUNT(*) FROM clients` + where
 	var total int
-	err := r.db.QueryRow(ctx, countQuery).Scan(&total)
+	err := q.QueryRow(ctx, countQuery).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -262,13 +787,15 @@ func (r *ClientRepository) List(ctx context.Context, limit, offset int) ([]model
 	query := `
 		SELECT id, barcode_id, name, address, family_size, num_children, children_ages,
 		       reason, photo_url, appointment_day, appointment_time,
-		       pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking,
-		       created_at, created_by
-		FROM clients
-		ORDER BY name ASC
+		       pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking, dietary_notes,
+		       consent_data_storage, consent_photo, consent_recorded_at,
+		       created_at, created_by, archived_at, archived_by, updated_at,
+		       ` + visitCountColumn + `, ` + createdByNameColumn + `
+		FROM clients` + where + `
+		ORDER BY ` + clientOrderClause(sort) + `
 		LIMIT $1 OFFSET $2`
 
-	rows, err := r.db.Query(ctx, query, limit, offset)
+	rows, err := q.Query(ctx, query, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -280,8 +807,9 @@ func (r *ClientRepository) List(ctx context.Context, limit, offset int) ([]model
 		err := rows.Scan(
 			&c.ID, &c.BarcodeID, &c.Name, &c.Address, &c.FamilySize, &c.NumChildren, &c.ChildrenAges,
 			&c.Reason, &c.PhotoURL, &c.AppointmentDay, &c.AppointmentTime,
-			&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking,
-			&c.CreatedAt, &c.CreatedBy,
+			&c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian, &c.PrefNoCooking, &c.DietaryNotes,
+			&c.ConsentDataStorage, &c.ConsentPhoto, &c.ConsentRecordedAt,
+			&c.CreatedAt, &c.CreatedBy, &c.ArchivedAt, &c.ArchivedBy, &c.UpdatedAt, &c.VisitCount, &c.CreatedByName,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -291,15 +819,60 @@ func (r *ClientRepository) List(ctx context.Context, limit, offset int) ([]model
 	return clients, total, rows.Err()
 }
 
-func (r *ClientRepository) RecordAttendance(ctx context.Context, clientID, verifiedBy uuid.UUID) (*model.Attendance, error) {
+// Archive hides a client from default listings without deleting the record.
+// Archiving an already archived client is a no-op that returns the client.
+func (r *ClientRepository) Archive(ctx context.Context, id, archivedBy uuid.UUID) (*model.Client, error) {
+	_, err := r.db.Exec(ctx, `
+		UPDATE clients
+		SET archived_at = NOW(), archived_by = $2, updated_at = NOW()
+		WHERE id = $1 AND archived_at IS NULL`,
+		id, archivedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Unarchive restores an archived client to default listings
+func (r *ClientRepository) Unarchive(ctx context.Context, id uuid.UUID) (*model.Client, error) {
+	_, err := r.db.Exec(ctx, `
+		UPDATE clients
+		SET archived_at = NULL, archived_by = NULL, updated_at = NOW()
+		WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// ClearPhoto removes a client's photo_url
+func (r *ClientRepository) ClearPhoto(ctx context.Context, id uuid.UUID) (*model.Client, error) {
+	_, err := r.db.Exec(ctx, `UPDATE clients SET photo_url = NULL, updated_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// lockClientAttendance takes the per-client advisory lock that serialises
+// attendance writes for the rest of tx
+func lockClientAttendance(ctx context.Context, tx pgx.Tx, clientID uuid.UUID) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1::text))`, clientID.String())
+	return err
+}
+
+func insertAttendance(ctx context.Context, q querier, clientID, verifiedBy uuid.UUID, at *time.Time, overrideReason *string) (*model.Attendance, error) {
 	query := `
-		INSERT INTO attendance (client_id, verified_by)
-		VALUES ($1, $2)
-		RETURNING id, client_id, verified_by, verified_at`
+		INSERT INTO attendance (client_id, verified_by, verified_at, override_reason)
+		VALUES ($1, $2, COALESCE($3::timestamptz, NOW()), $4)
+		RETURNING id, client_id, verified_by, verified_at, override_reason`
 
 	var a model.Attendance
-	err := r.db.QueryRow(ctx, query, clientID, verifiedBy).Scan(
-		&a.ID, &a.ClientID, &a.VerifiedBy, &a.VerifiedAt,
+	err := q.QueryRow(ctx, query, clientID, verifiedBy, at, overrideReason).Scan(
+		&a.ID, &a.ClientID, &a.VerifiedBy, &a.VerifiedAt, &a.OverrideReason,
 	)
 	if err != nil {
 		return nil, err
@@ -307,18 +880,242 @@ func (r *ClientRepository) RecordAttendance(ctx context.Context, clientID, verif
 	return &a, nil
 }
 
-func (r *ClientRepository) GetAttendanceHistory(ctx context.Context, clientID uuid.UUID, limit int) ([]model.AttendanceWithDetails, error) {
+// nearestAttendance returns the client's visit closest to at (now when at
+// is nil) and that reference time, or a nil visit if there is none
+func nearestAttendance(ctx context.Context, q querier, clientID uuid.UUID, at *time.Time) (*model.Attendance, time.Time, error) {
 	query := `
-		SELECT a.id, a.client_id, a.verified_by, a.verified_at,
+		SELECT id, client_id, verified_by, verified_at, override_reason, COALESCE($2::timestamptz, NOW())
+		FROM attendance
+		WHERE client_id = $1 AND voided_at IS NULL
+		ORDER BY abs(extract(epoch FROM verified_at - COALESCE($2::timestamptz, NOW())))
+		LIMIT 1`
+
+	var a model.Attendance
+	var ref time.Time
+	err := q.QueryRow(ctx, query, clientID, at).Scan(
+		&a.ID, &a.ClientID, &a.VerifiedBy, &a.VerifiedAt, &a.OverrideReason, &ref,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return &a, ref, nil
+}
+
+// RecordAttendanceCheckedTx records a visit in tx unless check's dedup or
+// cooldown rules stop it. The rules are applied under the client's advisory
+// lock, held until tx ends, so two concurrent scans can't both pass them.
+func (r *ClientRepository) RecordAttendanceCheckedTx(ctx context.Context, tx pgx.Tx, clientID, verifiedBy uuid.UUID, check model.AttendanceCheck) (*model.AttendanceOutcome, error) {
+	if err := lockClientAttendance(ctx, tx, clientID); err != nil {
+		return nil, err
+	}
+	return recordAttendanceChecked(ctx, tx, clientID, verifiedBy, check)
+}
+
+// recordAttendanceChecked applies check against the client's nearest visit
+// and records the new one unless it is a duplicate or too soon. The caller
+// must hold the client's advisory lock.
+func recordAttendanceChecked(ctx context.Context, q querier, clientID, verifiedBy uuid.UUID, check model.AttendanceCheck) (*model.AttendanceOutcome, error) {
+	out := &model.AttendanceOutcome{}
+	if check.DedupWindow > 0 || check.Cooldown > 0 {
+		nearest, ref, err := nearestAttendance(ctx, q, clientID, check.At)
+		if err != nil {
+			return nil, err
+		}
+		out.Nearest = nearest
+		if nearest != nil {
+			gap := ref.Sub(nearest.VerifiedAt)
+			if gap < 0 {
+				gap = -gap
+			}
+			switch {
+			case gap < check.DedupWindow:
+				out.Attendance = nearest
+				out.Duplicate = true
+				return out, nil
+			case gap < check.Cooldown && !check.Override:
+				out.TooSoon = true
+				return out, nil
+			case gap < check.Cooldown:
+				out.Overridden = true
+			}
+		}
+	}
+
+	var reason *string
+	if out.Overridden {
+		reason = check.OverrideReason
+	}
+	a, err := insertAttendance(ctx, q, clientID, verifiedBy, check.At, reason)
+	if err != nil {
+		return nil, err
+	}
+	out.Attendance = a
+	out.Created = true
+	return out, nil
+}
+
+// RecordAttendanceBatch records a visit for each client in one transaction,
+// applying check to each as RecordAttendanceCheckedTx does. Every client's
+// advisory lock is taken up front, in a fixed order so concurrent batches
+// can't deadlock. Each visit runs in its own savepoint so a single failure
+// doesn't abort the batch; the returned slice matches clientIDs with nil
+// for failed rows. clientIDs must not repeat.
+func (r *ClientRepository) RecordAttendanceBatch(ctx context.Context, clientIDs []uuid.UUID, verifiedBy uuid.UUID, check model.AttendanceCheck) ([]*model.AttendanceOutcome, error) {
+	tx, err := beginTx(ctx, r.db, r.statementTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockClientsAttendance(ctx, tx, clientIDs); err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]*model.AttendanceOutcome, len(clientIDs))
+	for i, clientID := range clientIDs {
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		out, err := recordAttendanceChecked(ctx, sp, clientID, verifiedBy, check)
+		if err != nil {
+			sp.Rollback(ctx)
+			continue
+		}
+		if err := sp.Commit(ctx); err != nil {
+			return nil, err
+		}
+		outcomes[i] = out
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
+
+// lockClientsAttendance takes the advisory lock of each client, sorted by ID.
+// The locks are re-entrant, so repeated IDs are harmless.
+func lockClientsAttendance(ctx context.Context, tx pgx.Tx, clientIDs []uuid.UUID) error {
+	sorted := append([]uuid.UUID(nil), clientIDs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].String() < sorted[j].String() })
+	for _, id := range sorted {
+		if err := lockClientAttendance(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportAttendance records the given visits in a single transaction, so
+// either all are stored or none are. Each visit is checked as
+// RecordAttendanceCheckedTx does, at its own VerifiedAt and under its
+// client's advisory lock, against existing visits and those inserted
+// earlier in the same call.
+func (r *ClientRepository) ImportAttendance(ctx context.Context, visits []model.AttendanceImportVisit, verifiedBy uuid.UUID, check model.AttendanceCheck) ([]*model.AttendanceOutcome, error) {
+	tx, err := beginTx(ctx, r.db, r.statementTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	clientIDs := make([]uuid.UUID, len(visits))
+	for i, v := range visits {
+		clientIDs[i] = v.ClientID
+	}
+	if err := lockClientsAttendance(ctx, tx, clientIDs); err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]*model.AttendanceOutcome, len(visits))
+	for i, v := range visits {
+		visitCheck := check
+		visitCheck.At = &v.VerifiedAt
+		out, err := recordAttendanceChecked(ctx, tx, v.ClientID, verifiedBy, visitCheck)
+		if err != nil {
+			return nil, err
+		}
+		outcomes[i] = out
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
+
+// GetAttendance returns an attendance record by ID, including voided ones
+func (r *ClientRepository) GetAttendance(ctx context.Context, id uuid.UUID) (*model.Attendance, error) {
+	query := `
+		SELECT id, client_id, verified_by, verified_at, override_reason, voided_at, voided_by
+		FROM attendance
+		WHERE id = $1`
+
+	var a model.Attendance
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&a.ID, &a.ClientID, &a.VerifiedBy, &a.VerifiedAt, &a.OverrideReason, &a.VoidedAt, &a.VoidedBy,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAttendanceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// VoidAttendance soft-deletes an attendance record so it no longer counts as
+// a visit anywhere. It returns ErrAttendanceNotFound if the record doesn't
+// exist or is already voided.
+func (r *ClientRepository) VoidAttendance(ctx context.Context, id, voidedBy uuid.UUID) (*model.Attendance, error) {
+	query := `
+		UPDATE attendance SET voided_at = NOW(), voided_by = $2
+		WHERE id = $1 AND voided_at IS NULL
+		RETURNING id, client_id, verified_by, verified_at, override_reason, voided_at, voided_by`
+
+	var a model.Attendance
+	err := r.db.QueryRow(ctx, query, id, voidedBy).Scan(
+		&a.ID, &a.ClientID, &a.VerifiedBy, &a.VerifiedAt, &a.OverrideReason, &a.VoidedAt, &a.VoidedBy,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAttendanceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *ClientRepository) GetAttendanceHistory(ctx context.Context, clientID uuid.UUID, q model.AttendanceQuery) ([]model.AttendanceWithDetails, error) {
+	query := `
+		SELECT a.id, a.client_id, a.verified_by, a.verified_at, a.override_reason,
 		       c.name as client_name, s.name as verified_by_name
 		FROM attendance a
 		JOIN clients c ON a.client_id = c.id
 		JOIN staff s ON a.verified_by = s.id
-		WHERE a.client_id = $1
-		ORDER BY a.verified_at DESC
-		LIMIT $2`
+		WHERE a.client_id = $1 AND a.voided_at IS NULL`
+
+	args := []interface{}{clientID}
+	argNum := 2
+
+	if q.From != nil {
+		query += fmt.Sprintf(" AND a.verified_at >= $%d", argNum)
+		args = append(args, *q.From)
+		argNum++
+	}
+	if q.To != nil {
+		query += fmt.Sprintf(" AND a.verified_at <= $%d", argNum)
+		args = append(args, *q.To)
+		argNum++
+	}
+
+	query += fmt.Sprintf(" ORDER BY a.verified_at DESC LIMIT $%d", argNum)
+	args = append(args, q.Limit)
 
-	rows, err := r.db.Query(ctx, query, clientID, limit)
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -328,7 +1125,7 @@ func (r *ClientRepository) GetAttendanceHistory(ctx context.Context, clientID uu
 	for rows.Next() {
 		var a model.AttendanceWithDetails
 		err := rows.Scan(
-			&a.ID, &a.ClientID, &a.VerifiedBy, &a.VerifiedAt,
+			&a.ID, &a.ClientID, &a.VerifiedBy, &a.VerifiedAt, &a.OverrideReason,
 			&a.ClientName, &a.VerifiedName,
 		)
 		if err != nil {
@@ -338,3 +1135,175 @@ func (r *ClientRepository) GetAttendanceHistory(ctx context.Context, clientID uu
 	}
 	return history, rows.Err()
 }
+
+// ListAttendance returns attendance across all clients with verified_at in
+// [q.From, q.To), oldest first, along with the total matching count
+func (r *ClientRepository) ListAttendance(ctx context.Context, q model.AttendanceRangeQuery) ([]model.AttendanceWithDetails, int, error) {
+	var total int
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM attendance WHERE voided_at IS NULL AND verified_at >= $1 AND verified_at < $2`,
+		q.From, q.To).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT a.id, a.client_id, a.verified_by, a.verified_at, a.override_reason,
+		       c.name as client_name, COALESCE(s.name, '') as verified_by_name
+		FROM attendance a
+		JOIN clients c ON a.client_id = c.id
+		LEFT JOIN staff s ON a.verified_by = s.id
+		WHERE a.voided_at IS NULL AND a.verified_at >= $1 AND a.verified_at < $2
+		ORDER BY a.verified_at ASC, a.id ASC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.Query(ctx, query, q.From, q.To, q.Limit, q.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var attendance []model.AttendanceWithDetails
+	for rows.Next() {
+		var a model.AttendanceWithDetails
+		err := rows.Scan(
+			&a.ID, &a.ClientID, &a.VerifiedBy, &a.VerifiedAt, &a.OverrideReason,
+			&a.ClientName, &a.VerifiedName,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		attendance = append(attendance, a)
+	}
+	return attendance, total, rows.Err()
+}
+
+// AttendanceSummaryByMonth returns visit and distinct client counts per month
+// between from and to (inclusive), with zero rows for months without visits.
+func (r *ClientRepository) AttendanceSummaryByMonth(ctx context.Context, from, to time.Time) ([]model.MonthlyAttendance, error) {
+	query := `
+		WITH counts AS (
+			SELECT date_trunc('month', verified_at) AS month,
+			       COUNT(*) AS visits,
+			       COUNT(DISTINCT client_id) AS unique_clients
+			FROM attendance
+			WHERE voided_at IS NULL AND verified_at >= $1 AND verified_at <= $2
+			GROUP BY date_trunc('month', verified_at)
+		)
+		SELECT m.month, COALESCE(c.visits, 0), COALESCE(c.unique_clients, 0)
+		FROM generate_series(date_trunc('month', $1::timestamptz), date_trunc('month', $2::timestamptz), interval '1 month') AS m(month)
+		LEFT JOIN counts c ON c.month = m.month
+		ORDER BY m.month ASC`
+
+	rows, err := r.db.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summary []model.MonthlyAttendance
+	for rows.Next() {
+		var m model.MonthlyAttendance
+		if err := rows.Scan(&m.Month, &m.Visits, &m.UniqueClients); err != nil {
+			return nil, err
+		}
+		summary = append(summary, m)
+	}
+	return summary, rows.Err()
+}
+
+// AttendanceByDayHour counts visits between from and to grouped by weekday
+// and hour of day in the given IANA timezone. Empty slots are omitted.
+func (r *ClientRepository) AttendanceByDayHour(ctx context.Context, from, to time.Time, timezone string) ([]model.HeatmapCell, error) {
+	query := `
+		SELECT extract(dow FROM verified_at AT TIME ZONE $3)::int AS dow,
+		       extract(hour FROM verified_at AT TIME ZONE $3)::int AS hour,
+		       COUNT(*)
+		FROM attendance
+		WHERE voided_at IS NULL AND verified_at >= $1 AND verified_at <= $2
+		GROUP BY dow, hour`
+
+	rows, err := r.db.Query(ctx, query, from, to, timezone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cells []model.HeatmapCell
+	for rows.Next() {
+		var c model.HeatmapCell
+		if err := rows.Scan(&c.DayOfWeek, &c.Hour, &c.Visits); err != nil {
+			return nil, err
+		}
+		cells = append(cells, c)
+	}
+	return cells, rows.Err()
+}
+
+// ClientVisitSummaries returns every client with visit count and last visit
+// between from and to. A nil bound leaves that side of the range open.
+func (r *ClientRepository) ClientVisitSummaries(ctx context.Context, from, to *time.Time) ([]model.ClientVisitSummary, error) {
+	query := `
+		SELECT c.id, c.barcode_id, c.name, c.address, c.family_size, c.num_children,
+		       c.appointment_day, c.appointment_time, c.pref_gluten_free, c.pref_halal,
+		       c.pref_vegetarian, c.pref_no_cooking, c.created_at, c.archived_at,
+		       COUNT(a.id), MAX(a.verified_at)
+		FROM clients c
+		LEFT JOIN attendance a ON a.client_id = c.id AND a.voided_at IS NULL
+			AND ($1::timestamptz IS NULL OR a.verified_at >= $1)
+			AND ($2::timestamptz IS NULL OR a.verified_at <= $2)
+		GROUP BY c.id
+		ORDER BY c.name ASC`
+
+	rows, err := r.db.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []model.ClientVisitSummary
+	for rows.Next() {
+		var s model.ClientVisitSummary
+		err := rows.Scan(
+			&s.ID, &s.BarcodeID, &s.Name, &s.Address, &s.FamilySize, &s.NumChildren,
+			&s.AppointmentDay, &s.AppointmentTime, &s.PrefGlutenFree, &s.PrefHalal,
+			&s.PrefVegetarian, &s.PrefNoCooking, &s.CreatedAt, &s.ArchivedAt,
+			&s.VisitCount, &s.LastVisit,
+		)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+// AttendanceForReport returns visits between from and to, oldest first.
+// A nil bound leaves that side of the range open.
+func (r *ClientRepository) AttendanceForReport(ctx context.Context, from, to *time.Time) ([]model.AttendanceReportRow, error) {
+	query := `
+		SELECT a.id, a.client_id, c.barcode_id, c.name, COALESCE(s.name, ''), a.verified_at
+		FROM attendance a
+		JOIN clients c ON c.id = a.client_id
+		LEFT JOIN staff s ON s.id = a.verified_by
+		WHERE a.voided_at IS NULL
+		  AND ($1::timestamptz IS NULL OR a.verified_at >= $1)
+		  AND ($2::timestamptz IS NULL OR a.verified_at <= $2)
+		ORDER BY a.verified_at ASC`
+
+	rows, err := r.db.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var visits []model.AttendanceReportRow
+	for rows.Next() {
+		var v model.AttendanceReportRow
+		if err := rows.Scan(&v.ID, &v.ClientID, &v.BarcodeID, &v.ClientName, &v.VerifiedByName, &v.VerifiedAt); err != nil {
+			return nil, err
+		}
+		visits = append(visits, v)
+	}
+	return visits, rows.Err()
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+)
+
+var (
+	ErrAppointmentNotFound  = errors.New("appointment not found")
+	ErrDuplicateAppointment = errors.New("client already has this appointment slot")
+)
+
+const appointmentSelectColumns = `id, client_id, day, time, is_primary, created_at`
+
+// appointmentWeekOrder orders a client's slots Monday to Saturday
+const appointmentWeekOrder = `array_position(ARRAY['Monday','Tuesday','Wednesday','Thursday','Friday','Saturday'], day),
+		         time NULLS LAST, created_at`
+
+// ListAppointments returns a client's appointment slots, primary first and
+// the rest in week order
+func (r *ClientRepository) ListAppointments(ctx context.Context, clientID uuid.UUID) ([]model.ClientAppointment, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+appointmentSelectColumns+`
+		FROM client_appointments
+		WHERE client_id = $1
+		ORDER BY is_primary DESC, `+appointmentWeekOrder,
+		clientID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var appointments []model.ClientAppointment
+	for rows.Next() {
+		var a model.ClientAppointment
+		if err := rows.Scan(&a.ID, &a.ClientID, &a.Day, &a.Time, &a.IsPrimary, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		appointments = append(appointments, a)
+	}
+	return appointments, rows.Err()
+}
+
+// AddAppointment adds a slot for a client. It becomes the primary slot when
+// primary is set or the client has no primary yet, and the client's legacy
+// appointment fields are updated to match.
+func (r *ClientRepository) AddAppointment(ctx context.Context, clientID uuid.UUID, day string, timeOfDay *string, primary bool) (*model.ClientAppointment, error) {
+	tx, err := beginTx(ctx, r.db, r.statementTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the client so concurrent adds agree on which slot is primary
+	var hasPrimary, duplicate bool
+	err = tx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM client_appointments WHERE client_id = c.id AND is_primary),
+		       EXISTS (SELECT 1 FROM client_appointments
+		               WHERE client_id = c.id AND day = $2 AND time IS NOT DISTINCT FROM $3::time)
+		FROM clients c
+		WHERE c.id = $1
+		FOR UPDATE`,
+		clientID, day, timeOfDay,
+	).Scan(&hasPrimary, &duplicate)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrClientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if duplicate {
+		return nil, ErrDuplicateAppointment
+	}
+
+	primary = primary || !hasPrimary
+	if primary && hasPrimary {
+		if _, err := tx.Exec(ctx, `UPDATE client_appointments SET is_primary = FALSE WHERE client_id = $1 AND is_primary`, clientID); err != nil {
+			return nil, err
+		}
+	}
+
+	var a model.ClientAppointment
+	err = tx.QueryRow(ctx, `
+		INSERT INTO client_appointments (client_id, day, time, is_primary)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+appointmentSelectColumns,
+		clientID, day, timeOfDay, primary,
+	).Scan(&a.ID, &a.ClientID, &a.Day, &a.Time, &a.IsPrimary, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if primary {
+		if err := copyPrimaryAppointmentToClient(ctx, tx, clientID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// DeleteAppointment removes one of a client's slots and returns it. Removing
+// the primary promotes the next slot in week order, or clears the client's
+// legacy appointment fields when none is left.
+func (r *ClientRepository) DeleteAppointment(ctx context.Context, clientID, appointmentID uuid.UUID) (*model.ClientAppointment, error) {
+	tx, err := beginTx(ctx, r.db, r.statementTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var a model.ClientAppointment
+	err = tx.QueryRow(ctx, `
+		DELETE FROM client_appointments
+		WHERE id = $1 AND client_id = $2
+		RETURNING `+appointmentSelectColumns,
+		appointmentID, clientID,
+	).Scan(&a.ID, &a.ClientID, &a.Day, &a.Time, &a.IsPrimary, &a.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAppointmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if a.IsPrimary {
+		_, err := tx.Exec(ctx, `
+			UPDATE client_appointments SET is_primary = TRUE
+			WHERE id = (
+				SELECT id FROM client_appointments
+				WHERE client_id = $1
+				ORDER BY `+appointmentWeekOrder+`
+				LIMIT 1
+			)`,
+			clientID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := copyPrimaryAppointmentToClient(ctx, tx, clientID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// copyPrimaryAppointmentToClient sets the client's appointment_day/time from
+// its primary slot, or clears them when it has none
+func copyPrimaryAppointmentToClient(ctx context.Context, q querier, clientID uuid.UUID) error {
+	_, err := q.Exec(ctx, `
+		UPDATE clients
+		SET appointment_day = p.day, appointment_time = p.time, updated_at = NOW()
+		FROM (SELECT $1::uuid AS client_id) c
+		LEFT JOIN client_appointments p ON p.client_id = c.client_id AND p.is_primary
+		WHERE clients.id = c.client_id`,
+		clientID,
+	)
+	return err
+}
+
+// copyClientAppointmentToPrimary is the reverse of copyPrimaryAppointmentToClient,
+// for when appointment_day/time are edited on the client itself: the primary
+// slot is replaced by the client's values, reusing a matching slot if there
+// is one, and removed when the client's day is cleared
+func copyClientAppointmentToPrimary(ctx context.Context, q querier, clientID uuid.UUID) error {
+	if _, err := q.Exec(ctx, `DELETE FROM client_appointments WHERE client_id = $1 AND is_primary`, clientID); err != nil {
+		return err
+	}
+	if _, err := q.Exec(ctx, `
+		UPDATE client_appointments SET is_primary = TRUE
+		WHERE id = (
+			SELECT a.id FROM client_appointments a
+			JOIN clients c ON c.id = a.client_id
+			WHERE c.id = $1 AND a.day = c.appointment_day AND a.time IS NOT DISTINCT FROM c.appointment_time
+			LIMIT 1
+		)`,
+		clientID,
+	); err != nil {
+		return err
+	}
+	_, err := q.Exec(ctx, `
+		INSERT INTO client_appointments (client_id, day, time, is_primary)
+		SELECT id, appointment_day, appointment_time, TRUE
+		FROM clients
+		WHERE id = $1 AND appointment_day IS NOT NULL AND appointment_day <> ''
+		  AND NOT EXISTS (SELECT 1 FROM client_appointments WHERE client_id = $1 AND is_primary)`,
+		clientID,
+	)
+	return err
+}
+
+// SyncPrimaryAppointmentTx makes a newly inserted client's appointment_day/time
+// its primary slot, for inserts that bypass Create (e.g. CSV import)
+func (r *ClientRepository) SyncPrimaryAppointmentTx(ctx context.Context, tx pgx.Tx, clientID uuid.UUID) error {
+	return copyClientAppointmentToPrimary(ctx, tx, clientID)
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+// assertPrimaryDay checks that the client's single primary slot and its
+// legacy appointment_day both match want, or that neither is set for ""
+func assertPrimaryDay(t *testing.T, repo *ClientRepository, clientID uuid.UUID, want string) {
+	t.Helper()
+	ctx := context.Background()
+
+	appointments, err := repo.ListAppointments(ctx, clientID)
+	if err != nil {
+		t.Fatalf("list appointments: %v", err)
+	}
+	var primaries []string
+	for _, a := range appointments {
+		if a.IsPrimary {
+			primaries = append(primaries, a.Day)
+		}
+	}
+	client, err := repo.GetByID(ctx, clientID)
+	if err != nil {
+		t.Fatalf("get client: %v", err)
+	}
+
+	if want == "" {
+		if len(primaries) != 0 || client.AppointmentDay != nil {
+			t.Errorf("primaries %v, appointment_day %v; want none", primaries, client.AppointmentDay)
+		}
+		return
+	}
+	if len(primaries) != 1 || primaries[0] != want {
+		t.Errorf("primaries = %v, want only %s", primaries, want)
+	}
+	if client.AppointmentDay == nil || *client.AppointmentDay != want {
+		t.Errorf("appointment_day = %v, want %s", client.AppointmentDay, want)
+	}
+}
+
+func TestAddAppointmentPromotesPrimary(t *testing.T) {
+	repo := NewClientRepository(testdb.Open(t))
+	ctx := context.Background()
+	client := createTestClient(t, repo, &model.CreateClientRequest{Name: "Slots", Address: "1 Slot St"})
+
+	// The first slot is primary even when not asked for
+	first, err := repo.AddAppointment(ctx, client.ID, "Tuesday", nil, false)
+	if err != nil {
+		t.Fatalf("add Tuesday: %v", err)
+	}
+	if !first.IsPrimary {
+		t.Error("first slot is not primary")
+	}
+	assertPrimaryDay(t, repo, client.ID, "Tuesday")
+
+	// Later slots are secondary unless asked
+	if _, err := repo.AddAppointment(ctx, client.ID, "Thursday", nil, false); err != nil {
+		t.Fatalf("add Thursday: %v", err)
+	}
+	assertPrimaryDay(t, repo, client.ID, "Tuesday")
+
+	// A new primary demotes the old one
+	if _, err := repo.AddAppointment(ctx, client.ID, "Monday", nil, true); err != nil {
+		t.Fatalf("add Monday: %v", err)
+	}
+	assertPrimaryDay(t, repo, client.ID, "Monday")
+
+	if _, err := repo.AddAppointment(ctx, client.ID, "Thursday", nil, false); err != ErrDuplicateAppointment {
+		t.Errorf("duplicate Thursday: err = %v, want ErrDuplicateAppointment", err)
+	}
+}
+
+func TestDeleteAppointmentPromotesNextSlot(t *testing.T) {
+	repo := NewClientRepository(testdb.Open(t))
+	ctx := context.Background()
+	client := createTestClient(t, repo, &model.CreateClientRequest{Name: "Slots", Address: "2 Slot St"})
+
+	ids := map[string]uuid.UUID{}
+	for _, day := range []string{"Friday", "Wednesday", "Tuesday"} {
+		a, err := repo.AddAppointment(ctx, client.ID, day, nil, false)
+		if err != nil {
+			t.Fatalf("add %s: %v", day, err)
+		}
+		ids[day] = a.ID
+	}
+	assertPrimaryDay(t, repo, client.ID, "Friday")
+
+	// Removing a secondary slot leaves the primary alone
+	if _, err := repo.DeleteAppointment(ctx, client.ID, ids["Wednesday"]); err != nil {
+		t.Fatalf("delete Wednesday: %v", err)
+	}
+	assertPrimaryDay(t, repo, client.ID, "Friday")
+
+	// Removing the primary promotes the next slot in week order
+	if _, err := repo.DeleteAppointment(ctx, client.ID, ids["Friday"]); err != nil {
+		t.Fatalf("delete Friday: %v", err)
+	}
+	assertPrimaryDay(t, repo, client.ID, "Tuesday")
+
+	// Removing the last slot clears the client's appointment
+	if _, err := repo.DeleteAppointment(ctx, client.ID, ids["Tuesday"]); err != nil {
+		t.Fatalf("delete Tuesday: %v", err)
+	}
+	assertPrimaryDay(t, repo, client.ID, "")
+
+	if _, err := repo.DeleteAppointment(ctx, client.ID, ids["Tuesday"]); err != ErrAppointmentNotFound {
+		t.Errorf("delete again: err = %v, want ErrAppointmentNotFound", err)
+	}
+}
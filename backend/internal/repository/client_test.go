@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+// createTestClient inserts a client created by the system actor
+func createTestClient(t *testing.T, repo *ClientRepository, req *model.CreateClientRequest) *model.Client {
+	t.Helper()
+	if req.FamilySize == nil {
+		req.FamilySize = intPtr(1)
+	}
+	barcode := fmt.Sprintf("T%011d", testBarcodeSeq)
+	testBarcodeSeq++
+	c, err := repo.Create(context.Background(), req, barcode, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create client %q: %v", req.Name, err)
+	}
+	return c
+}
+
+var testBarcodeSeq = 1
+
+func strPtr(s string) *string { return &s }
+
+func intPtr(n int) *int { return &n }
+
+func TestSearchByChildAgeRange(t *testing.T) {
+	repo := NewClientRepository(testdb.Open(t))
+	ctx := context.Background()
+
+	createTestClient(t, repo, &model.CreateClientRequest{Name: "Baby", Address: "1 High St", ChildrenAges: strPtr("1, 9")})
+	createTestClient(t, repo, &model.CreateClientRequest{Name: "Primary", Address: "2 High St", ChildrenAges: strPtr("5, 8")})
+	createTestClient(t, repo, &model.CreateClientRequest{Name: "Teen", Address: "3 High St", ChildrenAges: strPtr("15")})
+	createTestClient(t, repo, &model.CreateClientRequest{Name: "Legacy", Address: "4 High St", ChildrenAges: strPtr("two and four")})
+	createTestClient(t, repo, &model.CreateClientRequest{Name: "None", Address: "5 High St"})
+
+	tests := []struct {
+		name string
+		min  *int
+		max  *int
+		want []string
+	}{
+		{"under twos", nil, intPtr(1), []string{"Baby"}},
+		{"five to eight", intPtr(5), intPtr(8), []string{"Primary"}},
+		{"at least nine", intPtr(9), nil, []string{"Baby", "Teen"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := &model.ClientSearchParams{ChildAgeMin: tt.min, ChildAgeMax: tt.max, Limit: 50}
+			clients, total, err := repo.Search(ctx, params)
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			var names []string
+			for _, c := range clients {
+				names = append(names, c.Name)
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.want) || total != len(tt.want) {
+				t.Errorf("got %v (total %d), want %v", names, total, tt.want)
+			}
+
+			count, err := repo.Count(ctx, params)
+			if err != nil {
+				t.Fatalf("count: %v", err)
+			}
+			if count != len(tt.want) {
+				t.Errorf("count = %d, want %d", count, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestSearchRanksFullTextAboveSubstring(t *testing.T) {
+	repo := NewClientRepository(testdb.Open(t))
+
+	createTestClient(t, repo, &model.CreateClientRequest{Name: "Ann Blacksmithson", Address: "3 Low St"})
+	createTestClient(t, repo, &model.CreateClientRequest{Name: "Jane Doe", Address: "2 Smith Road"})
+	createTestClient(t, repo, &model.CreateClientRequest{Name: "John Smith", Address: "1 High St"})
+	createTestClient(t, repo, &model.CreateClientRequest{Name: "Bob Other", Address: "4 Elm St"})
+
+	clients, total, err := repo.Search(context.Background(), &model.ClientSearchParams{Query: "smith", Limit: 10})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+
+	// Name hits outrank address hits, which outrank substring-only matches
+	want := []string{"John Smith", "Jane Doe", "Ann Blacksmithson"}
+	var got []string
+	for _, c := range clients {
+		got = append(got, c.Name)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) || total != len(want) {
+		t.Errorf("got %v (total %d), want %v", got, total, want)
+	}
+}
+
+func TestSearchUsesIndexes(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+	// Tiny test tables always favour a sequential scan; forbid it to see
+	// whether the indexes can answer the search at all
+	if _, err := tx.Exec(ctx, "SET LOCAL enable_seqscan = off"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, params := range []*model.ClientSearchParams{
+		{Query: "smith"},
+		{Query: "smith", IncludeNotes: true},
+		{Query: "FB00"},
+	} {
+		where, args, _ := searchWhere(params)
+		rows, err := tx.Query(ctx, "EXPLAIN SELECT id FROM clients WHERE "+where, args...)
+		if err != nil {
+			t.Fatalf("explain %+v: %v", params, err)
+		}
+		var plan []string
+		for rows.Next() {
+			var line string
+			if err := rows.Scan(&line); err != nil {
+				t.Fatal(err)
+			}
+			plan = append(plan, line)
+		}
+		rows.Close()
+		for _, line := range plan {
+			if strings.Contains(line, "Seq Scan on clients") {
+				t.Errorf("query %q (notes %v) needs a sequential scan:\n%s", params.Query, params.IncludeNotes, strings.Join(plan, "\n"))
+				break
+			}
+		}
+	}
+}
+
+func TestUpdateWithdrawingPhotoConsentClearsPhoto(t *testing.T) {
+	repo := NewClientRepository(testdb.Open(t))
+	ctx := context.Background()
+
+	c := createTestClient(t, repo, &model.CreateClientRequest{
+		Name: "Photo Client", Address: "1 High St",
+		PhotoURL: strPtr("https://example.com/photo.jpg"), ConsentPhoto: true,
+	})
+
+	noConsent := false
+	updated, err := repo.Update(ctx, c.ID, &model.UpdateClientRequest{ConsentPhoto: &noConsent})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.ConsentPhoto || updated.PhotoURL != nil {
+		t.Errorf("consent %v, photo %v; want consent withdrawn and the photo cleared", updated.ConsentPhoto, updated.PhotoURL)
+	}
+}
+
+func TestClientCreatedByName(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewClientRepository(db)
+	staffRepo := NewStaffRepository(db)
+	ctx := context.Background()
+
+	newCreator := func(name string) *model.Staff {
+		t.Helper()
+		s, err := staffRepo.CreateWithRole(ctx, "auth0|"+name, name, strings.ToLower(name)+"@example.com", model.RoleStaff, nil, nil, &model.SystemStaffID)
+		if err != nil {
+			t.Fatalf("create staff %s: %v", name, err)
+		}
+		return s
+	}
+	active, departed, removed := newCreator("Active"), newCreator("Departed"), newCreator("Removed")
+	if err := staffRepo.Deactivate(ctx, departed.ID, model.SystemStaffID); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+
+	clients := map[string]*model.Client{}
+	for i, creator := range []*model.Staff{active, departed, removed} {
+		c, err := repo.Create(ctx, &model.CreateClientRequest{
+			Name: "Client of " + creator.Name, Address: fmt.Sprintf("%d Creator Way", i+1), FamilySize: intPtr(1),
+		}, fmt.Sprintf("C%011d", i+1), creator.ID)
+		if err != nil {
+			t.Fatalf("create client: %v", err)
+		}
+		clients[creator.Name] = c
+	}
+
+	// The foreign key normally keeps creators around; drop it in this
+	// throwaway schema to check the name comes back nil, not an error
+	if _, err := db.Exec(ctx, `ALTER TABLE clients DROP CONSTRAINT clients_created_by_fkey`); err != nil {
+		t.Fatalf("drop constraint: %v", err)
+	}
+	if _, err := db.Exec(ctx, `DELETE FROM staff WHERE id = $1`, removed.ID); err != nil {
+		t.Fatalf("delete staff: %v", err)
+	}
+
+	want := map[string]*string{"Active": strPtr("Active"), "Departed": strPtr("Departed"), "Removed": nil}
+	for creator, c := range clients {
+		got, err := repo.GetByID(ctx, c.ID)
+		if err != nil {
+			t.Fatalf("get %s: %v", c.Name, err)
+		}
+		if !equalStrPtr(got.CreatedByName, want[creator]) {
+			t.Errorf("%s: created_by_name = %v, want %v", c.Name, got.CreatedByName, want[creator])
+		}
+	}
+
+	listed, _, err := repo.List(ctx, 10, 0, false, "")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	for _, c := range listed {
+		for creator, created := range clients {
+			if c.ID == created.ID && !equalStrPtr(c.CreatedByName, want[creator]) {
+				t.Errorf("list %s: created_by_name = %v, want %v", c.Name, c.CreatedByName, want[creator])
+			}
+		}
+	}
+}
+
+func equalStrPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+)
+
+type EmailLogRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEmailLogRepository(db *pgxpool.Pool) *EmailLogRepository {
+	return &EmailLogRepository{db: db}
+}
+
+// Create records an outgoing email, filling in its ID and CreatedAt
+func (r *EmailLogRepository) Create(ctx context.Context, entry *model.EmailLog) error {
+	query := `
+		INSERT INTO email_log (recipient, message_type, message_id, status, error, attempts)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	return r.db.QueryRow(ctx, query,
+		entry.Recipient, entry.MessageType, entry.MessageID, entry.Status, entry.Error, entry.Attempts,
+	).Scan(&entry.ID, &entry.CreatedAt)
+}
+
+// List returns a page of email log entries, newest first, with the total count
+func (r *EmailLogRepository) List(ctx context.Context, limit, offset int) ([]model.EmailLog, int, error) {
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM email_log`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, recipient, message_type, message_id, status, error, attempts, created_at
+		FROM email_log
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []model.EmailLog
+	for rows.Next() {
+		var e model.EmailLog
+		if err := rows.Scan(
+			&e.ID, &e.Recipient, &e.MessageType, &e.MessageID, &e.Status, &e.Error, &e.Attempts, &e.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+// DeleteOlderThan removes entries created before olderThan and returns the
+// number of rows deleted
+func (r *EmailLogRepository) DeleteOlderThan(ctx context.Context, olderThan time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM email_log WHERE created_at < $1`, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
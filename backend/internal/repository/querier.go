@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// querier is the subset of methods shared by *pgxpool.Pool and pgx.Tx,
+// so the same query code can run inside or outside a transaction
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// beginTx starts a transaction whose statements are aborted server-side
+// after timeout (0 keeps the server default). Backup, import and restore
+// open their own transactions and are deliberately not limited.
+func beginTx(ctx context.Context, db *pgxpool.Pool, timeout time.Duration) (pgx.Tx, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())); err != nil {
+			tx.Rollback(ctx)
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// IsStatementTimeout reports whether err is Postgres cancelling a statement
+// that exceeded statement_timeout. A query cancelled because ctx ended (e.g.
+// the caller went away) fails with the same code, so that doesn't count.
+func IsStatementTimeout(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "57014" && strings.Contains(pgErr.Message, "statement timeout")
+}
+
+// clientsBarcodeConstraint is the unique constraint on clients.barcode_id
+const clientsBarcodeConstraint = "clients_barcode_id_key"
+
+// IsBarcodeCollision reports whether err is a unique violation on a
+// client's barcode_id
+func IsBarcodeCollision(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == clientsBarcodeConstraint
+}
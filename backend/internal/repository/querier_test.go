@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsStatementTimeout(t *testing.T) {
+	timeout := &pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"}
+	userCancel := &pgconn.PgError{Code: "57014", Message: "canceling statement due to user request"}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"statement timeout", context.Background(), fmt.Errorf("search: %w", timeout), true},
+		{"query cancelled by the caller", context.Background(), userCancel, false},
+		{"request context ended", cancelled, timeout, false},
+		{"other error", context.Background(), errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsStatementTimeout(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("IsStatementTimeout = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -71,14 +71,14 @@ func generateToken() (string, error) {
 }
 
 // Create creates a new registration request with a generated approval token
-func (r *RegistrationRequestRepository) Create(ctx context.Context, name, email string, mobile, address *string) (*model.RegistrationRequest, error) {
+// that expires after ttl
+func (r *RegistrationRequestRepository) Create(ctx context.Context, name, email string, mobile, address *string, ttl time.Duration) (*model.RegistrationRequest, error) {
 	token, err := generateToken()
 	if err != nil {
 		return nil, err
 	}
 
-	// Token expires in 7 days
-	expiresAt := time.Now().Add(7 * 24 * time.Hour)
+	expiresAt := time.Now().Add(ttl)
 
 	query := `
 		INSERT INTO registration_requests (name, email, mobile, address, approval_token, token_expires_at)
@@ -88,6 +88,23 @@ func (r *RegistrationRequestRepository) Create(ctx context.Context, name, email
 	return scanRegistrationRequest(r.db.QueryRow(ctx, query, name, email, mobile, address, token, expiresAt))
 }
 
+// RegenerateToken replaces a pending request's approval token with a fresh
+// one expiring after ttl, invalidating the old link
+func (r *RegistrationRequestRepository) RegenerateToken(ctx context.Context, id uuid.UUID, ttl time.Duration) (*model.RegistrationRequest, error) {
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE registration_requests
+		SET approval_token = $2, token_expires_at = $3
+		WHERE id = $1 AND status = 'pending'
+		RETURNING ` + registrationRequestSelectColumns
+
+	return scanRegistrationRequest(r.db.QueryRow(ctx, query, id, token, time.Now().Add(ttl)))
+}
+
 // GetByID retrieves a registration request by ID
 func (r *RegistrationRequestRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.RegistrationRequest, error) {
 	query := `SELECT ` + registrationRequestSelectColumns + ` FROM registration_requests WHERE id = $1`
@@ -106,11 +123,45 @@ func (r *RegistrationRequestRepository) GetByToken(ctx context.Context, token st
 	return scanRegistrationRequest(r.db.QueryRow(ctx, query, token))
 }
 
-// ListPending returns all pending registration requests
-func (r *RegistrationRequestRepository) ListPending(ctx context.Context) ([]model.RegistrationRequest, error) {
-	query := `SELECT ` + registrationRequestSelectColumns + ` FROM registration_requests WHERE status = 'pending' ORDER BY created_at ASC`
+// List returns a page of registration requests with the given status (all
+// statuses when empty) and the total number of matching requests. Pending
+// requests are listed oldest first; processed requests most recent first.
+func (r *RegistrationRequestRepository) List(ctx context.Context, status string, limit, offset int) ([]model.RegistrationRequest, int, error) {
+	var total int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM registration_requests WHERE ($1 = '' OR status = $1)`, status).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	order := "created_at DESC"
+	if status == model.RequestStatusPending {
+		order = "created_at ASC"
+	}
+
+	query := `SELECT ` + registrationRequestSelectColumns + ` FROM registration_requests
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY ` + order + `
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	requests, err := scanRegistrationRequestRows(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+// ListByStatus returns registration requests with the given status, or all
+// requests when status is empty, oldest first
+func (r *RegistrationRequestRepository) ListByStatus(ctx context.Context, status string) ([]model.RegistrationRequest, error) {
+	query := `SELECT ` + registrationRequestSelectColumns + ` FROM registration_requests WHERE ($1 = '' OR status = $1) ORDER BY created_at ASC`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := r.db.Query(ctx, query, status)
 	if err != nil {
 		return nil, err
 	}
@@ -161,40 +212,6 @@ func (r *RegistrationRequestRepository) Reject(ctx context.Context, id uuid.UUID
 	return nil
 }
 
-// ApproveWithoutReviewer marks a request as approved without a reviewer (token-based approval)
-func (r *RegistrationRequestRepository) ApproveWithoutReviewer(ctx context.Context, id uuid.UUID) error {
-	query := `
-		UPDATE registration_requests
-		SET status = 'approved', reviewed_at = $2
-		WHERE id = $1 AND status = 'pending'`
-
-	result, err := r.db.Exec(ctx, query, id, time.Now())
-	if err != nil {
-		return err
-	}
-	if result.RowsAffected() == 0 {
-		return ErrRegistrationRequestNotFound
-	}
-	return nil
-}
-
-// RejectWithoutReviewer marks a request as rejected without a reviewer (token-based rejection)
-func (r *RegistrationRequestRepository) RejectWithoutReviewer(ctx context.Context, id uuid.UUID) error {
-	query := `
-		UPDATE registration_requests
-		SET status = 'rejected', reviewed_at = $2
-		WHERE id = $1 AND status = 'pending'`
-
-	result, err := r.db.Exec(ctx, query, id, time.Now())
-	if err != nil {
-		return err
-	}
-	if result.RowsAffected() == 0 {
-		return ErrRegistrationRequestNotFound
-	}
-	return nil
-}
-
 // Delete removes a registration request (for cleanup)
 func (r *RegistrationRequestRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM registration_requests WHERE id = $1`
@@ -213,3 +230,13 @@ func (r *RegistrationRequestRepository) GetPendingByEmail(ctx context.Context, e
 	query := `SELECT ` + registrationRequestSelectColumns + ` FROM registration_requests WHERE email = $1 AND status = 'pending'`
 	return scanRegistrationRequest(r.db.QueryRow(ctx, query, email))
 }
+
+// DeleteExpiredPending removes pending requests whose approval token expired
+// before the given time and returns the number of rows deleted
+func (r *RegistrationRequestRepository) DeleteExpiredPending(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM registration_requests WHERE status = 'pending' AND token_expires_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
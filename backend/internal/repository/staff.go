@@ -30,6 +30,7 @@ func scanStaff(row pgx.Row) (*model.Staff, error) {
 		&s.Address, &s.Theme, &s.BackgroundImage, &s.Role, &s.IsActive,
 		&s.EmailVerified, &s.EmailVerifiedAt,
 		&s.CreatedAt, &s.CreatedBy, &s.DeactivatedAt, &s.DeactivatedBy,
+		&s.NameIsPlaceholder,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrStaffNotFound
@@ -50,6 +51,7 @@ func scanStaffRows(rows pgx.Rows) ([]model.Staff, error) {
 			&s.Address, &s.Theme, &s.BackgroundImage, &s.Role, &s.IsActive,
 			&s.EmailVerified, &s.EmailVerifiedAt,
 			&s.CreatedAt, &s.CreatedBy, &s.DeactivatedAt, &s.DeactivatedBy,
+			&s.NameIsPlaceholder,
 		)
 		if err != nil {
 			return nil, err
@@ -59,7 +61,7 @@ func scanStaffRows(rows pgx.Rows) ([]model.Staff, error) {
 	return staff, rows.Err()
 }
 
-const staffSelectColumns = `id, auth0_id, name, email, mobile, address, theme, background_image, role, is_active, email_verified, email_verified_at, created_at, created_by, deactivated_at, deactivated_by`
+const staffSelectColumns = `id, auth0_id, name, email, mobile, address, theme, background_image, role, is_active, email_verified, email_verified_at, created_at, created_by, deactivated_at, deactivated_by, name_is_placeholder`
 
 func (r *StaffRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Staff, error) {
 	query := `SELECT ` + staffSelectColumns + ` FROM staff WHERE id = $1`
@@ -76,14 +78,15 @@ func (r *StaffRepository) GetByEmail(ctx context.Context, email string) (*model.
 	return scanStaff(r.db.QueryRow(ctx, query, email))
 }
 
-// Create creates a new staff member with default role 'staff'
-func (r *StaffRepository) Create(ctx context.Context, auth0ID, name, email string, mobile, address *string, createdBy *uuid.UUID) (*model.Staff, error) {
+// Create creates a new staff member with default role 'staff'. Callers that
+// had no real name to store set nameIsPlaceholder so Auth0 claims replace it.
+func (r *StaffRepository) Create(ctx context.Context, auth0ID, name, email string, nameIsPlaceholder bool, mobile, address *string, createdBy *uuid.UUID) (*model.Staff, error) {
 	query := `
-		INSERT INTO staff (auth0_id, name, email, mobile, address, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO staff (auth0_id, name, email, name_is_placeholder, mobile, address, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING ` + staffSelectColumns
 
-	return scanStaff(r.db.QueryRow(ctx, query, auth0ID, name, email, mobile, address, createdBy))
+	return scanStaff(r.db.QueryRow(ctx, query, auth0ID, name, email, nameIsPlaceholder, mobile, address, createdBy))
 }
 
 // CreateWithRole creates a new staff member with a specific role
@@ -96,10 +99,12 @@ func (r *StaffRepository) CreateWithRole(ctx context.Context, auth0ID, name, ema
 	return scanStaff(r.db.QueryRow(ctx, query, auth0ID, name, email, role, mobile, address, createdBy))
 }
 
+// Update saves profile fields. Changing the name clears the placeholder flag.
 func (r *StaffRepository) Update(ctx context.Context, id uuid.UUID, name, email string, mobile, address *string, theme, backgroundImage string) (*model.Staff, error) {
 	query := `
 		UPDATE staff
-		SET name = $2, email = $3, mobile = $4, address = $5, theme = $6, background_image = $7
+		SET name = $2, email = $3, mobile = $4, address = $5, theme = $6, background_image = $7,
+		    name_is_placeholder = name_is_placeholder AND name = $2
 		WHERE id = $1
 		RETURNING ` + staffSelectColumns
 
@@ -117,11 +122,11 @@ func (r *StaffRepository) UpdateRole(ctx context.Context, id uuid.UUID, role str
 	return scanStaff(r.db.QueryRow(ctx, query, id, role))
 }
 
-// List returns all active staff members
+// List returns active staff members. The system actor is never listed.
 func (r *StaffRepository) List(ctx context.Context) ([]model.Staff, error) {
-	query := `SELECT ` + staffSelectColumns + ` FROM staff WHERE is_active = true ORDER BY name ASC`
+	query := `SELECT ` + staffSelectColumns + ` FROM staff WHERE is_active = true AND id <> $1 ORDER BY name ASC`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := r.db.Query(ctx, query, model.SystemStaffID)
 	if err != nil {
 		return nil, err
 	}
@@ -130,11 +135,11 @@ func (r *StaffRepository) List(ctx context.Context) ([]model.Staff, error) {
 	return scanStaffRows(rows)
 }
 
-// ListAll returns all staff members including deactivated ones
-func (r *StaffRepository) ListAll(ctx context.Context) ([]model.Staff, error) {
-	query := `SELECT ` + staffSelectColumns + ` FROM staff ORDER BY is_active DESC, name ASC`
+// ListInactive returns deactivated staff, most recently deactivated first
+func (r *StaffRepository) ListInactive(ctx context.Context) ([]model.Staff, error) {
+	query := `SELECT ` + staffSelectColumns + ` FROM staff WHERE is_active = false AND id <> $1 ORDER BY deactivated_at DESC NULLS LAST, name ASC`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := r.db.Query(ctx, query, model.SystemStaffID)
 	if err != nil {
 		return nil, err
 	}
@@ -160,14 +165,15 @@ func (r *StaffRepository) Deactivate(ctx context.Context, id uuid.UUID, deactiva
 	return nil
 }
 
-// Reactivate marks a staff member as active
+// Reactivate marks a staff member as active. The system actor is reported
+// as not found so it can never be signed in as.
 func (r *StaffRepository) Reactivate(ctx context.Context, id uuid.UUID) error {
 	query := `
 		UPDATE staff
 		SET is_active = true, deactivated_at = NULL, deactivated_by = NULL
-		WHERE id = $1 AND is_active = false`
+		WHERE id = $1 AND is_active = false AND id <> $2`
 
-	result, err := r.db.Exec(ctx, query, id)
+	result, err := r.db.Exec(ctx, query, id, model.SystemStaffID)
 	if err != nil {
 		return err
 	}
@@ -185,7 +191,16 @@ func (r *StaffRepository) CountAdmins(ctx context.Context) (int, error) {
 	return count, err
 }
 
-// ListAdminEmails returns email addresses of all active admin users
+// CountActive returns the number of active staff members
+func (r *StaffRepository) CountActive(ctx context.Context) (int, error) {
+	query := `SELECT COUNT(*) FROM staff WHERE is_active = true`
+	var count int
+	err := r.db.QueryRow(ctx, query).Scan(&count)
+	return count, err
+}
+
+// ListAdminEmails returns email addresses of active admin users only.
+// Deactivated admins must never receive approval links.
 func (r *StaffRepository) ListAdminEmails(ctx context.Context) ([]string, error) {
 	query := `SELECT email FROM staff WHERE role = 'admin' AND is_active = true`
 
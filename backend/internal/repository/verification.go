@@ -93,3 +93,30 @@ func (r *VerificationRepository) CountRecentCodes(ctx context.Context, staffID u
 	err := r.db.QueryRow(ctx, query, staffID, since).Scan(&count)
 	return count, err
 }
+
+// NthLatestCodeCreatedAt returns when the staff member's nth most recent
+// code (1-based) was created, or ErrVerificationCodeNotFound if there are
+// fewer than n codes
+func (r *VerificationRepository) NthLatestCodeCreatedAt(ctx context.Context, staffID uuid.UUID, n int) (time.Time, error) {
+	query := `
+		SELECT created_at FROM verification_codes
+		WHERE staff_id = $1
+		ORDER BY created_at DESC
+		OFFSET $2 LIMIT 1`
+	var createdAt time.Time
+	err := r.db.QueryRow(ctx, query, staffID, n-1).Scan(&createdAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, ErrVerificationCodeNotFound
+	}
+	return createdAt, err
+}
+
+// DeleteExpired removes codes that expired before olderThan and returns the
+// number of rows deleted
+func (r *VerificationRepository) DeleteExpired(ctx context.Context, olderThan time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM verification_codes WHERE expires_at < $1`, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
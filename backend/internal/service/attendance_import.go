@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+)
+
+// MaxAttendanceImportLines caps how many barcodes one attendance import may contain
+const MaxAttendanceImportLines = 2000
+
+// ErrTooManyAttendanceLines is returned when an attendance import exceeds
+// MaxAttendanceImportLines
+var ErrTooManyAttendanceLines = errors.New("too many lines in attendance import")
+
+// attendanceImportTimeLayouts are the timestamp formats accepted in the
+// optional second column. Layouts without an offset are read in the app timezone.
+var attendanceImportTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// attendanceImportLine is one parsed line of an attendance import
+type attendanceImportLine struct {
+	line       int
+	barcode    string
+	verifiedAt time.Time
+	err        string
+}
+
+// ImportAttendance records visits from an offline session. Each line of r
+// holds a barcode and optionally when it was scanned (see
+// attendanceImportTimeLayouts); a missing time means now. An optional
+// "barcode" header line is skipped. Visits are stored in one transaction,
+// checked against the same dedup window and cooldown as live scans, measured
+// from each visit's own time: a visit inside the dedup window of another,
+// in the database or earlier in the file, is a duplicate, and one inside
+// the cooldown is reported as too soon.
+func (s *ClientService) ImportAttendance(ctx context.Context, r io.Reader, verifiedBy uuid.UUID) (*model.AttendanceImportResponse, error) {
+	lines, err := s.parseAttendanceImport(r)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &model.AttendanceImportResponse{Results: make([]model.AttendanceImportResult, len(lines))}
+	var pending []int
+	var visits []model.AttendanceImportVisit
+
+	for i, l := range lines {
+		res := &resp.Results[i]
+		res.Line = l.line
+		res.Barcode = l.barcode
+
+		if l.err != "" {
+			res.Status = "invalid"
+			res.Message = l.err
+			continue
+		}
+
+		id, err := s.repo.GetIDByBarcodeID(ctx, normalizeBarcode(l.barcode))
+		if errors.Is(err, repository.ErrClientNotFound) {
+			res.Status = "not_found"
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		res.ClientID = &id
+
+		pending = append(pending, i)
+		visits = append(visits, model.AttendanceImportVisit{ClientID: id, VerifiedAt: l.verifiedAt})
+	}
+
+	if len(visits) > 0 {
+		outcomes, err := s.repo.ImportAttendance(ctx, visits, verifiedBy, s.attendanceCheck())
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range pending {
+			res := &resp.Results[i]
+			switch out := outcomes[j]; {
+			case out.TooSoon:
+				res.Status = "too_soon"
+			case out.Duplicate:
+				res.Status = "duplicate"
+				res.Attendance = out.Attendance
+			default:
+				res.Status = "recorded"
+				res.Attendance = out.Attendance
+			}
+		}
+	}
+
+	for _, res := range resp.Results {
+		switch res.Status {
+		case "recorded":
+			resp.Matched++
+		case "not_found":
+			resp.Unmatched++
+		case "duplicate":
+			resp.Duplicates++
+		case "too_soon":
+			resp.TooSoon++
+		default:
+			resp.Invalid++
+		}
+	}
+
+	return resp, nil
+}
+
+// parseAttendanceImport reads barcode[,timestamp] lines, skipping blank lines
+// and a leading header. Lines with a bad timestamp are returned with err set.
+func (s *ClientService) parseAttendanceImport(r io.Reader) ([]attendanceImportLine, error) {
+	reader := csv.NewReader(skipBOM(r))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	now := time.Now()
+	var lines []attendanceImportLine
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			lines = append(lines, attendanceImportLine{line: perr.StartLine, err: perr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		barcode := strings.TrimSpace(record[0])
+		if first {
+			if strings.EqualFold(barcode, "barcode") || strings.EqualFold(barcode, "barcode_id") {
+				continue
+			}
+		}
+		if barcode == "" {
+			continue
+		}
+		if len(lines) >= MaxAttendanceImportLines {
+			return nil, fmt.Errorf("%w (max %d)", ErrTooManyAttendanceLines, MaxAttendanceImportLines)
+		}
+
+		l := attendanceImportLine{line: line, barcode: barcode, verifiedAt: now}
+		if len(record) > 1 && strings.TrimSpace(record[1]) != "" {
+			t, ok := s.parseAttendanceTime(strings.TrimSpace(record[1]))
+			switch {
+			case !ok:
+				l.err = fmt.Sprintf("invalid timestamp %q", record[1])
+			case t.After(now):
+				l.err = "timestamp is in the future"
+			default:
+				l.verifiedAt = t
+			}
+		}
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
+// parseAttendanceTime tries each accepted layout in the app timezone
+func (s *ClientService) parseAttendanceTime(v string) (time.Time, bool) {
+	for _, layout := range attendanceImportTimeLayouts {
+		if t, err := time.ParseInLocation(layout, v, s.location); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
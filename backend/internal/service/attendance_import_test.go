@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+)
+
+func importStatuses(resp *model.AttendanceImportResponse) []string {
+	statuses := make([]string, len(resp.Results))
+	for i, res := range resp.Results {
+		statuses[i] = res.Status
+	}
+	return statuses
+}
+
+func TestImportAttendanceAppliesDedupWindow(t *testing.T) {
+	s := newTestClientService(t)
+	s.SetAttendanceDedupWindow(time.Minute)
+	client := createTestClient(t, s, "Offline Dedup")
+
+	csv := fmt.Sprintf("barcode,scanned_at\n%[1]s,2025-01-06 10:00:00\n%[1]s,2025-01-06 10:00:30\n%[1]s,2025-01-06 14:00:00\n", client.BarcodeID)
+	resp, err := s.ImportAttendance(context.Background(), strings.NewReader(csv), model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	want := []string{"recorded", "duplicate", "recorded"}
+	if got := importStatuses(resp); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	if resp.Matched != 2 || resp.Duplicates != 1 {
+		t.Errorf("matched %d, duplicates %d; want 2, 1", resp.Matched, resp.Duplicates)
+	}
+}
+
+func TestImportAttendanceAppliesCooldown(t *testing.T) {
+	s := newTestClientService(t)
+	s.SetAttendanceCooldown(24 * time.Hour)
+	client := createTestClient(t, s, "Offline Cooldown")
+
+	csv := fmt.Sprintf("%[1]s,2025-01-06 10:00\n%[1]s,2025-01-06 15:00\n%[1]s,2025-01-08 10:00\n", client.BarcodeID)
+	resp, err := s.ImportAttendance(context.Background(), strings.NewReader(csv), model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	want := []string{"recorded", "too_soon", "recorded"}
+	if got := importStatuses(resp); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	if resp.TooSoon != 1 {
+		t.Errorf("too soon = %d, want 1", resp.TooSoon)
+	}
+}
+
+func TestImportAttendanceSeesLiveScans(t *testing.T) {
+	s := newTestClientService(t)
+	s.SetAttendanceDedupWindow(time.Minute)
+	ctx := context.Background()
+	client := createTestClient(t, s, "Scanned Live")
+
+	live, _, err := s.RecordAttendance(ctx, client.ID, model.SystemStaffID, false, "")
+	if err != nil {
+		t.Fatalf("live scan: %v", err)
+	}
+
+	csv := fmt.Sprintf("%s,%s\n", client.BarcodeID, live.VerifiedAt.Add(-20*time.Second).Format(time.RFC3339))
+	resp, err := s.ImportAttendance(ctx, strings.NewReader(csv), model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if resp.Results[0].Status != "duplicate" || resp.Results[0].Attendance == nil || resp.Results[0].Attendance.ID != live.ID {
+		t.Errorf("result = %+v, want a duplicate of the live scan %s", resp.Results[0], live.ID)
+	}
+}
@@ -2,15 +2,24 @@ package service
 
 import (
 	"archive/zip"
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
 )
 
 // BackupService handles database backup and restore operations
@@ -25,15 +34,117 @@ func NewBackupService(db *pgxpool.Pool) *BackupService {
 
 // Backup represents a complete database backup
 type Backup struct {
-	Version              string                  `json:"version"`
-	CreatedAt            time.Time               `json:"created_at"`
-	CreatedBy            string                  `json:"created_by"`
-	Staff                []StaffBackup           `json:"staff"`
-	Clients              []ClientBackup          `json:"clients"`
-	Attendance           []AttendanceBackup      `json:"attendance"`
-	AuditLog             []AuditLogBackup        `json:"audit_log"`
-	RegistrationRequests []RegistrationBackup    `json:"registration_requests"`
-	VerificationCodes    []VerificationBackup    `json:"verification_codes"`
+	Version              string                    `json:"version"`
+	CreatedAt            time.Time                 `json:"created_at"`
+	CreatedBy            string                    `json:"created_by"`
+	Staff                []StaffBackup             `json:"staff"`
+	Clients              []ClientBackup            `json:"clients"`
+	Attendance           []AttendanceBackup        `json:"attendance"`
+	ClientAppointments   []ClientAppointmentBackup `json:"client_appointments,omitempty"`
+	AuditLog             []AuditLogBackup          `json:"audit_log"`
+	RegistrationRequests []RegistrationBackup      `json:"registration_requests"`
+	VerificationCodes    []VerificationBackup      `json:"verification_codes"`
+	Checksum             string                    `json:"checksum,omitempty"`
+}
+
+// CurrentBackupVersion is the backup format written by this binary.
+// 1.1 added client dietary notes and archiving, and the checksum.
+const CurrentBackupVersion = "1.1"
+
+var (
+	// ErrBackupChecksumMismatch means the backup data does not match its checksum
+	ErrBackupChecksumMismatch = errors.New("backup checksum mismatch")
+	// ErrBackupTooNew means the backup was written by a newer release
+	ErrBackupTooNew = errors.New("backup version is newer than this server supports")
+	// ErrUnsupportedBackupVersion means the version string is missing or unknown
+	ErrUnsupportedBackupVersion = errors.New("unsupported backup version")
+)
+
+// parseBackupVersion splits a "major.minor" version string
+func parseBackupVersion(v string) (major, minor int, err error) {
+	if _, err := fmt.Sscanf(v, "%d.%d", &major, &minor); err != nil {
+		return 0, 0, fmt.Errorf("%w: %q", ErrUnsupportedBackupVersion, v)
+	}
+	return major, minor, nil
+}
+
+// migrateBackup upgrades an older backup to CurrentBackupVersion in place,
+// one version step at a time, filling in fields older formats did not have
+func migrateBackup(b *Backup) (*Backup, error) {
+	major, minor, err := parseBackupVersion(b.Version)
+	if err != nil {
+		return nil, err
+	}
+	curMajor, curMinor, _ := parseBackupVersion(CurrentBackupVersion)
+	if major > curMajor || (major == curMajor && minor > curMinor) {
+		return nil, fmt.Errorf("%w: %s (supported up to %s)", ErrBackupTooNew, b.Version, CurrentBackupVersion)
+	}
+
+	switch b.Version {
+	case "0.9":
+		// 0.9 predates staff roles and deactivation: everyone was an active staff member
+		for i := range b.Staff {
+			if b.Staff[i].Role == "" {
+				b.Staff[i].Role = model.RoleStaff
+			}
+			b.Staff[i].IsActive = true
+		}
+		fallthrough
+	case "1.0":
+		// 1.0 could carry empty values where the schema now has defaults
+		for i := range b.Staff {
+			if b.Staff[i].Theme == "" {
+				b.Staff[i].Theme = "light"
+			}
+		}
+		for i := range b.Clients {
+			if b.Clients[i].FamilySize < 1 {
+				b.Clients[i].FamilySize = 1
+			}
+		}
+		fallthrough
+	case CurrentBackupVersion:
+		b.Version = CurrentBackupVersion
+		return b, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedBackupVersion, b.Version)
+	}
+}
+
+// ComputeChecksum returns the hex SHA-256 of the canonical JSON of the
+// backup's data sections. Metadata (version, created_at/by, checksum) is
+// not covered.
+func (b *Backup) ComputeChecksum() (string, error) {
+	data, err := json.Marshal(struct {
+		Staff                []StaffBackup             `json:"staff"`
+		Clients              []ClientBackup            `json:"clients"`
+		Attendance           []AttendanceBackup        `json:"attendance"`
+		ClientAppointments   []ClientAppointmentBackup `json:"client_appointments,omitempty"`
+		AuditLog             []AuditLogBackup          `json:"audit_log"`
+		RegistrationRequests []RegistrationBackup      `json:"registration_requests"`
+		VerificationCodes    []VerificationBackup      `json:"verification_codes"`
+	}{b.Staff, b.Clients, b.Attendance, b.ClientAppointments, b.AuditLog, b.RegistrationRequests, b.VerificationCodes})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// VerifyChecksum checks the stored checksum. Backups without one (created
+// before checksums were added) are accepted.
+func (b *Backup) VerifyChecksum() error {
+	if b.Checksum == "" {
+		return nil
+	}
+	sum, err := b.ComputeChecksum()
+	if err != nil {
+		return fmt.Errorf("failed to compute checksum: %w", err)
+	}
+	if sum != b.Checksum {
+		return ErrBackupChecksumMismatch
+	}
+	return nil
 }
 
 // StaffBackup represents a staff record for backup
@@ -73,8 +184,15 @@ type ClientBackup struct {
 	PrefHalal       bool      `json:"pref_halal"`
 	PrefVegetarian  bool      `json:"pref_vegetarian"`
 	PrefNoCooking   bool      `json:"pref_no_cooking"`
-	CreatedAt       time.Time `json:"created_at"`
-	CreatedBy       uuid.UUID `json:"created_by"`
+	DietaryNotes    *string   `json:"dietary_notes,omitempty"`
+	// omitempty keeps checksums of backups taken before consent was tracked stable
+	ConsentDataStorage bool       `json:"consent_data_storage,omitempty"`
+	ConsentPhoto       bool       `json:"consent_photo,omitempty"`
+	ConsentRecordedAt  *time.Time `json:"consent_recorded_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	CreatedBy          uuid.UUID  `json:"created_by"`
+	ArchivedAt         *time.Time `json:"archived_at,omitempty"`
+	ArchivedBy         *uuid.UUID `json:"archived_by,omitempty"`
 }
 
 // AttendanceBackup represents an attendance record for backup
@@ -83,6 +201,22 @@ type AttendanceBackup struct {
 	ClientID   uuid.UUID `json:"client_id"`
 	VerifiedBy uuid.UUID `json:"verified_by"`
 	VerifiedAt time.Time `json:"verified_at"`
+	// omitempty keeps checksums of backups taken before this column stable
+	OverrideReason *string    `json:"override_reason,omitempty"`
+	VoidedAt       *time.Time `json:"voided_at,omitempty"`
+	VoidedBy       *uuid.UUID `json:"voided_by,omitempty"`
+}
+
+// ClientAppointmentBackup represents a client appointment slot for backup.
+// Backups taken before slots existed have none; the primary slot is then
+// derived from the client's appointment_day/time on restore.
+type ClientAppointmentBackup struct {
+	ID        uuid.UUID `json:"id"`
+	ClientID  uuid.UUID `json:"client_id"`
+	Day       string    `json:"day"`
+	Time      *string   `json:"time,omitempty"`
+	IsPrimary bool      `json:"is_primary"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // AuditLogBackup represents an audit log record for backup
@@ -123,143 +257,311 @@ type VerificationBackup struct {
 	CreatedAt  time.Time  `json:"created_at"`
 }
 
-// CreateBackup exports all database tables to a Backup struct
-func (s *BackupService) CreateBackup(ctx context.Context, createdBy string) (*Backup, error) {
-	backup := &Backup{
-		Version:   "1.0",
-		CreatedAt: time.Now().UTC(),
-		CreatedBy: createdBy,
-	}
+// backupSection is one data table of a JSON backup, in file order. scan
+// reads the current row as the section's element type and add appends such
+// a value to an in-memory Backup.
+type backupSection struct {
+	key       string
+	omitEmpty bool
+	query     string
+	scan      func(rows pgx.Rows) (interface{}, error)
+	add       func(b *Backup, row interface{})
+}
 
-	// Export staff
-	rows, err := s.db.Query(ctx, `
-		SELECT id, auth0_id, name, email, mobile, address, theme,
-		       COALESCE(background_image, '') as background_image, role, is_active,
-		       email_verified, email_verified_at, created_at, created_by,
-		       deactivated_at, deactivated_by
-		FROM staff ORDER BY created_at
-	`)
+var backupSections = []backupSection{
+	{
+		key: "staff",
+		query: `
+			SELECT id, auth0_id, name, email, mobile, address, theme,
+			       COALESCE(background_image, '') as background_image, role, is_active,
+			       email_verified, email_verified_at, created_at, created_by,
+			       deactivated_at, deactivated_by
+			FROM staff ORDER BY created_at`,
+		scan: func(rows pgx.Rows) (interface{}, error) {
+			var s StaffBackup
+			err := rows.Scan(&s.ID, &s.Auth0ID, &s.Name, &s.Email, &s.Mobile, &s.Address,
+				&s.Theme, &s.BackgroundImage, &s.Role, &s.IsActive, &s.EmailVerified,
+				&s.EmailVerifiedAt, &s.CreatedAt, &s.CreatedBy, &s.DeactivatedAt, &s.DeactivatedBy)
+			return s, err
+		},
+		add: func(b *Backup, row interface{}) { b.Staff = append(b.Staff, row.(StaffBackup)) },
+	},
+	{
+		key: "clients",
+		query: `
+			SELECT id, barcode_id, name, address, family_size, num_children, children_ages,
+			       reason, photo_url, appointment_day, appointment_time, pref_gluten_free,
+			       pref_halal, pref_vegetarian, pref_no_cooking, dietary_notes, created_at, created_by,
+			       archived_at, archived_by, consent_data_storage, consent_photo, consent_recorded_at
+			FROM clients ORDER BY created_at`,
+		scan: func(rows pgx.Rows) (interface{}, error) {
+			var c ClientBackup
+			err := rows.Scan(&c.ID, &c.BarcodeID, &c.Name, &c.Address, &c.FamilySize,
+				&c.NumChildren, &c.ChildrenAges, &c.Reason, &c.PhotoURL, &c.AppointmentDay,
+				&c.AppointmentTime, &c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian,
+				&c.PrefNoCooking, &c.DietaryNotes, &c.CreatedAt, &c.CreatedBy,
+				&c.ArchivedAt, &c.ArchivedBy, &c.ConsentDataStorage, &c.ConsentPhoto, &c.ConsentRecordedAt)
+			return c, err
+		},
+		add: func(b *Backup, row interface{}) { b.Clients = append(b.Clients, row.(ClientBackup)) },
+	},
+	{
+		key: "attendance",
+		query: `
+			SELECT id, client_id, verified_by, verified_at, override_reason, voided_at, voided_by
+			FROM attendance ORDER BY verified_at`,
+		scan: func(rows pgx.Rows) (interface{}, error) {
+			var a AttendanceBackup
+			err := rows.Scan(&a.ID, &a.ClientID, &a.VerifiedBy, &a.VerifiedAt, &a.OverrideReason, &a.VoidedAt, &a.VoidedBy)
+			return a, err
+		},
+		add: func(b *Backup, row interface{}) { b.Attendance = append(b.Attendance, row.(AttendanceBackup)) },
+	},
+	{
+		key:       "client_appointments",
+		omitEmpty: true,
+		query: `
+			SELECT id, client_id, day, time, is_primary, created_at
+			FROM client_appointments ORDER BY created_at`,
+		scan: func(rows pgx.Rows) (interface{}, error) {
+			var a ClientAppointmentBackup
+			err := rows.Scan(&a.ID, &a.ClientID, &a.Day, &a.Time, &a.IsPrimary, &a.CreatedAt)
+			return a, err
+		},
+		add: func(b *Backup, row interface{}) {
+			b.ClientAppointments = append(b.ClientAppointments, row.(ClientAppointmentBackup))
+		},
+	},
+	{
+		key: "audit_log",
+		query: `
+			SELECT id, table_name, record_id, action, old_values, new_values, changed_by, changed_at
+			FROM audit_log ORDER BY changed_at`,
+		scan: func(rows pgx.Rows) (interface{}, error) {
+			var a AuditLogBackup
+			err := rows.Scan(&a.ID, &a.TableName, &a.RecordID, &a.Action, &a.OldValues,
+				&a.NewValues, &a.ChangedBy, &a.ChangedAt)
+			return a, err
+		},
+		add: func(b *Backup, row interface{}) { b.AuditLog = append(b.AuditLog, row.(AuditLogBackup)) },
+	},
+	{
+		key: "registration_requests",
+		query: `
+			SELECT id, name, email, mobile, address, status, approval_token,
+			       token_expires_at, created_at, reviewed_at, reviewed_by
+			FROM registration_requests ORDER BY created_at`,
+		scan: func(rows pgx.Rows) (interface{}, error) {
+			var r RegistrationBackup
+			err := rows.Scan(&r.ID, &r.Name, &r.Email, &r.Mobile, &r.Address, &r.Status,
+				&r.ApprovalToken, &r.TokenExpiresAt, &r.CreatedAt, &r.ReviewedAt, &r.ReviewedBy)
+			return r, err
+		},
+		add: func(b *Backup, row interface{}) {
+			b.RegistrationRequests = append(b.RegistrationRequests, row.(RegistrationBackup))
+		},
+	},
+	{
+		key: "verification_codes",
+		query: `
+			SELECT id, staff_id, code, expires_at, attempts, verified_at, created_at
+			FROM verification_codes ORDER BY created_at`,
+		scan: func(rows pgx.Rows) (interface{}, error) {
+			var v VerificationBackup
+			err := rows.Scan(&v.ID, &v.StaffID, &v.Code, &v.ExpiresAt, &v.Attempts,
+				&v.VerifiedAt, &v.CreatedAt)
+			return v, err
+		},
+		add: func(b *Backup, row interface{}) {
+			b.VerificationCodes = append(b.VerificationCodes, row.(VerificationBackup))
+		},
+	},
+}
+
+// eachBackupRow calls fn with every row of sec in turn
+func eachBackupRow(ctx context.Context, tx pgx.Tx, sec backupSection, fn func(row interface{}) error) error {
+	rows, err := tx.Query(ctx, sec.query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query staff: %w", err)
+		return fmt.Errorf("failed to query %s: %w", sec.key, err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var s StaffBackup
-		err := rows.Scan(&s.ID, &s.Auth0ID, &s.Name, &s.Email, &s.Mobile, &s.Address,
-			&s.Theme, &s.BackgroundImage, &s.Role, &s.IsActive, &s.EmailVerified,
-			&s.EmailVerifiedAt, &s.CreatedAt, &s.CreatedBy, &s.DeactivatedAt, &s.DeactivatedBy)
+		row, err := sec.scan(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan staff: %w", err)
+			return fmt.Errorf("failed to scan %s: %w", sec.key, err)
+		}
+		if err := fn(row); err != nil {
+			return err
 		}
-		backup.Staff = append(backup.Staff, s)
 	}
+	return rows.Err()
+}
 
-	// Export clients
-	rows, err = s.db.Query(ctx, `
-		SELECT id, barcode_id, name, address, family_size, num_children, children_ages,
-		       reason, photo_url, appointment_day, appointment_time, pref_gluten_free,
-		       pref_halal, pref_vegetarian, pref_no_cooking, created_at, created_by
-		FROM clients ORDER BY created_at
-	`)
+// beginSnapshot starts a read-only transaction so every table in a backup
+// is read from the same snapshot
+func (s *BackupService) beginSnapshot(ctx context.Context) (pgx.Tx, error) {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
 	if err != nil {
-		return nil, fmt.Errorf("failed to query clients: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer rows.Close()
+	return tx, nil
+}
 
-	for rows.Next() {
-		var c ClientBackup
-		err := rows.Scan(&c.ID, &c.BarcodeID, &c.Name, &c.Address, &c.FamilySize,
-			&c.NumChildren, &c.ChildrenAges, &c.Reason, &c.PhotoURL, &c.AppointmentDay,
-			&c.AppointmentTime, &c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian,
-			&c.PrefNoCooking, &c.CreatedAt, &c.CreatedBy)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan client: %w", err)
-		}
-		backup.Clients = append(backup.Clients, c)
+// CreateBackup exports all database tables to a Backup struct
+func (s *BackupService) CreateBackup(ctx context.Context, createdBy string) (*Backup, error) {
+	backup := &Backup{
+		Version:   CurrentBackupVersion,
+		CreatedAt: time.Now().UTC(),
+		CreatedBy: createdBy,
 	}
 
-	// Export attendance
-	rows, err = s.db.Query(ctx, `
-		SELECT id, client_id, verified_by, verified_at
-		FROM attendance ORDER BY verified_at
-	`)
+	tx, err := s.beginSnapshot(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query attendance: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
+	defer tx.Rollback(ctx)
 
-	for rows.Next() {
-		var a AttendanceBackup
-		err := rows.Scan(&a.ID, &a.ClientID, &a.VerifiedBy, &a.VerifiedAt)
+	for _, sec := range backupSections {
+		err := eachBackupRow(ctx, tx, sec, func(row interface{}) error {
+			sec.add(backup, row)
+			return nil
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan attendance: %w", err)
+			return nil, err
 		}
-		backup.Attendance = append(backup.Attendance, a)
 	}
 
-	// Export audit log
-	rows, err = s.db.Query(ctx, `
-		SELECT id, table_name, record_id, action, old_values, new_values, changed_by, changed_at
-		FROM audit_log ORDER BY changed_at
-	`)
+	backup.Checksum, err = backup.ComputeChecksum()
 	if err != nil {
-		return nil, fmt.Errorf("failed to query audit_log: %w", err)
+		return nil, fmt.Errorf("failed to compute checksum: %w", err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var a AuditLogBackup
-		err := rows.Scan(&a.ID, &a.TableName, &a.RecordID, &a.Action, &a.OldValues,
-			&a.NewValues, &a.ChangedBy, &a.ChangedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan audit_log: %w", err)
-		}
-		backup.AuditLog = append(backup.AuditLog, a)
-	}
+	return backup, nil
+}
 
-	// Export registration requests
-	rows, err = s.db.Query(ctx, `
-		SELECT id, name, email, mobile, address, status, approval_token,
-		       token_expires_at, created_at, reviewed_at, reviewed_by
-		FROM registration_requests ORDER BY created_at
-	`)
+// BackupStream is a backup snapshot that has been opened but not yet
+// written. Close must be called once it is no longer needed.
+type BackupStream struct {
+	tx     pgx.Tx
+	header *Backup
+}
+
+// BeginStreamBackup opens the snapshot for a streamed backup. Callers should
+// do this before committing to a response, so a database failure can still
+// be reported properly.
+func (s *BackupService) BeginStreamBackup(ctx context.Context, createdBy string) (*BackupStream, error) {
+	tx, err := s.beginSnapshot(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query registration_requests: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
+	return &BackupStream{
+		tx: tx,
+		header: &Backup{
+			Version:   CurrentBackupVersion,
+			CreatedAt: time.Now().UTC(),
+			CreatedBy: createdBy,
+		},
+	}, nil
+}
 
-	for rows.Next() {
-		var r RegistrationBackup
-		err := rows.Scan(&r.ID, &r.Name, &r.Email, &r.Mobile, &r.Address, &r.Status,
-			&r.ApprovalToken, &r.TokenExpiresAt, &r.CreatedAt, &r.ReviewedAt, &r.ReviewedBy)
+// Write writes the same JSON as encoding CreateBackup's result, but row by
+// row, so memory use doesn't grow with the size of the database. The checksum
+// is hashed as the data goes out and written last. Once anything has been
+// written an error leaves w holding a truncated document.
+func (b *BackupStream) Write(ctx context.Context, w io.Writer) error {
+	return writeBackupJSON(w, b.header, func(sec backupSection, fn func(row interface{}) error) error {
+		return eachBackupRow(ctx, b.tx, sec, fn)
+	})
+}
+
+// Close releases the snapshot
+func (b *BackupStream) Close(ctx context.Context) {
+	b.tx.Rollback(ctx)
+}
+
+// writeBackupJSON writes header's metadata followed by every section's rows
+// as produced by each. The output matches json.Encoder on the equivalent
+// Backup: data sections are laid out exactly as ComputeChecksum marshals
+// them, so those bytes double as the checksum input.
+func writeBackupJSON(w io.Writer, header *Backup, each func(sec backupSection, fn func(row interface{}) error) error) error {
+	bw := bufio.NewWriter(w)
+	hash := sha256.New()
+	data := io.MultiWriter(bw, hash)
+
+	// writeJSON writes the encoding of v, as json.Marshal would produce it
+	writeJSON := func(out io.Writer, v interface{}) error {
+		b, err := json.Marshal(v)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan registration_request: %w", err)
+			return err
 		}
-		backup.RegistrationRequests = append(backup.RegistrationRequests, r)
+		_, err = out.Write(b)
+		return err
 	}
 
-	// Export verification codes
-	rows, err = s.db.Query(ctx, `
-		SELECT id, staff_id, code, expires_at, attempts, verified_at, created_at
-		FROM verification_codes ORDER BY created_at
-	`)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query verification_codes: %w", err)
+	bw.WriteString(`{"version":`)
+	if err := writeJSON(bw, header.Version); err != nil {
+		return err
+	}
+	bw.WriteString(`,"created_at":`)
+	if err := writeJSON(bw, header.CreatedAt); err != nil {
+		return err
+	}
+	bw.WriteString(`,"created_by":`)
+	if err := writeJSON(bw, header.CreatedBy); err != nil {
+		return err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var v VerificationBackup
-		err := rows.Scan(&v.ID, &v.StaffID, &v.Code, &v.ExpiresAt, &v.Attempts,
-			&v.VerifiedAt, &v.CreatedAt)
+	// Data sections follow the metadata in the file but open the checksum
+	// input, so the comma before the first one is not hashed
+	hash.Write([]byte("{"))
+	first := true
+	writeKey := func(key string) error {
+		if first {
+			bw.WriteString(",")
+		} else if _, err := io.WriteString(data, ","); err != nil {
+			return err
+		}
+		first = false
+		_, err := io.WriteString(data, `"`+key+`":`)
+		return err
+	}
+
+	for _, sec := range backupSections {
+		// The key goes out with the first row so an empty omitEmpty section
+		// can be left out entirely
+		rowCount := 0
+		err := each(sec, func(row interface{}) error {
+			if rowCount == 0 {
+				if err := writeKey(sec.key); err != nil {
+					return err
+				}
+				io.WriteString(data, "[")
+			} else {
+				io.WriteString(data, ",")
+			}
+			rowCount++
+			return writeJSON(data, row)
+		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan verification_code: %w", err)
+			return err
+		}
+
+		switch {
+		case rowCount > 0:
+			io.WriteString(data, "]")
+		case !sec.omitEmpty:
+			// A table with no rows is a nil slice in Backup
+			if err := writeKey(sec.key); err != nil {
+				return err
+			}
+			io.WriteString(data, "null")
 		}
-		backup.VerificationCodes = append(backup.VerificationCodes, v)
 	}
+	hash.Write([]byte("}"))
 
-	return backup, nil
+	bw.WriteString(`,"checksum":"` + hex.EncodeToString(hash.Sum(nil)) + "\"}\n")
+	return bw.Flush()
 }
 
 // ExportCSV exports all tables as a ZIP archive containing CSV files
@@ -360,15 +662,13 @@ func (s *BackupService) writeClientsCSV(ctx context.Context, zw *zip.Writer, bom
 	f.Write(bom)
 	w := csv.NewWriter(f)
 
-	w.Write([]string{"id", "barcode_id", "name", "address", "family_size", "num_children",
-		"children_ages", "reason", "photo_url", "appointment_day", "appointment_time",
-		"pref_gluten_free", "pref_halal", "pref_vegetarian", "pref_no_cooking",
-		"created_at", "created_by"})
+	w.Write(clientCSVHeader)
 
 	rows, err := s.db.Query(ctx, `
 		SELECT id, barcode_id, name, address, family_size, num_children, children_ages,
 		       reason, photo_url, appointment_day, appointment_time, pref_gluten_free,
-		       pref_halal, pref_vegetarian, pref_no_cooking, created_at, created_by
+		       pref_halal, pref_vegetarian, pref_no_cooking, dietary_notes, created_at, created_by,
+		       archived_at, archived_by, consent_data_storage, consent_photo, consent_recorded_at
 		FROM clients ORDER BY created_at
 	`)
 	if err != nil {
@@ -381,24 +681,51 @@ func (s *BackupService) writeClientsCSV(ctx context.Context, zw *zip.Writer, bom
 		err := rows.Scan(&c.ID, &c.BarcodeID, &c.Name, &c.Address, &c.FamilySize,
 			&c.NumChildren, &c.ChildrenAges, &c.Reason, &c.PhotoURL, &c.AppointmentDay,
 			&c.AppointmentTime, &c.PrefGlutenFree, &c.PrefHalal, &c.PrefVegetarian,
-			&c.PrefNoCooking, &c.CreatedAt, &c.CreatedBy)
+			&c.PrefNoCooking, &c.DietaryNotes, &c.CreatedAt, &c.CreatedBy,
+			&c.ArchivedAt, &c.ArchivedBy, &c.ConsentDataStorage, &c.ConsentPhoto, &c.ConsentRecordedAt)
 		if err != nil {
 			return err
 		}
-		w.Write([]string{
-			c.ID.String(), c.BarcodeID, c.Name, c.Address,
-			fmt.Sprintf("%d", c.FamilySize), fmt.Sprintf("%d", c.NumChildren),
-			ptrToString(c.ChildrenAges), ptrToString(c.Reason), ptrToString(c.PhotoURL),
-			ptrToString(c.AppointmentDay), ptrToString(c.AppointmentTime),
-			boolToString(c.PrefGlutenFree), boolToString(c.PrefHalal),
-			boolToString(c.PrefVegetarian), boolToString(c.PrefNoCooking),
-			c.CreatedAt.Format(time.RFC3339), c.CreatedBy.String(),
-		})
+		w.Write(clientCSVRow(&c))
 	}
 	w.Flush()
 	return nil
 }
 
+// clientCSVHeader is the column order shared by the backup and roster exports
+var clientCSVHeader = []string{"id", "barcode_id", "name", "address", "family_size", "num_children",
+	"children_ages", "reason", "photo_url", "appointment_day", "appointment_time",
+	"pref_gluten_free", "pref_halal", "pref_vegetarian", "pref_no_cooking",
+	"dietary_notes", "created_at", "created_by", "archived_at", "archived_by",
+	"consent_data_storage", "consent_photo", "consent_recorded_at"}
+
+// clientBackupFromModel copies the stored client columns into a ClientBackup
+func clientBackupFromModel(c *model.Client) ClientBackup {
+	return ClientBackup{
+		ID: c.ID, BarcodeID: c.BarcodeID, Name: c.Name, Address: c.Address,
+		FamilySize: c.FamilySize, NumChildren: c.NumChildren, ChildrenAges: c.ChildrenAges,
+		Reason: c.Reason, PhotoURL: c.PhotoURL, AppointmentDay: c.AppointmentDay, AppointmentTime: c.AppointmentTime,
+		PrefGlutenFree: c.PrefGlutenFree, PrefHalal: c.PrefHalal, PrefVegetarian: c.PrefVegetarian, PrefNoCooking: c.PrefNoCooking,
+		DietaryNotes: c.DietaryNotes, CreatedAt: c.CreatedAt, CreatedBy: c.CreatedBy,
+		ArchivedAt: c.ArchivedAt, ArchivedBy: c.ArchivedBy,
+		ConsentDataStorage: c.ConsentDataStorage, ConsentPhoto: c.ConsentPhoto, ConsentRecordedAt: c.ConsentRecordedAt,
+	}
+}
+
+func clientCSVRow(c *ClientBackup) []string {
+	return []string{
+		c.ID.String(), c.BarcodeID, c.Name, c.Address,
+		fmt.Sprintf("%d", c.FamilySize), fmt.Sprintf("%d", c.NumChildren),
+		ptrToString(c.ChildrenAges), ptrToString(c.Reason), ptrToString(c.PhotoURL),
+		ptrToString(c.AppointmentDay), ptrToString(c.AppointmentTime),
+		boolToString(c.PrefGlutenFree), boolToString(c.PrefHalal),
+		boolToString(c.PrefVegetarian), boolToString(c.PrefNoCooking),
+		ptrToString(c.DietaryNotes), c.CreatedAt.Format(time.RFC3339), c.CreatedBy.String(),
+		timeToString(c.ArchivedAt), uuidPtrToString(c.ArchivedBy),
+		boolToString(c.ConsentDataStorage), boolToString(c.ConsentPhoto), timeToString(c.ConsentRecordedAt),
+	}
+}
+
 func (s *BackupService) writeAttendanceCSV(ctx context.Context, zw *zip.Writer, bom []byte) error {
 	f, err := zw.Create("attendance.csv")
 	if err != nil {
@@ -407,10 +734,10 @@ func (s *BackupService) writeAttendanceCSV(ctx context.Context, zw *zip.Writer,
 	f.Write(bom)
 	w := csv.NewWriter(f)
 
-	w.Write([]string{"id", "client_id", "verified_by", "verified_at"})
+	w.Write([]string{"id", "client_id", "verified_by", "verified_at", "override_reason", "voided_at", "voided_by"})
 
 	rows, err := s.db.Query(ctx, `
-		SELECT id, client_id, verified_by, verified_at
+		SELECT id, client_id, verified_by, verified_at, override_reason, voided_at, voided_by
 		FROM attendance ORDER BY verified_at
 	`)
 	if err != nil {
@@ -420,13 +747,14 @@ func (s *BackupService) writeAttendanceCSV(ctx context.Context, zw *zip.Writer,
 
 	for rows.Next() {
 		var a AttendanceBackup
-		err := rows.Scan(&a.ID, &a.ClientID, &a.VerifiedBy, &a.VerifiedAt)
+		err := rows.Scan(&a.ID, &a.ClientID, &a.VerifiedBy, &a.VerifiedAt, &a.OverrideReason, &a.VoidedAt, &a.VoidedBy)
 		if err != nil {
 			return err
 		}
 		w.Write([]string{
 			a.ID.String(), a.ClientID.String(), a.VerifiedBy.String(),
-			a.VerifiedAt.Format(time.RFC3339),
+			a.VerifiedAt.Format(time.RFC3339), ptrToString(a.OverrideReason),
+			timeToString(a.VoidedAt), uuidPtrToString(a.VoidedBy),
 		})
 	}
 	w.Flush()
@@ -543,125 +871,667 @@ func (s *BackupService) writeVerificationCodesCSV(ctx context.Context, zw *zip.W
 	return nil
 }
 
-// RestoreBackup imports data from a backup
-func (s *BackupService) RestoreBackup(ctx context.Context, backup *Backup) error {
-	tx, err := s.db.Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+// RestoreMode selects how RestoreBackup treats rows already in the database
+type RestoreMode string
+
+const (
+	// RestoreModeReplace wipes every table before importing the backup
+	RestoreModeReplace RestoreMode = "replace"
+	// RestoreModeMerge upserts backup rows by id and keeps rows not in the backup
+	RestoreModeMerge RestoreMode = "merge"
+)
+
+var ErrInvalidRestoreMode = errors.New("invalid restore mode")
+
+// RestoreTableCount reports how many rows of one table were inserted or updated
+type RestoreTableCount struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+}
+
+// RestoreResult summarises a restore per table
+type RestoreResult struct {
+	Mode   RestoreMode                  `json:"mode"`
+	Tables map[string]RestoreTableCount `json:"tables"`
+}
+
+var (
+	staffRestoreColumns = []string{"id", "auth0_id", "name", "email", "mobile", "address", "theme",
+		"background_image", "role", "is_active", "email_verified", "email_verified_at", "created_at",
+		"created_by", "deactivated_at", "deactivated_by"}
+	clientRestoreColumns = []string{"id", "barcode_id", "name", "address", "family_size", "num_children",
+		"children_ages", "reason", "photo_url", "appointment_day", "appointment_time", "pref_gluten_free",
+		"pref_halal", "pref_vegetarian", "pref_no_cooking", "dietary_notes", "created_at", "created_by",
+		"archived_at", "archived_by", "consent_data_storage", "consent_photo", "consent_recorded_at"}
+	attendanceRestoreColumns   = []string{"id", "client_id", "verified_by", "verified_at", "override_reason", "voided_at", "voided_by"}
+	appointmentRestoreColumns  = []string{"id", "client_id", "day", "time", "is_primary", "created_at"}
+	auditLogRestoreColumns     = []string{"id", "table_name", "record_id", "action", "old_values", "new_values", "changed_by", "changed_at"}
+	registrationRestoreColumns = []string{"id", "name", "email", "mobile", "address", "status", "approval_token",
+		"token_expires_at", "created_at", "reviewed_at", "reviewed_by"}
+	verificationRestoreColumns = []string{"id", "staff_id", "code", "expires_at", "attempts", "verified_at", "created_at"}
+)
+
+// upsertSQL builds an INSERT ... ON CONFLICT (id) DO UPDATE for the given
+// columns. It returns whether the row was newly inserted (xmax = 0).
+func upsertSQL(table string, columns []string) string {
+	placeholders := make([]string, len(columns))
+	updates := make([]string, 0, len(columns)-1)
+	for i, col := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		if col != "id" {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", col, col))
+		}
 	}
-	defer tx.Rollback(ctx)
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (id) DO UPDATE SET %s RETURNING (xmax = 0)",
+		table, strings.Join(columns, ", "), strings.Join(placeholders, ", "), strings.Join(updates, ", "))
+}
 
-	// Disable foreign key checks temporarily by deleting in correct order
-	// Delete in reverse dependency order
-	_, err = tx.Exec(ctx, "DELETE FROM verification_codes")
-	if err != nil {
-		return fmt.Errorf("failed to clear verification_codes: %w", err)
+// restoreRow upserts one row and records it in the table's counts
+func restoreRow(ctx context.Context, tx pgx.Tx, result *RestoreResult, table string, columns []string, args ...interface{}) error {
+	var inserted bool
+	if err := tx.QueryRow(ctx, upsertSQL(table, columns), args...).Scan(&inserted); err != nil {
+		return err
 	}
-	_, err = tx.Exec(ctx, "DELETE FROM registration_requests")
-	if err != nil {
-		return fmt.Errorf("failed to clear registration_requests: %w", err)
+	count := result.Tables[table]
+	if inserted {
+		count.Inserted++
+	} else {
+		count.Updated++
 	}
-	_, err = tx.Exec(ctx, "DELETE FROM audit_log")
-	if err != nil {
-		return fmt.Errorf("failed to clear audit_log: %w", err)
+	result.Tables[table] = count
+	return nil
+}
+
+// RestoreBackup imports data from a backup. In replace mode every table is
+// cleared first; in merge mode rows are upserted by id and existing rows
+// missing from the backup are left alone. A merge is refused with a
+// *BackupConflictError if a row's other unique keys (e.g. a staff email or
+// client barcode) belong to a different existing row.
+func (s *BackupService) RestoreBackup(ctx context.Context, backup *Backup, mode RestoreMode, restoredBy uuid.UUID) (*RestoreResult, error) {
+	if mode != RestoreModeReplace && mode != RestoreModeMerge {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRestoreMode, mode)
 	}
-	_, err = tx.Exec(ctx, "DELETE FROM attendance")
-	if err != nil {
-		return fmt.Errorf("failed to clear attendance: %w", err)
+	// The checksum covers the data as written, so verify before migrating
+	if err := backup.VerifyChecksum(); err != nil {
+		return nil, err
 	}
-	_, err = tx.Exec(ctx, "DELETE FROM clients")
+	backup, err := migrateBackup(backup)
 	if err != nil {
-		return fmt.Errorf("failed to clear clients: %w", err)
+		return nil, err
 	}
-	_, err = tx.Exec(ctx, "DELETE FROM staff")
+
+	tx, err := s.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to clear staff: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Catch dangling references up front rather than as a raw FK error
+	// halfway through. A merge keeps the rows already in the database, so
+	// it may also point at those.
+	var existingStaff, existingClients map[uuid.UUID]bool
+	if mode == RestoreModeMerge {
+		if existingStaff, err = existingIDs(ctx, tx, "staff"); err != nil {
+			return nil, fmt.Errorf("failed to read staff ids: %w", err)
+		}
+		if existingClients, err = existingIDs(ctx, tx, "clients"); err != nil {
+			return nil, fmt.Errorf("failed to read client ids: %w", err)
+		}
+	}
+	if issues := backupReferenceIssues(backup, existingStaff, existingClients); len(issues) > 0 {
+		return nil, &BackupReferenceError{Issues: issues}
+	}
+	// Upserts are keyed on id, so a row whose other unique keys belong to a
+	// different existing row would fail with a raw constraint error
+	if mode == RestoreModeMerge {
+		issues, err := uniqueKeyConflicts(ctx, tx, backup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check unique keys: %w", err)
+		}
+		if len(issues) > 0 {
+			return nil, &BackupConflictError{Issues: issues}
+		}
+	}
+
+	if mode == RestoreModeReplace {
+		// Delete in reverse dependency order
+		for _, table := range []string{"verification_codes", "registration_requests", "audit_log", "attendance", "client_appointments", "clients", "staff"} {
+			if _, err := tx.Exec(ctx, "DELETE FROM "+table); err != nil {
+				return nil, fmt.Errorf("failed to clear %s: %w", table, err)
+			}
+		}
+	}
+
+	result := &RestoreResult{Mode: mode, Tables: map[string]RestoreTableCount{}}
+	for _, table := range []string{"staff", "clients", "client_appointments", "attendance", "audit_log", "registration_requests", "verification_codes"} {
+		result.Tables[table] = RestoreTableCount{}
 	}
 
 	// Import staff first (no dependencies)
 	for _, staff := range backup.Staff {
-		_, err := tx.Exec(ctx, `
-			INSERT INTO staff (id, auth0_id, name, email, mobile, address, theme, background_image,
-			                   role, is_active, email_verified, email_verified_at, created_at,
-			                   created_by, deactivated_at, deactivated_by)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
-		`, staff.ID, staff.Auth0ID, staff.Name, staff.Email, staff.Mobile, staff.Address,
+		err := restoreRow(ctx, tx, result, "staff", staffRestoreColumns,
+			staff.ID, staff.Auth0ID, staff.Name, staff.Email, staff.Mobile, staff.Address,
 			staff.Theme, staff.BackgroundImage, staff.Role, staff.IsActive, staff.EmailVerified,
 			staff.EmailVerifiedAt, staff.CreatedAt, staff.CreatedBy, staff.DeactivatedAt, staff.DeactivatedBy)
 		if err != nil {
-			return fmt.Errorf("failed to insert staff %s: %w", staff.Email, err)
+			return nil, fmt.Errorf("failed to restore staff %s: %w", staff.Email, err)
 		}
 	}
 
+	// Backups taken before the system actor existed don't include it
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO staff (id, auth0_id, name, email, role, is_active)
+		VALUES ($1, 'system', 'System', 'system@foodbank.invalid', 'staff', false)
+		ON CONFLICT (id) DO NOTHING`, model.SystemStaffID); err != nil {
+		return nil, fmt.Errorf("failed to restore system staff: %w", err)
+	}
+
 	// Import clients (depends on staff)
 	for _, client := range backup.Clients {
-		_, err := tx.Exec(ctx, `
-			INSERT INTO clients (id, barcode_id, name, address, family_size, num_children, children_ages,
-			                     reason, photo_url, appointment_day, appointment_time, pref_gluten_free,
-			                     pref_halal, pref_vegetarian, pref_no_cooking, created_at, created_by)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
-		`, client.ID, client.BarcodeID, client.Name, client.Address, client.FamilySize,
+		err := restoreRow(ctx, tx, result, "clients", clientRestoreColumns,
+			client.ID, client.BarcodeID, client.Name, client.Address, client.FamilySize,
 			client.NumChildren, client.ChildrenAges, client.Reason, client.PhotoURL,
 			client.AppointmentDay, client.AppointmentTime, client.PrefGlutenFree,
-			client.PrefHalal, client.PrefVegetarian, client.PrefNoCooking,
-			client.CreatedAt, client.CreatedBy)
+			client.PrefHalal, client.PrefVegetarian, client.PrefNoCooking, client.DietaryNotes,
+			client.CreatedAt, client.CreatedBy, client.ArchivedAt, client.ArchivedBy,
+			client.ConsentDataStorage, client.ConsentPhoto, client.ConsentRecordedAt)
 		if err != nil {
-			return fmt.Errorf("failed to insert client %s: %w", client.Name, err)
+			return nil, fmt.Errorf("failed to restore client %s: %w", client.Name, err)
 		}
 	}
 
+	// Import appointment slots (depends on clients). Backups taken before
+	// slots existed carry none, so derive each primary from the client row.
+	if len(backup.ClientAppointments) > 0 {
+		for _, appt := range backup.ClientAppointments {
+			if appt.IsPrimary && mode == RestoreModeMerge {
+				// Slots are only upserted, so demote any other primary the
+				// client already has rather than deleting it
+				_, err := tx.Exec(ctx, `
+					UPDATE client_appointments SET is_primary = false
+					WHERE client_id = $1 AND is_primary AND id <> $2`, appt.ClientID, appt.ID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to demote primary appointment of client %s: %w", appt.ClientID, err)
+				}
+			}
+			err := restoreRow(ctx, tx, result, "client_appointments", appointmentRestoreColumns,
+				appt.ID, appt.ClientID, appt.Day, appt.Time, appt.IsPrimary, appt.CreatedAt)
+			if err != nil {
+				return nil, fmt.Errorf("failed to restore client appointment %s: %w", appt.ID, err)
+			}
+			if appt.IsPrimary && mode == RestoreModeMerge {
+				// The client row may not be in the backup, so mirror the new
+				// primary into its legacy appointment fields
+				_, err := tx.Exec(ctx, `
+					UPDATE clients SET appointment_day = $2, appointment_time = $3, updated_at = NOW()
+					WHERE id = $1`, appt.ClientID, appt.Day, appt.Time)
+				if err != nil {
+					return nil, fmt.Errorf("failed to sync appointment of client %s: %w", appt.ClientID, err)
+				}
+			}
+		}
+	} else {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO client_appointments (client_id, day, time, is_primary)
+			SELECT c.id, c.appointment_day, c.appointment_time, TRUE
+			FROM clients c
+			WHERE c.appointment_day IS NOT NULL AND c.appointment_day <> ''
+			  AND NOT EXISTS (SELECT 1 FROM client_appointments a WHERE a.client_id = c.id AND a.is_primary)
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive client appointments: %w", err)
+		}
+		result.Tables["client_appointments"] = RestoreTableCount{Inserted: int(tag.RowsAffected())}
+	}
+
 	// Import attendance (depends on clients, staff)
 	for _, att := range backup.Attendance {
-		_, err := tx.Exec(ctx, `
-			INSERT INTO attendance (id, client_id, verified_by, verified_at)
-			VALUES ($1, $2, $3, $4)
-		`, att.ID, att.ClientID, att.VerifiedBy, att.VerifiedAt)
+		err := restoreRow(ctx, tx, result, "attendance", attendanceRestoreColumns,
+			att.ID, att.ClientID, att.VerifiedBy, att.VerifiedAt, att.OverrideReason, att.VoidedAt, att.VoidedBy)
 		if err != nil {
-			return fmt.Errorf("failed to insert attendance %s: %w", att.ID, err)
+			return nil, fmt.Errorf("failed to restore attendance %s: %w", att.ID, err)
 		}
 	}
 
 	// Import audit log (depends on staff)
 	for _, audit := range backup.AuditLog {
-		_, err := tx.Exec(ctx, `
-			INSERT INTO audit_log (id, table_name, record_id, action, old_values, new_values, changed_by, changed_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		`, audit.ID, audit.TableName, audit.RecordID, audit.Action,
+		err := restoreRow(ctx, tx, result, "audit_log", auditLogRestoreColumns,
+			audit.ID, audit.TableName, audit.RecordID, audit.Action,
 			audit.OldValues, audit.NewValues, audit.ChangedBy, audit.ChangedAt)
 		if err != nil {
-			return fmt.Errorf("failed to insert audit_log %s: %w", audit.ID, err)
+			return nil, fmt.Errorf("failed to restore audit_log %s: %w", audit.ID, err)
 		}
 	}
 
 	// Import registration requests
 	for _, req := range backup.RegistrationRequests {
-		_, err := tx.Exec(ctx, `
-			INSERT INTO registration_requests (id, name, email, mobile, address, status, approval_token,
-			                                   token_expires_at, created_at, reviewed_at, reviewed_by)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		`, req.ID, req.Name, req.Email, req.Mobile, req.Address, req.Status, req.ApprovalToken,
+		err := restoreRow(ctx, tx, result, "registration_requests", registrationRestoreColumns,
+			req.ID, req.Name, req.Email, req.Mobile, req.Address, req.Status, req.ApprovalToken,
 			req.TokenExpiresAt, req.CreatedAt, req.ReviewedAt, req.ReviewedBy)
 		if err != nil {
-			return fmt.Errorf("failed to insert registration_request %s: %w", req.Email, err)
+			return nil, fmt.Errorf("failed to restore registration_request %s: %w", req.Email, err)
 		}
 	}
 
 	// Import verification codes
 	for _, code := range backup.VerificationCodes {
-		_, err := tx.Exec(ctx, `
-			INSERT INTO verification_codes (id, staff_id, code, expires_at, attempts, verified_at, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-		`, code.ID, code.StaffID, code.Code, code.ExpiresAt, code.Attempts, code.VerifiedAt, code.CreatedAt)
+		err := restoreRow(ctx, tx, result, "verification_codes", verificationRestoreColumns,
+			code.ID, code.StaffID, code.Code, code.ExpiresAt, code.Attempts, code.VerifiedAt, code.CreatedAt)
 		if err != nil {
-			return fmt.Errorf("failed to insert verification_code %s: %w", code.ID, err)
+			return nil, fmt.Errorf("failed to restore verification_code %s: %w", code.ID, err)
 		}
 	}
 
+	if err := logRestore(ctx, tx, backup, mode, restoredBy); err != nil {
+		return nil, fmt.Errorf("failed to audit restore: %w", err)
+	}
+
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return nil
+	return result, nil
+}
+
+// BackupValidationReport describes the result of validating a backup file
+type BackupValidationReport struct {
+	Valid     bool           `json:"valid"`
+	Version   string         `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	CreatedBy string         `json:"created_by"`
+	Stats     map[string]int `json:"stats"`
+	Errors    []BackupIssue  `json:"errors"`
+}
+
+// BackupIssue describes a single problem found in a backup file
+type BackupIssue struct {
+	Table   string `json:"table"`
+	ID      string `json:"id,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ErrBackupReferentialIntegrity means backup rows reference staff or clients
+// the backup doesn't contain. Restore returns it as a *BackupReferenceError.
+var ErrBackupReferentialIntegrity = errors.New("backup references missing rows")
+
+// BackupReferenceError lists the rows whose references would dangle
+type BackupReferenceError struct {
+	Issues []BackupIssue
+}
+
+func (e *BackupReferenceError) Error() string {
+	return fmt.Sprintf("%v: %d dangling reference(s)", ErrBackupReferentialIntegrity, len(e.Issues))
+}
+
+func (e *BackupReferenceError) Unwrap() error {
+	return ErrBackupReferentialIntegrity
+}
+
+// ErrBackupConflict means merged rows share a unique key with a different
+// row already in the database. Restore returns it as a *BackupConflictError.
+var ErrBackupConflict = errors.New("backup rows conflict with existing rows")
+
+// BackupConflictError lists the rows whose unique keys are already taken
+type BackupConflictError struct {
+	Issues []BackupIssue
+}
+
+func (e *BackupConflictError) Error() string {
+	return fmt.Sprintf("%v: %d conflicting row(s)", ErrBackupConflict, len(e.Issues))
+}
+
+func (e *BackupConflictError) Unwrap() error {
+	return ErrBackupConflict
+}
+
+// backupKey is one unique key value of a backup row
+type backupKey struct {
+	id    uuid.UUID
+	value string
+}
+
+// uniqueKeyConflicts reports backup rows whose unique keys other than id
+// already belong to a different row in the database
+func uniqueKeyConflicts(ctx context.Context, tx pgx.Tx, backup *Backup) ([]BackupIssue, error) {
+	var staffAuth0, staffEmail, barcodes, requestEmail, requestToken []backupKey
+	for _, s := range backup.Staff {
+		staffAuth0 = append(staffAuth0, backupKey{s.ID, s.Auth0ID})
+		staffEmail = append(staffEmail, backupKey{s.ID, s.Email})
+	}
+	for _, c := range backup.Clients {
+		barcodes = append(barcodes, backupKey{c.ID, c.BarcodeID})
+	}
+	for _, r := range backup.RegistrationRequests {
+		requestEmail = append(requestEmail, backupKey{r.ID, r.Email})
+		requestToken = append(requestToken, backupKey{r.ID, r.ApprovalToken})
+	}
+
+	var issues []BackupIssue
+	for _, check := range []struct {
+		table, column string
+		keys          []backupKey
+	}{
+		{"staff", "auth0_id", staffAuth0},
+		{"staff", "email", staffEmail},
+		{"clients", "barcode_id", barcodes},
+		{"registration_requests", "email", requestEmail},
+		{"registration_requests", "approval_token", requestToken},
+	} {
+		found, err := keyConflicts(ctx, tx, check.table, check.column, check.keys)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+	}
+	return issues, nil
+}
+
+// keyConflicts checks one unique column of table. Values are left out of the
+// messages since some, like approval tokens, are secret.
+func keyConflicts(ctx context.Context, tx pgx.Tx, table, column string, keys []backupKey) ([]BackupIssue, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = k.value
+	}
+
+	rows, err := tx.Query(ctx, fmt.Sprintf("SELECT id, %s FROM %s WHERE %s = ANY($1)", column, table, column), values)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	owners := make(map[string]uuid.UUID)
+	for rows.Next() {
+		var id uuid.UUID
+		var value string
+		if err := rows.Scan(&id, &value); err != nil {
+			return nil, err
+		}
+		owners[value] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var issues []BackupIssue
+	for _, k := range keys {
+		if owner, ok := owners[k.value]; ok && owner != k.id {
+			issues = append(issues, BackupIssue{
+				Table:   table,
+				ID:      k.id.String(),
+				Field:   column,
+				Message: fmt.Sprintf("%s is already used by existing row %s", column, owner),
+			})
+		}
+	}
+	return issues, nil
+}
+
+// existingIDs returns the ids of every row in table
+func existingIDs(ctx context.Context, tx pgx.Tx, table string) (map[uuid.UUID]bool, error) {
+	rows, err := tx.Query(ctx, "SELECT id FROM "+table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// backupReferenceIssues checks that every foreign key in the backup points
+// at a staff member or client the backup contains, or one of existingStaff
+// and existingClients (nil for a backup on its own). The system actor always
+// counts as present because a restore seeds it.
+func backupReferenceIssues(backup *Backup, existingStaff, existingClients map[uuid.UUID]bool) []BackupIssue {
+	staffIDs := map[uuid.UUID]bool{model.SystemStaffID: true}
+	for id := range existingStaff {
+		staffIDs[id] = true
+	}
+	for _, s := range backup.Staff {
+		if s.ID != uuid.Nil {
+			staffIDs[s.ID] = true
+		}
+	}
+	clientIDs := make(map[uuid.UUID]bool, len(backup.Clients)+len(existingClients))
+	for id := range existingClients {
+		clientIDs[id] = true
+	}
+	for _, c := range backup.Clients {
+		if c.ID != uuid.Nil {
+			clientIDs[c.ID] = true
+		}
+	}
+
+	var issues []BackupIssue
+	addIssue := func(table string, id uuid.UUID, field, message string) {
+		issue := BackupIssue{Table: table, Field: field, Message: message}
+		if id != uuid.Nil {
+			issue.ID = id.String()
+		}
+		issues = append(issues, issue)
+	}
+
+	for _, s := range backup.Staff {
+		if s.CreatedBy != nil && !staffIDs[*s.CreatedBy] {
+			addIssue("staff", s.ID, "created_by", "references missing staff "+s.CreatedBy.String())
+		}
+		if s.DeactivatedBy != nil && !staffIDs[*s.DeactivatedBy] {
+			addIssue("staff", s.ID, "deactivated_by", "references missing staff "+s.DeactivatedBy.String())
+		}
+	}
+	for _, c := range backup.Clients {
+		if !staffIDs[c.CreatedBy] {
+			addIssue("clients", c.ID, "created_by", "references missing staff "+c.CreatedBy.String())
+		}
+		if c.ArchivedBy != nil && !staffIDs[*c.ArchivedBy] {
+			addIssue("clients", c.ID, "archived_by", "references missing staff "+c.ArchivedBy.String())
+		}
+	}
+	for _, a := range backup.Attendance {
+		if !clientIDs[a.ClientID] {
+			addIssue("attendance", a.ID, "client_id", "references missing client "+a.ClientID.String())
+		}
+		if !staffIDs[a.VerifiedBy] {
+			addIssue("attendance", a.ID, "verified_by", "references missing staff "+a.VerifiedBy.String())
+		}
+		if a.VoidedBy != nil && !staffIDs[*a.VoidedBy] {
+			addIssue("attendance", a.ID, "voided_by", "references missing staff "+a.VoidedBy.String())
+		}
+	}
+	for _, a := range backup.ClientAppointments {
+		if !clientIDs[a.ClientID] {
+			addIssue("client_appointments", a.ID, "client_id", "references missing client "+a.ClientID.String())
+		}
+	}
+	for _, a := range backup.AuditLog {
+		if !staffIDs[a.ChangedBy] {
+			addIssue("audit_log", a.ID, "changed_by", "references missing staff "+a.ChangedBy.String())
+		}
+	}
+	for _, r := range backup.RegistrationRequests {
+		if r.ReviewedBy != nil && !staffIDs[*r.ReviewedBy] {
+			addIssue("registration_requests", r.ID, "reviewed_by", "references missing staff "+r.ReviewedBy.String())
+		}
+	}
+	for _, v := range backup.VerificationCodes {
+		if !staffIDs[v.StaffID] {
+			addIssue("verification_codes", v.ID, "staff_id", "references missing staff "+v.StaffID.String())
+		}
+	}
+	return issues
+}
+
+// logRestore records the restore in the (restored) audit log under a fresh
+// record id. A replace may have removed the restoring admin's own staff row,
+// in which case the entry falls back to the system actor.
+func logRestore(ctx context.Context, tx pgx.Tx, backup *Backup, mode RestoreMode, restoredBy uuid.UUID) error {
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM staff WHERE id = $1)`, restoredBy).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		restoredBy = model.SystemStaffID
+	}
+
+	details, err := json.Marshal(map[string]interface{}{
+		"mode":              mode,
+		"backup_version":    backup.Version,
+		"backup_created_at": backup.CreatedAt,
+		"backup_created_by": backup.CreatedBy,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO audit_log (table_name, record_id, action, new_values, changed_by)
+		VALUES ('backups', $1, 'RESTORE', $2, $3)`, uuid.New(), details, restoredBy)
+	return err
+}
+
+// ValidateBackup checks a backup for structural and referential problems.
+// It works entirely in memory and never touches the database, so it can be
+// used to verify an archive offline.
+func ValidateBackup(backup *Backup) *BackupValidationReport {
+	report := &BackupValidationReport{
+		Version:   backup.Version,
+		CreatedAt: backup.CreatedAt,
+		CreatedBy: backup.CreatedBy,
+		Stats: map[string]int{
+			"staff":                 len(backup.Staff),
+			"clients":               len(backup.Clients),
+			"attendance":            len(backup.Attendance),
+			"client_appointments":   len(backup.ClientAppointments),
+			"audit_log":             len(backup.AuditLog),
+			"registration_requests": len(backup.RegistrationRequests),
+			"verification_codes":    len(backup.VerificationCodes),
+		},
+		Errors: []BackupIssue{},
+	}
+
+	addIssue := func(table string, id uuid.UUID, field, message string) {
+		issue := BackupIssue{Table: table, Field: field, Message: message}
+		if id != uuid.Nil {
+			issue.ID = id.String()
+		}
+		report.Errors = append(report.Errors, issue)
+	}
+
+	if backup.Version == "" {
+		addIssue("backup", uuid.Nil, "version", "missing version")
+	} else if major, minor, err := parseBackupVersion(backup.Version); err != nil {
+		addIssue("backup", uuid.Nil, "version", err.Error())
+	} else if curMajor, curMinor, _ := parseBackupVersion(CurrentBackupVersion); major > curMajor || (major == curMajor && minor > curMinor) {
+		addIssue("backup", uuid.Nil, "version", fmt.Sprintf("version %s is newer than supported %s", backup.Version, CurrentBackupVersion))
+	}
+	if err := backup.VerifyChecksum(); err != nil {
+		addIssue("backup", uuid.Nil, "checksum", err.Error())
+	}
+
+	// Structural checks: every row needs an ID, and IDs must be unique per table
+	staffIDs := make(map[uuid.UUID]bool, len(backup.Staff))
+	for _, s := range backup.Staff {
+		if s.ID == uuid.Nil {
+			addIssue("staff", s.ID, "id", "missing id")
+			continue
+		}
+		if staffIDs[s.ID] {
+			addIssue("staff", s.ID, "id", "duplicate id")
+		}
+		staffIDs[s.ID] = true
+		if s.Auth0ID == "" {
+			addIssue("staff", s.ID, "auth0_id", "missing auth0_id")
+		}
+		if s.Email == "" {
+			addIssue("staff", s.ID, "email", "missing email")
+		}
+	}
+
+	clientIDs := make(map[uuid.UUID]bool, len(backup.Clients))
+	barcodes := make(map[string]bool, len(backup.Clients))
+	for _, c := range backup.Clients {
+		if c.ID == uuid.Nil {
+			addIssue("clients", c.ID, "id", "missing id")
+			continue
+		}
+		if clientIDs[c.ID] {
+			addIssue("clients", c.ID, "id", "duplicate id")
+		}
+		clientIDs[c.ID] = true
+		if c.BarcodeID == "" {
+			addIssue("clients", c.ID, "barcode_id", "missing barcode_id")
+		} else if barcodes[c.BarcodeID] {
+			addIssue("clients", c.ID, "barcode_id", "duplicate barcode_id")
+		}
+		barcodes[c.BarcodeID] = true
+		if c.Name == "" {
+			addIssue("clients", c.ID, "name", "missing name")
+		}
+	}
+
+	attendanceIDs := make(map[uuid.UUID]bool, len(backup.Attendance))
+	for _, a := range backup.Attendance {
+		if a.ID == uuid.Nil {
+			addIssue("attendance", a.ID, "id", "missing id")
+			continue
+		}
+		if attendanceIDs[a.ID] {
+			addIssue("attendance", a.ID, "id", "duplicate id")
+		}
+		attendanceIDs[a.ID] = true
+	}
+
+	auditIDs := make(map[uuid.UUID]bool, len(backup.AuditLog))
+	for _, a := range backup.AuditLog {
+		if a.ID == uuid.Nil {
+			addIssue("audit_log", a.ID, "id", "missing id")
+			continue
+		}
+		if auditIDs[a.ID] {
+			addIssue("audit_log", a.ID, "id", "duplicate id")
+		}
+		auditIDs[a.ID] = true
+	}
+
+	requestIDs := make(map[uuid.UUID]bool, len(backup.RegistrationRequests))
+	for _, r := range backup.RegistrationRequests {
+		if r.ID == uuid.Nil {
+			addIssue("registration_requests", r.ID, "id", "missing id")
+			continue
+		}
+		if requestIDs[r.ID] {
+			addIssue("registration_requests", r.ID, "id", "duplicate id")
+		}
+		requestIDs[r.ID] = true
+	}
+
+	codeIDs := make(map[uuid.UUID]bool, len(backup.VerificationCodes))
+	for _, v := range backup.VerificationCodes {
+		if v.ID == uuid.Nil {
+			addIssue("verification_codes", v.ID, "id", "missing id")
+			continue
+		}
+		if codeIDs[v.ID] {
+			addIssue("verification_codes", v.ID, "id", "duplicate id")
+		}
+		codeIDs[v.ID] = true
+	}
+
+	report.Errors = append(report.Errors, backupReferenceIssues(backup, nil, nil)...)
+
+	report.Valid = len(report.Errors) == 0
+	return report
 }
 
 // CheckDatabaseConnection tests if the database is accessible
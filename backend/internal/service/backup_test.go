@@ -0,0 +1,202 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+func TestStreamedBackupMatchesCreateBackup(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	clients := NewClientService(repository.NewClientRepository(db), repository.NewAuditRepository(db), time.UTC)
+	client := createTestClient(t, clients, "Backup Client")
+	if _, _, err := clients.RecordAttendance(ctx, client.ID, model.SystemStaffID, false, ""); err != nil {
+		t.Fatalf("record attendance: %v", err)
+	}
+
+	s := NewBackupService(db)
+	stream, err := s.BeginStreamBackup(ctx, "test")
+	if err != nil {
+		t.Fatalf("begin stream: %v", err)
+	}
+	var streamed bytes.Buffer
+	err = stream.Write(ctx, &streamed)
+	stream.Close(ctx)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	backup, err := s.CreateBackup(ctx, "test")
+	if err != nil {
+		t.Fatalf("create backup: %v", err)
+	}
+	// The two were taken moments apart; only the timestamp may differ
+	var header Backup
+	if err := json.Unmarshal(streamed.Bytes(), &header); err != nil {
+		t.Fatalf("decode streamed backup: %v", err)
+	}
+	backup.CreatedAt = header.CreatedAt
+
+	var encoded bytes.Buffer
+	if err := json.NewEncoder(&encoded).Encode(backup); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if !bytes.Equal(streamed.Bytes(), encoded.Bytes()) {
+		t.Errorf("streamed backup differs from CreateBackup\nstreamed: %s\nencoded:  %s", streamed.Bytes(), encoded.Bytes())
+	}
+	if err := header.VerifyChecksum(); err != nil {
+		t.Errorf("streamed checksum: %v", err)
+	}
+}
+
+func TestMergeRestoreChecksReferences(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	clients := NewClientService(repository.NewClientRepository(db), repository.NewAuditRepository(db), time.UTC)
+	existing := createTestClient(t, clients, "Already Here")
+	s := NewBackupService(db)
+
+	attendanceFor := func(clientID uuid.UUID) *Backup {
+		return &Backup{
+			Version: CurrentBackupVersion,
+			Attendance: []AttendanceBackup{{
+				ID: uuid.New(), ClientID: clientID, VerifiedBy: model.SystemStaffID, VerifiedAt: time.Now().UTC(),
+			}},
+		}
+	}
+
+	// A client neither in the backup nor in the database is reported
+	missing := uuid.New()
+	_, err := s.RestoreBackup(ctx, attendanceFor(missing), RestoreModeMerge, model.SystemStaffID)
+	var refErr *BackupReferenceError
+	if !errors.As(err, &refErr) {
+		t.Fatalf("err = %v, want a BackupReferenceError", err)
+	}
+	if len(refErr.Issues) != 1 || refErr.Issues[0].Field != "client_id" {
+		t.Errorf("issues = %+v, want the dangling client_id", refErr.Issues)
+	}
+
+	// A client the database already has is fine in a merge
+	result, err := s.RestoreBackup(ctx, attendanceFor(existing.ID), RestoreModeMerge, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("merge restore: %v", err)
+	}
+	if result.Tables["attendance"].Inserted != 1 {
+		t.Errorf("attendance counts = %+v, want 1 inserted", result.Tables["attendance"])
+	}
+}
+
+func TestMergeRestoreReportsUniqueKeyConflicts(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	clients := NewClientService(repository.NewClientRepository(db), repository.NewAuditRepository(db), time.UTC)
+	existing := createTestClient(t, clients, "Barcode Owner")
+	staff, err := repository.NewStaffRepository(db).CreateWithRole(ctx, "auth0|owner", "Owner", "owner@example.com", model.RoleStaff, nil, nil, &model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create staff: %v", err)
+	}
+
+	now := time.Now().UTC()
+	backup := &Backup{
+		Version: CurrentBackupVersion,
+		Staff: []StaffBackup{
+			// Same row as in the database: an ordinary update
+			{ID: staff.ID, Auth0ID: staff.Auth0ID, Name: "Owner Renamed", Email: staff.Email, Theme: "light", Role: model.RoleStaff, IsActive: true, CreatedAt: now},
+			// A different row reusing the existing email
+			{ID: uuid.New(), Auth0ID: "auth0|other", Name: "Other", Email: staff.Email, Theme: "light", Role: model.RoleStaff, IsActive: true, CreatedAt: now},
+		},
+		Clients: []ClientBackup{
+			{ID: uuid.New(), BarcodeID: existing.BarcodeID, Name: "Barcode Clash", Address: "9 Other St", FamilySize: 1, CreatedAt: now, CreatedBy: model.SystemStaffID},
+		},
+	}
+
+	_, err = NewBackupService(db).RestoreBackup(ctx, backup, RestoreModeMerge, model.SystemStaffID)
+	var conflictErr *BackupConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("err = %v, want a BackupConflictError", err)
+	}
+	got := map[string]string{}
+	for _, issue := range conflictErr.Issues {
+		got[issue.ID] = issue.Table + "." + issue.Field
+	}
+	want := map[string]string{
+		backup.Staff[1].ID.String():   "staff.email",
+		backup.Clients[0].ID.String(): "clients.barcode_id",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("conflicts = %v, want %v", got, want)
+	}
+}
+
+func TestMergeRestoreUpsertsAppointments(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	clientRepo := repository.NewClientRepository(db)
+	clients := NewClientService(clientRepo, repository.NewAuditRepository(db), time.UTC)
+	client := createTestClient(t, clients, "Slot Holder")
+	existing, err := clients.AddAppointment(ctx, client.ID, &model.CreateAppointmentRequest{Day: "Monday"}, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("add appointment: %v", err)
+	}
+
+	backup := &Backup{
+		Version: CurrentBackupVersion,
+		ClientAppointments: []ClientAppointmentBackup{
+			{ID: uuid.New(), ClientID: client.ID, Day: "Friday", IsPrimary: true, CreatedAt: time.Now().UTC()},
+		},
+	}
+	s := NewBackupService(db)
+	result, err := s.RestoreBackup(ctx, backup, RestoreModeMerge, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("merge restore: %v", err)
+	}
+	if got := result.Tables["client_appointments"]; got.Inserted != 1 || got.Updated != 0 {
+		t.Errorf("first restore counts = %+v, want 1 inserted", got)
+	}
+
+	// The restored primary replaces the existing one, which is kept as a
+	// secondary slot
+	appointments, err := clientRepo.ListAppointments(ctx, client.ID)
+	if err != nil {
+		t.Fatalf("list appointments: %v", err)
+	}
+	primary := map[uuid.UUID]bool{}
+	for _, a := range appointments {
+		primary[a.ID] = a.IsPrimary
+	}
+	want := map[uuid.UUID]bool{backup.ClientAppointments[0].ID: true, existing.ID: false}
+	if fmt.Sprint(primary) != fmt.Sprint(want) {
+		t.Errorf("slots = %v, want %v", primary, want)
+	}
+	restored, err := clientRepo.GetByID(ctx, client.ID)
+	if err != nil {
+		t.Fatalf("get client: %v", err)
+	}
+	if restored.AppointmentDay == nil || *restored.AppointmentDay != "Friday" {
+		t.Errorf("appointment_day = %v, want Friday from the restored primary", restored.AppointmentDay)
+	}
+
+	// Restoring the same backup again updates the slot in place
+	result, err = s.RestoreBackup(ctx, backup, RestoreModeMerge, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("second merge restore: %v", err)
+	}
+	if got := result.Tables["client_appointments"]; got.Inserted != 0 || got.Updated != 1 {
+		t.Errorf("second restore counts = %+v, want 1 updated", got)
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+)
+
+// maxBarcodeAttempts bounds how many barcodes are tried before giving up
+const maxBarcodeAttempts = 5
+
+// ErrBarcodeExhausted is returned when every generated barcode collided with
+// an existing client
+var ErrBarcodeExhausted = errors.New("could not generate a unique barcode")
+
+// generateBarcodeID creates a barcode ID in format: FFB-YYYYMM-XXXXX
+// where XXXXX is a random alphanumeric string
+func generateBarcodeID() string {
+	const charset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // Excludes confusable chars: 0,O,1,I
+	b := make([]byte, 5)
+	rand.Read(b)
+	for i := range b {
+		b[i] = charset[int(b[i])%len(charset)]
+	}
+	return fmt.Sprintf("FFB-%s-%s", time.Now().Format("200601"), string(b))
+}
+
+// newBarcodeID is the generator withUniqueBarcode uses; tests replace it to
+// force collisions
+var newBarcodeID = generateBarcodeID
+
+// withUniqueBarcode calls insert with a fresh barcode, generating another
+// whenever the previous one already belongs to a client. insert must leave
+// any transaction usable after a collision (e.g. by using a savepoint).
+func withUniqueBarcode(insert func(barcodeID string) error) error {
+	for attempt := 0; attempt < maxBarcodeAttempts; attempt++ {
+		err := insert(newBarcodeID())
+		if !repository.IsBarcodeCollision(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("%w after %d attempts", ErrBarcodeExhausted, maxBarcodeAttempts)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+)
+
+// forceBarcodes makes the next generated barcodes ids, in order, before
+// falling back to random ones
+func forceBarcodes(t *testing.T, ids ...string) {
+	t.Helper()
+	orig := newBarcodeID
+	t.Cleanup(func() { newBarcodeID = orig })
+	newBarcodeID = func() string {
+		if len(ids) == 0 {
+			return orig()
+		}
+		id := ids[0]
+		ids = ids[1:]
+		return id
+	}
+}
+
+func TestCreateRetriesBarcodeCollision(t *testing.T) {
+	s := newTestClientService(t)
+	existing := createTestClient(t, s, "Barcode Holder")
+
+	forceBarcodes(t, existing.BarcodeID)
+	client := createTestClient(t, s, "Second Client")
+	if client.BarcodeID == existing.BarcodeID || client.BarcodeID == "" {
+		t.Errorf("barcode = %q, want a new one after colliding with %q", client.BarcodeID, existing.BarcodeID)
+	}
+}
+
+func TestImportRetriesBarcodeCollision(t *testing.T) {
+	s, clientRepo := newTestImportService(t, 1)
+	clients := NewClientService(clientRepo, nil, time.UTC)
+	existing := createTestClient(t, clients, "Barcode Holder")
+
+	forceBarcodes(t, existing.BarcodeID, existing.BarcodeID)
+	result, err := s.ImportClients(context.Background(), importRows(2), model.SystemStaffID, 10, false, false)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if result.Imported != 2 || result.Failed != 0 {
+		t.Fatalf("imported %d, failed %d; want both rows imported", result.Imported, result.Failed)
+	}
+	for _, c := range result.ImportedClients {
+		if c.BarcodeID == existing.BarcodeID {
+			t.Errorf("row %d reused the colliding barcode %q", c.Row, c.BarcodeID)
+		}
+	}
+}
+
+func TestWithUniqueBarcodeGivesUp(t *testing.T) {
+	collision := &pgconn.PgError{Code: "23505", ConstraintName: "clients_barcode_id_key"}
+	attempts := 0
+	err := withUniqueBarcode(func(string) error {
+		attempts++
+		return collision
+	})
+	if !errors.Is(err, ErrBarcodeExhausted) {
+		t.Errorf("err = %v, want ErrBarcodeExhausted", err)
+	}
+	if attempts != maxBarcodeAttempts {
+		t.Errorf("tried %d barcodes, want %d", attempts, maxBarcodeAttempts)
+	}
+
+	// Any other error is returned straight away
+	other := errors.New("connection reset")
+	attempts = 0
+	if err := withUniqueBarcode(func(string) error { attempts++; return other }); err != other || attempts != 1 {
+		t.Errorf("err = %v after %d attempts, want the error after 1", err, attempts)
+	}
+}
@@ -1,41 +1,136 @@
 package service
 
 import (
+	"bytes"
 	"context"
-	"crypto/rand"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/finchley-foodbank/foodbank/internal/model"
 	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/validate"
 )
 
+var (
+	ErrInvalidClientData = errors.New("invalid client data")
+	ErrInvalidDateRange  = errors.New("invalid date range")
+	ErrAttendanceTooSoon = errors.New("client attended too recently")
+	// ErrOverrideReasonRequired is returned when a cooldown override has no reason
+	ErrOverrideReasonRequired = errors.New("a reason is required to override the attendance cooldown")
+	ErrDuplicateClient        = errors.New("a client with this name and address already exists")
+)
+
+// DuplicateClientError identifies the existing client that blocked a create
+// in strict duplicate mode. It matches ErrDuplicateClient with errors.Is.
+type DuplicateClientError struct {
+	ExistingID uuid.UUID
+}
+
+func (e *DuplicateClientError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrDuplicateClient, e.ExistingID)
+}
+
+func (e *DuplicateClientError) Unwrap() error {
+	return ErrDuplicateClient
+}
+
+// AttendanceTooSoonError reports when a client next becomes eligible.
+// It matches ErrAttendanceTooSoon with errors.Is.
+type AttendanceTooSoonError struct {
+	LastVisit    time.Time
+	NextEligible time.Time
+}
+
+func (e *AttendanceTooSoonError) Error() string {
+	return fmt.Sprintf("%s: next eligible at %s", ErrAttendanceTooSoon, e.NextEligible.Format(time.RFC3339))
+}
+
+func (e *AttendanceTooSoonError) Unwrap() error {
+	return ErrAttendanceTooSoon
+}
+
+// maxSummaryMonths bounds the attendance summary so gap filling stays small
+const maxSummaryMonths = 120
+
+// maxAttendanceListDays bounds the date range of ListAttendanceByDate
+const maxAttendanceListDays = 31
+
+// attendanceDateLayout is the YYYY-MM-DD format accepted for attendance dates
+const attendanceDateLayout = "2006-01-02"
+
 type ClientService struct {
 	repo      *repository.ClientRepository
 	auditRepo *repository.AuditRepository
+	// attendanceDedupWindow collapses repeat scans of the same client
+	attendanceDedupWindow time.Duration
+	// attendanceCooldown is the minimum time between visits (0 = unlimited)
+	attendanceCooldown time.Duration
+	// requireOverrideReason rejects cooldown overrides without a reason
+	requireOverrideReason bool
+	// location defines local day boundaries for date-based attendance lists
+	location *time.Location
+	// strictDuplicates rejects creating a client whose name+address exists
+	strictDuplicates bool
 }
 
-func NewClientService(repo *repository.ClientRepository, auditRepo *repository.AuditRepository) *ClientService {
-	return &ClientService{repo: repo, auditRepo: auditRepo}
+// NewClientService creates a ClientService whose date-based attendance lists
+// use loc's local days
+func NewClientService(repo *repository.ClientRepository, auditRepo *repository.AuditRepository, loc *time.Location) *ClientService {
+	return &ClientService{repo: repo, auditRepo: auditRepo, location: loc}
 }
 
-// generateBarcodeID creates a unique barcode ID in format: FFB-YYYYMM-XXXXX
-// where XXXXX is a random alphanumeric string
-func generateBarcodeID() string {
-	const charset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // Excludes confusable chars: 0,O,1,I
-	b := make([]byte, 5)
-	rand.Read(b)
-	for i := range b {
-		b[i] = charset[int(b[i])%len(charset)]
-	}
-	return fmt.Sprintf("FFB-%s-%s", time.Now().Format("200601"), string(b))
+// SetStrictDuplicates makes Create reject clients whose normalized name and
+// address match an existing client. By default duplicates are allowed.
+func (s *ClientService) SetStrictDuplicates(strict bool) {
+	s.strictDuplicates = strict
+}
+
+// SetAttendanceCooldown limits how often a client can attend. Zero allows
+// unlimited visits.
+func (s *ClientService) SetAttendanceCooldown(d time.Duration) {
+	s.attendanceCooldown = d
+}
+
+// SetRequireOverrideReason makes cooldown overrides require a reason, which
+// is stored on the attendance row and in the audit entry.
+func (s *ClientService) SetRequireOverrideReason(require bool) {
+	s.requireOverrideReason = require
+}
+
+// SetAttendanceDedupWindow makes RecordAttendance return the existing visit
+// when the same client was recorded within d. Zero disables deduplication.
+func (s *ClientService) SetAttendanceDedupWindow(d time.Duration) {
+	s.attendanceDedupWindow = d
 }
 
 func (s *ClientService) Create(ctx context.Context, req *model.CreateClientRequest, createdBy uuid.UUID) (*model.Client, error) {
-	barcodeID := generateBarcodeID()
-	client, err := s.repo.Create(ctx, req, barcodeID, createdBy)
+	if err := validateClientCreate(req); err != nil {
+		return nil, err
+	}
+	if req.FamilySize == nil {
+		defaultFamilySize := 1
+		req.FamilySize = &defaultFamilySize
+	}
+
+	var client *model.Client
+	err := withUniqueBarcode(func(barcodeID string) error {
+		if !s.strictDuplicates {
+			var err error
+			client, err = s.repo.Create(ctx, req, barcodeID, createdBy)
+			return err
+		}
+		created, existingID, err := s.repo.CreateIfNoDuplicate(ctx, req, barcodeID, createdBy)
+		if err == nil && created == nil {
+			return &DuplicateClientError{ExistingID: existingID}
+		}
+		client = created
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -52,16 +147,201 @@ func (s *ClientService) GetByID(ctx context.Context, id uuid.UUID) (*model.Clien
 	return s.repo.GetByID(ctx, id)
 }
 
+// Per-section limits for GetProfile
+const (
+	profileAttendanceLimit = 10
+	profileAuditLimit      = 20
+)
+
+// GetProfile returns a client with their most recent attendance and audit
+// entries
+func (s *ClientService) GetProfile(ctx context.Context, id uuid.UUID) (*model.ClientProfile, error) {
+	client, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	attendance, err := s.repo.GetAttendanceHistory(ctx, id, model.AttendanceQuery{Limit: profileAttendanceLimit})
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &model.ClientProfile{
+		Client:     client,
+		Attendance: attendance,
+		Audit:      []model.AuditLog{},
+	}
+	if profile.Attendance == nil {
+		profile.Attendance = []model.AttendanceWithDetails{}
+	}
+
+	if s.auditRepo != nil {
+		audit, err := s.auditRepo.GetRecentByRecordID(ctx, "clients", id, profileAuditLimit)
+		if err != nil {
+			return nil, err
+		}
+		if audit != nil {
+			profile.Audit = audit
+		}
+	}
+
+	return profile, nil
+}
+
 func (s *ClientService) GetByBarcodeID(ctx context.Context, barcodeID string) (*model.Client, error) {
-	return s.repo.GetByBarcodeID(ctx, barcodeID)
+	return s.repo.GetByBarcodeID(ctx, normalizeBarcode(barcodeID))
+}
+
+// LookupBarcode reports whether a barcode belongs to an active client
+func (s *ClientService) LookupBarcode(ctx context.Context, barcodeID string) (*model.BarcodeLookupResponse, error) {
+	id, err := s.repo.GetIDByBarcodeID(ctx, normalizeBarcode(barcodeID))
+	if errors.Is(err, repository.ErrClientNotFound) {
+		return &model.BarcodeLookupResponse{Exists: false}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &model.BarcodeLookupResponse{Exists: true, ClientID: &id}, nil
+}
+
+// normalizeBarcode trims whitespace and upper-cases scanned barcodes, which
+// are always generated in upper case
+func normalizeBarcode(barcodeID string) string {
+	return strings.ToUpper(strings.TrimSpace(barcodeID))
+}
+
+// ClientValidationError lists every invalid field in a client create/update.
+// It matches ErrInvalidClientData with errors.Is.
+type ClientValidationError struct {
+	Errors []model.FieldError
+}
+
+func (e *ClientValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidClientData, strings.Join(msgs, "; "))
+}
+
+func (e *ClientValidationError) Unwrap() error {
+	return ErrInvalidClientData
+}
+
+// clientFields holds the validated client fields; nil means "not supplied".
+// A valid appointment day or children ages list is normalized in place.
+type clientFields struct {
+	name, address, appointmentDay, appointmentTime, dietaryNotes, photoURL, childrenAges *string
+	familySize, numChildren                                                              *int
+	consentPhoto                                                                         *bool
+}
+
+// childrenAgesMessage explains the accepted children_ages format
+var childrenAgesMessage = fmt.Sprintf("Children's ages must be whole numbers from 0 to %d separated by commas (e.g. 5, 8)", validate.MaxChildAge)
+
+// photoConsentError is reported when a photo is stored without photo consent
+var photoConsentError = model.FieldError{Field: "photo_url", Message: "Photo consent is required to store a photo"}
+
+func validateClientFields(f clientFields) error {
+	var errs []model.FieldError
+	if f.name != nil && strings.TrimSpace(*f.name) == "" {
+		errs = append(errs, model.FieldError{Field: "name", Message: "Name is required"})
+	}
+	if f.address != nil && strings.TrimSpace(*f.address) == "" {
+		errs = append(errs, model.FieldError{Field: "address", Message: "Address is required"})
+	}
+	if f.familySize != nil && *f.familySize < 1 {
+		errs = append(errs, model.FieldError{Field: "family_size", Message: "Family size must be at least 1"})
+	}
+	if f.numChildren != nil && *f.numChildren < 0 {
+		errs = append(errs, model.FieldError{Field: "num_children", Message: "Number of children cannot be negative"})
+	}
+	if f.childrenAges != nil && *f.childrenAges != "" {
+		if ages, err := validate.ChildrenAges(*f.childrenAges); err != nil {
+			errs = append(errs, model.FieldError{Field: "children_ages", Message: childrenAgesMessage})
+		} else {
+			*f.childrenAges = ages
+		}
+	}
+	if f.appointmentDay != nil && *f.appointmentDay != "" {
+		if day, err := validate.AppointmentDay(*f.appointmentDay); err != nil {
+			errs = append(errs, model.FieldError{Field: "appointment_day", Message: "Invalid day. Must be Monday-Saturday"})
+		} else {
+			*f.appointmentDay = day
+		}
+	}
+	if f.appointmentTime != nil && *f.appointmentTime != "" {
+		if _, err := validate.AppointmentTime(*f.appointmentTime); err != nil {
+			errs = append(errs, model.FieldError{Field: "appointment_time", Message: "Invalid time format. Use HH:MM (e.g., 10:30)"})
+		}
+	}
+	if f.dietaryNotes != nil && len(*f.dietaryNotes) > model.MaxDietaryNotesLength {
+		errs = append(errs, model.FieldError{Field: "dietary_notes", Message: fmt.Sprintf("Dietary notes cannot exceed %d characters", model.MaxDietaryNotesLength)})
+	}
+	if f.photoURL != nil && *f.photoURL != "" && f.consentPhoto != nil && !*f.consentPhoto {
+		errs = append(errs, photoConsentError)
+	}
+	if len(errs) > 0 {
+		return &ClientValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// validateClientCreate checks a new client; name, address and family size are required
+func validateClientCreate(req *model.CreateClientRequest) error {
+	return validateClientFields(clientFields{
+		name:            &req.Name,
+		address:         &req.Address,
+		familySize:      req.FamilySize,
+		numChildren:     &req.NumChildren,
+		childrenAges:    req.ChildrenAges,
+		appointmentDay:  req.AppointmentDay,
+		appointmentTime: req.AppointmentTime,
+		dietaryNotes:    req.DietaryNotes,
+		photoURL:        req.PhotoURL,
+		consentPhoto:    &req.ConsentPhoto,
+	})
+}
+
+// validateClientUpdate rejects updates that would leave a client in an invalid state
+func validateClientUpdate(req *model.UpdateClientRequest) error {
+	return validateClientFields(clientFields{
+		name:            req.Name,
+		address:         req.Address,
+		familySize:      req.FamilySize,
+		numChildren:     req.NumChildren,
+		childrenAges:    req.ChildrenAges,
+		appointmentDay:  req.AppointmentDay,
+		appointmentTime: req.AppointmentTime,
+		dietaryNotes:    req.DietaryNotes,
+		photoURL:        req.PhotoURL,
+		consentPhoto:    req.ConsentPhoto,
+	})
+}
+
+// checkPhotoConsent rejects setting a photo on a client without photo
+// consent when the update itself doesn't change consent (that case is
+// covered by validateClientUpdate)
+func checkPhotoConsent(req *model.UpdateClientRequest, old *model.Client) error {
+	if req.PhotoURL == nil || *req.PhotoURL == "" || req.ConsentPhoto != nil || old.ConsentPhoto {
+		return nil
+	}
+	return &ClientValidationError{Errors: []model.FieldError{photoConsentError}}
 }
 
 func (s *ClientService) Update(ctx context.Context, id uuid.UUID, req *model.UpdateClientRequest, updatedBy uuid.UUID) (*model.Client, error) {
+	if err := validateClientUpdate(req); err != nil {
+		return nil, err
+	}
+
 	// Get old values for audit
 	oldClient, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkPhotoConsent(req, oldClient); err != nil {
+		return nil, err
+	}
 
 	// Perform update
 	client, err := s.repo.Update(ctx, id, req)
@@ -77,6 +357,63 @@ func (s *ClientService) Update(ctx context.Context, id uuid.UUID, req *model.Upd
 	return client, nil
 }
 
+// BatchUpdate applies the same partial update to each client in a single
+// transaction. Missing clients are reported per id; any other failure rolls
+// back the whole batch.
+func (s *ClientService) BatchUpdate(ctx context.Context, ids []uuid.UUID, req *model.UpdateClientRequest, updatedBy uuid.UUID) (*model.BatchUpdateClientsResponse, error) {
+	if err := validateClientUpdate(req); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.repo.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	resp := &model.BatchUpdateClientsResponse{Results: make([]model.BatchUpdateResult, 0, len(ids))}
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		oldClient, err := s.repo.GetByIDTx(ctx, tx, id)
+		if errors.Is(err, repository.ErrClientNotFound) {
+			resp.Results = append(resp.Results, model.BatchUpdateResult{ID: id, Status: "not_found"})
+			resp.NotFound++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPhotoConsent(req, oldClient); err != nil {
+			return nil, err
+		}
+
+		client, err := s.repo.UpdateTx(ctx, tx, id, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.auditRepo != nil {
+			if err := s.auditRepo.LogTx(ctx, tx, "clients", client.ID, "UPDATE", oldClient, client, updatedBy); err != nil {
+				return nil, err
+			}
+		}
+
+		resp.Results = append(resp.Results, model.BatchUpdateResult{ID: id, Status: "updated", Client: client})
+		resp.Updated++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
 func (s *ClientService) Search(ctx context.Context, params *model.ClientSearchParams) ([]model.Client, int, error) {
 	if params.Limit <= 0 {
 		params.Limit = 20
@@ -87,31 +424,406 @@ func (s *ClientService) Search(ctx context.Context, params *model.ClientSearchPa
 	return s.repo.Search(ctx, params)
 }
 
-func (s *ClientService) List(ctx context.Context, limit, offset int) ([]model.Client, int, error) {
+// Count returns how many clients match params (all clients for an empty query)
+func (s *ClientService) Count(ctx context.Context, params *model.ClientSearchParams) (int, error) {
+	return s.repo.Count(ctx, params)
+}
+
+// ExportCSV writes the clients matching params (all clients for an empty
+// query) as a single CSV with a UTF-8 BOM, using the backup column order
+func (s *ClientService) ExportCSV(ctx context.Context, params *model.ClientSearchParams) ([]byte, error) {
+	clients, err := s.repo.ListAll(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	// UTF-8 BOM for Excel compatibility
+	buf.Write([]byte{0xEF, 0xBB, 0xBF})
+	w := csv.NewWriter(&buf)
+
+	w.Write(clientCSVHeader)
+	for i := range clients {
+		c := clientBackupFromModel(&clients[i])
+		w.Write(clientCSVRow(&c))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *ClientService) List(ctx context.Context, limit, offset int, includeArchived bool, sort string) ([]model.Client, int, error) {
 	if limit <= 0 {
 		limit = 20
 	}
 	if limit > 10000 {
 		limit = 10000
 	}
-	return s.repo.List(ctx, limit, offset)
+	return s.repo.List(ctx, limit, offset, includeArchived, sort)
+}
+
+// Archive soft-deletes a client so they no longer appear in default listings
+func (s *ClientService) Archive(ctx context.Context, id, archivedBy uuid.UUID) (*model.Client, error) {
+	oldClient, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if oldClient.ArchivedAt != nil {
+		return oldClient, nil
+	}
+
+	client, err := s.repo.Archive(ctx, id, archivedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditRepo != nil {
+		s.auditRepo.Log(ctx, "clients", client.ID, "ARCHIVE", oldClient, client, archivedBy)
+	}
+
+	return client, nil
+}
+
+// RemovePhoto clears a client's photo, e.g. when photo consent is withdrawn.
+// Photos are stored only as URLs, so there is no stored object to delete.
+// Removing a photo from a client without one is a no-op.
+func (s *ClientService) RemovePhoto(ctx context.Context, id, removedBy uuid.UUID) (*model.Client, error) {
+	oldClient, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if oldClient.PhotoURL == nil {
+		return oldClient, nil
+	}
+
+	client, err := s.repo.ClearPhoto(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditRepo != nil {
+		s.auditRepo.Log(ctx, "clients", client.ID, "PHOTO_REMOVED", oldClient, client, removedBy)
+	}
+
+	return client, nil
+}
+
+// Unarchive restores an archived client
+func (s *ClientService) Unarchive(ctx context.Context, id, unarchivedBy uuid.UUID) (*model.Client, error) {
+	oldClient, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if oldClient.ArchivedAt == nil {
+		return oldClient, nil
+	}
+
+	client, err := s.repo.Unarchive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditRepo != nil {
+		s.auditRepo.Log(ctx, "clients", client.ID, "UNARCHIVE", oldClient, client, unarchivedBy)
+	}
+
+	return client, nil
 }
 
-func (s *ClientService) RecordAttendance(ctx context.Context, clientID, verifiedBy uuid.UUID) (*model.Attendance, error) {
+// RecordAttendance records a visit. created is false when a visit inside the
+// dedup window already existed and was returned instead. A visit inside the
+// cooldown fails with *AttendanceTooSoonError unless override is set, in
+// which case the override is audited.
+func (s *ClientService) RecordAttendance(ctx context.Context, clientID, verifiedBy uuid.UUID, override bool, reason string) (attendance *model.Attendance, created bool, err error) {
+	reason = strings.TrimSpace(reason)
+	if override && reason == "" && s.requireOverrideReason {
+		return nil, false, ErrOverrideReasonRequired
+	}
+
 	// Verify client exists
-	_, err := s.repo.GetByID(ctx, clientID)
+	_, err = s.repo.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	check := s.attendanceCheck()
+	check.Override = override
+	if reason != "" {
+		check.OverrideReason = &reason
+	}
+	tx, err := s.repo.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	out, err := s.repo.RecordAttendanceCheckedTx(ctx, tx, clientID, verifiedBy, check)
+	if err != nil {
+		return nil, false, err
+	}
+	if out.TooSoon {
+		last := out.Nearest.VerifiedAt
+		return nil, false, &AttendanceTooSoonError{LastVisit: last, NextEligible: last.Add(s.attendanceCooldown)}
+	}
+
+	// Audited under the same lock and transaction, so an override can't be
+	// recorded without its audit entry
+	if out.Overridden && s.auditRepo != nil {
+		if err := s.auditRepo.LogTx(ctx, tx, "attendance", out.Attendance.ID, "COOLDOWN_OVERRIDE", out.Nearest, out.Attendance, verifiedBy); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+	return out.Attendance, out.Created, nil
+}
+
+// attendanceCheck returns the dedup and cooldown rules a scan is recorded under
+func (s *ClientService) attendanceCheck() model.AttendanceCheck {
+	return model.AttendanceCheck{DedupWindow: s.attendanceDedupWindow, Cooldown: s.attendanceCooldown}
+}
+
+// RecordAttendanceBulk records a visit for each scanned barcode in a single
+// transaction, under the same per-client lock, dedup window and cooldown as
+// RecordAttendance. Unknown barcodes, clients inside the cooldown and failed
+// inserts are reported per barcode without aborting the rest. A repeat scan,
+// in the request or inside the dedup window, is reported as a duplicate
+// with the existing visit.
+func (s *ClientService) RecordAttendanceBulk(ctx context.Context, barcodes []string, verifiedBy uuid.UUID) (*model.BulkAttendanceResponse, error) {
+	resp := &model.BulkAttendanceResponse{Results: make([]model.BulkAttendanceResult, len(barcodes))}
+
+	var pending []int
+	var clientIDs []uuid.UUID
+	firstSeen := make(map[uuid.UUID]int)
+	var repeats []int
+
+	for i, barcode := range barcodes {
+		res := &resp.Results[i]
+		res.Barcode = barcode
+
+		client, err := s.repo.GetByBarcodeID(ctx, normalizeBarcode(barcode))
+		if errors.Is(err, repository.ErrClientNotFound) {
+			res.Status = "not_found"
+			continue
+		}
+		if err != nil {
+			res.Status = "error"
+			continue
+		}
+		res.ClientID = &client.ID
+
+		if _, ok := firstSeen[client.ID]; ok {
+			repeats = append(repeats, i)
+			continue
+		}
+		firstSeen[client.ID] = i
+		pending = append(pending, i)
+		clientIDs = append(clientIDs, client.ID)
+	}
+
+	if len(clientIDs) > 0 {
+		outcomes, err := s.repo.RecordAttendanceBatch(ctx, clientIDs, verifiedBy, s.attendanceCheck())
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range pending {
+			res := &resp.Results[i]
+			out := outcomes[j]
+			switch {
+			case out == nil:
+				res.Status = "error"
+			case out.TooSoon:
+				res.Status = "too_soon"
+			case out.Duplicate:
+				res.Status = "duplicate"
+				res.Attendance = out.Attendance
+			default:
+				res.Status = "recorded"
+				res.Attendance = out.Attendance
+			}
+		}
+	}
+
+	// Later scans of a client in the same request never record a visit
+	for _, i := range repeats {
+		first := resp.Results[firstSeen[*resp.Results[i].ClientID]]
+		resp.Results[i].Status = "duplicate"
+		resp.Results[i].Attendance = first.Attendance
+	}
+
+	for _, res := range resp.Results {
+		switch res.Status {
+		case "recorded":
+			resp.Recorded++
+		case "duplicate":
+			resp.Duplicates++
+		case "not_found":
+			resp.NotFound++
+		case "too_soon":
+			resp.TooSoon++
+		default:
+			resp.Failed++
+		}
+	}
+
+	return resp, nil
+}
+
+// VoidAttendance voids a visit recorded in error, e.g. after scanning the
+// wrong card. The record is kept but no longer counts towards history,
+// reports or the cooldown. A record that belongs to another client is
+// reported as repository.ErrAttendanceNotFound; voiding twice is a no-op.
+func (s *ClientService) VoidAttendance(ctx context.Context, clientID, attendanceID, voidedBy uuid.UUID) (*model.Attendance, error) {
+	old, err := s.repo.GetAttendance(ctx, attendanceID)
+	if err != nil {
+		return nil, err
+	}
+	if old.ClientID != clientID {
+		return nil, repository.ErrAttendanceNotFound
+	}
+	if old.VoidedAt != nil {
+		return old, nil
+	}
+
+	attendance, err := s.repo.VoidAttendance(ctx, attendanceID, voidedBy)
 	if err != nil {
 		return nil, err
 	}
-	return s.repo.RecordAttendance(ctx, clientID, verifiedBy)
+
+	if s.auditRepo != nil {
+		s.auditRepo.Log(ctx, "attendance", attendance.ID, "VOID", old, attendance, voidedBy)
+	}
+
+	return attendance, nil
 }
 
-func (s *ClientService) GetAttendanceHistory(ctx context.Context, clientID uuid.UUID, limit int) ([]model.AttendanceWithDetails, error) {
-	if limit <= 0 {
-		limit = 10
+// ListAppointments returns a client's appointment slots, primary first
+func (s *ClientService) ListAppointments(ctx context.Context, clientID uuid.UUID) ([]model.ClientAppointment, error) {
+	if _, err := s.repo.GetByID(ctx, clientID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAppointments(ctx, clientID)
+}
+
+// AddAppointment validates and adds an appointment slot for a client
+func (s *ClientService) AddAppointment(ctx context.Context, clientID uuid.UUID, req *model.CreateAppointmentRequest, createdBy uuid.UUID) (*model.ClientAppointment, error) {
+	var errs []model.FieldError
+	day, err := validate.AppointmentDay(req.Day)
+	if err != nil || day == "" {
+		errs = append(errs, model.FieldError{Field: "day", Message: "Invalid day. Must be Monday-Saturday"})
+	}
+	var timeOfDay *string
+	if req.Time != nil && *req.Time != "" {
+		t, err := validate.AppointmentTime(*req.Time)
+		if err != nil {
+			errs = append(errs, model.FieldError{Field: "time", Message: "Invalid time format. Use HH:MM (e.g., 10:30)"})
+		}
+		timeOfDay = &t
+	}
+	if len(errs) > 0 {
+		return nil, &ClientValidationError{Errors: errs}
+	}
+
+	appointment, err := s.repo.AddAppointment(ctx, clientID, day, timeOfDay, req.Primary)
+	if err != nil {
+		return nil, err
 	}
-	if limit > 50 {
+
+	if s.auditRepo != nil {
+		s.auditRepo.Log(ctx, "client_appointments", appointment.ID, "INSERT", nil, appointment, createdBy)
+	}
+
+	return appointment, nil
+}
+
+// DeleteAppointment removes one of a client's appointment slots
+func (s *ClientService) DeleteAppointment(ctx context.Context, clientID, appointmentID, deletedBy uuid.UUID) error {
+	appointment, err := s.repo.DeleteAppointment(ctx, clientID, appointmentID)
+	if err != nil {
+		return err
+	}
+
+	if s.auditRepo != nil {
+		s.auditRepo.Log(ctx, "client_appointments", appointment.ID, "DELETE", appointment, nil, deletedBy)
+	}
+
+	return nil
+}
+
+func (s *ClientService) GetAttendanceHistory(ctx context.Context, clientID uuid.UUID, q model.AttendanceQuery) ([]model.AttendanceWithDetails, error) {
+	if q.Limit <= 0 {
+		q.Limit = 10
+	}
+	if q.Limit > 50 {
+		q.Limit = 50
+	}
+	return s.repo.GetAttendanceHistory(ctx, clientID, q)
+}
+
+// ListAttendanceByDate returns attendance for all clients from the start of
+// fromDate to the end of toDate (YYYY-MM-DD, both inclusive) in the app
+// timezone. An empty fromDate defaults to today and an empty toDate to fromDate.
+func (s *ClientService) ListAttendanceByDate(ctx context.Context, fromDate, toDate string, limit, offset int) ([]model.AttendanceWithDetails, int, error) {
+	from := time.Now().In(s.location)
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, s.location)
+	if fromDate != "" {
+		t, err := time.ParseInLocation(attendanceDateLayout, fromDate, s.location)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: invalid date %q (expected YYYY-MM-DD)", ErrInvalidDateRange, fromDate)
+		}
+		from = t
+	}
+	to := from
+	if toDate != "" {
+		t, err := time.ParseInLocation(attendanceDateLayout, toDate, s.location)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: invalid date %q (expected YYYY-MM-DD)", ErrInvalidDateRange, toDate)
+		}
+		to = t
+	}
+	if to.Before(from) {
+		return nil, 0, fmt.Errorf("%w: to must not be before from", ErrInvalidDateRange)
+	}
+	if from.AddDate(0, 0, maxAttendanceListDays-1).Before(to) {
+		return nil, 0, fmt.Errorf("%w: range cannot exceed %d days", ErrInvalidDateRange, maxAttendanceListDays)
+	}
+
+	if limit <= 0 {
 		limit = 50
 	}
-	return s.repo.GetAttendanceHistory(ctx, clientID, limit)
+	if limit > 200 {
+		limit = 200
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.repo.ListAttendance(ctx, model.AttendanceRangeQuery{
+		From:   from,
+		To:     to.AddDate(0, 0, 1),
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// AttendanceSummaryByMonth returns monthly visit totals between from and to.
+// A zero from defaults to the start of the month eleven months before to,
+// and a zero to defaults to now.
+func (s *ClientService) AttendanceSummaryByMonth(ctx context.Context, from, to time.Time) ([]model.MonthlyAttendance, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, to.Location()).AddDate(0, -11, 0)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrInvalidDateRange)
+	}
+	if from.AddDate(0, maxSummaryMonths, 0).Before(to) {
+		return nil, fmt.Errorf("%w: range cannot exceed %d months", ErrInvalidDateRange, maxSummaryMonths)
+	}
+	return s.repo.AttendanceSummaryByMonth(ctx, from, to)
 }
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+// newTestClientService returns a ClientService backed by a fresh test schema
+func newTestClientService(t *testing.T) *ClientService {
+	t.Helper()
+	db := testdb.Open(t)
+	return NewClientService(repository.NewClientRepository(db), repository.NewAuditRepository(db), time.UTC)
+}
+
+var testClientSeq int
+
+// createTestClient creates a client as the system actor
+func createTestClient(t *testing.T, s *ClientService, name string) *model.Client {
+	t.Helper()
+	testClientSeq++
+	c, err := s.Create(context.Background(), &model.CreateClientRequest{
+		Name:               name,
+		Address:            fmt.Sprintf("%d Test Road", testClientSeq),
+		ConsentDataStorage: true,
+	}, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create client %q: %v", name, err)
+	}
+	return c
+}
+
+func TestRecordAttendanceCooldownUnderConcurrency(t *testing.T) {
+	s := newTestClientService(t)
+	s.SetAttendanceCooldown(time.Hour)
+	client := createTestClient(t, s, "Concurrent Scan")
+
+	const scans = 8
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		created  int
+		tooSoon  int
+		failures []error
+	)
+	for i := 0; i < scans; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := s.RecordAttendance(context.Background(), client.ID, model.SystemStaffID, false, "")
+			mu.Lock()
+			defer mu.Unlock()
+			var soon *AttendanceTooSoonError
+			switch {
+			case errors.As(err, &soon):
+				tooSoon++
+			case err != nil:
+				failures = append(failures, err)
+			case ok:
+				created++
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(failures) > 0 {
+		t.Fatalf("unexpected errors: %v", failures)
+	}
+	if created != 1 || tooSoon != scans-1 {
+		t.Errorf("created %d, too soon %d; want 1 and %d", created, tooSoon, scans-1)
+	}
+}
+
+func TestRecordAttendanceOverrideAndDedup(t *testing.T) {
+	s := newTestClientService(t)
+	s.SetAttendanceDedupWindow(time.Minute)
+	s.SetAttendanceCooldown(time.Hour)
+	client := createTestClient(t, s, "Repeat Scan")
+	ctx := context.Background()
+
+	first, created, err := s.RecordAttendance(ctx, client.ID, model.SystemStaffID, false, "")
+	if err != nil || !created {
+		t.Fatalf("first scan: created=%v err=%v", created, err)
+	}
+
+	// A repeat scan inside the dedup window returns the same visit
+	again, created, err := s.RecordAttendance(ctx, client.ID, model.SystemStaffID, false, "")
+	if err != nil || created || again.ID != first.ID {
+		t.Fatalf("repeat scan: created=%v err=%v id=%v, want existing %v", created, err, again, first.ID)
+	}
+}
+
+func TestRecordAttendanceOverrideIsAudited(t *testing.T) {
+	s := newTestClientService(t)
+	s.SetAttendanceCooldown(time.Hour)
+	client := createTestClient(t, s, "Override Scan")
+	ctx := context.Background()
+
+	if _, _, err := s.RecordAttendance(ctx, client.ID, model.SystemStaffID, false, ""); err != nil {
+		t.Fatalf("first scan: %v", err)
+	}
+	var soon *AttendanceTooSoonError
+	if _, _, err := s.RecordAttendance(ctx, client.ID, model.SystemStaffID, false, ""); !errors.As(err, &soon) {
+		t.Fatalf("second scan err = %v, want AttendanceTooSoonError", err)
+	}
+
+	visit, created, err := s.RecordAttendance(ctx, client.ID, model.SystemStaffID, true, "emergency parcel")
+	if err != nil || !created {
+		t.Fatalf("override scan: created=%v err=%v", created, err)
+	}
+	entries, err := s.auditRepo.GetByRecordID(ctx, "attendance", visit.ID)
+	if err != nil {
+		t.Fatalf("audit lookup: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != "COOLDOWN_OVERRIDE" {
+		t.Errorf("audit entries = %+v, want one COOLDOWN_OVERRIDE", entries)
+	}
+}
+
+func TestRecordAttendanceBulkCountsEachOutcome(t *testing.T) {
+	s := newTestClientService(t)
+	s.SetAttendanceDedupWindow(time.Minute)
+	s.SetAttendanceCooldown(time.Hour)
+	ctx := context.Background()
+
+	fresh := createTestClient(t, s, "Fresh")
+	scanned := createTestClient(t, s, "Already Scanned")
+
+	if _, _, err := s.RecordAttendance(ctx, scanned.ID, model.SystemStaffID, false, ""); err != nil {
+		t.Fatalf("record earlier scan: %v", err)
+	}
+
+	resp, err := s.RecordAttendanceBulk(ctx, []string{
+		fresh.BarcodeID,
+		fresh.BarcodeID,   // repeated in the same request
+		scanned.BarcodeID, // inside the dedup window
+		"UNKNOWN-BARCODE",
+	}, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("bulk: %v", err)
+	}
+
+	wantStatus := []string{"recorded", "duplicate", "duplicate", "not_found"}
+	for i, want := range wantStatus {
+		if got := resp.Results[i].Status; got != want {
+			t.Errorf("result %d status = %q, want %q", i, got, want)
+		}
+	}
+	if resp.Recorded != 1 || resp.Duplicates != 2 || resp.NotFound != 1 || resp.TooSoon != 0 || resp.Failed != 0 {
+		t.Errorf("counts = recorded %d, duplicates %d, not found %d, too soon %d, failed %d; want 1, 2, 1, 0, 0",
+			resp.Recorded, resp.Duplicates, resp.NotFound, resp.TooSoon, resp.Failed)
+	}
+	if resp.Results[1].Attendance == nil || resp.Results[1].Attendance.ID != resp.Results[0].Attendance.ID {
+		t.Error("repeated barcode should report the visit recorded for its first scan")
+	}
+}
+
+func TestRecordAttendanceBulkAppliesCooldown(t *testing.T) {
+	s := newTestClientService(t)
+	s.SetAttendanceCooldown(time.Hour)
+	ctx := context.Background()
+	client := createTestClient(t, s, "Cooldown")
+
+	if _, _, err := s.RecordAttendance(ctx, client.ID, model.SystemStaffID, false, ""); err != nil {
+		t.Fatalf("record earlier scan: %v", err)
+	}
+
+	resp, err := s.RecordAttendanceBulk(ctx, []string{client.BarcodeID}, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("bulk: %v", err)
+	}
+	if resp.Results[0].Status != "too_soon" || resp.TooSoon != 1 || resp.Recorded != 0 {
+		t.Errorf("status %q, too soon %d, recorded %d; want too_soon, 1, 0",
+			resp.Results[0].Status, resp.TooSoon, resp.Recorded)
+	}
+}
+
+func TestCreateStrictDuplicatesUnderConcurrency(t *testing.T) {
+	s := newTestClientService(t)
+	s.SetStrictDuplicates(true)
+
+	const creates = 8
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		created    int
+		duplicates int
+		failures   []error
+	)
+	for i := 0; i < creates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Case and spacing differ, but normalize to the same person
+			name := "Jane Doe"
+			if i%2 == 1 {
+				name = "  JANE DOE "
+			}
+			_, err := s.Create(context.Background(), &model.CreateClientRequest{
+				Name: name, Address: "1 Same Street", ConsentDataStorage: true,
+			}, model.SystemStaffID)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, ErrDuplicateClient):
+				duplicates++
+			case err != nil:
+				failures = append(failures, err)
+			default:
+				created++
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(failures) > 0 {
+		t.Fatalf("unexpected errors: %v", failures)
+	}
+	if created != 1 || duplicates != creates-1 {
+		t.Errorf("created %d, duplicates %d; want 1 and %d", created, duplicates, creates-1)
+	}
+}
+
+func TestValidateClientCreateFamilySize(t *testing.T) {
+	size := func(n int) *int { return &n }
+
+	tests := []struct {
+		name       string
+		familySize *int
+		wantErr    bool
+	}{
+		{"omitted", nil, false},
+		{"one", size(1), false},
+		{"zero", size(0), true},
+		{"negative", size(-2), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateClientCreate(&model.CreateClientRequest{Name: "Jane Doe", Address: "1 High St", FamilySize: tt.familySize})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateDefaultsOmittedFamilySize(t *testing.T) {
+	s := newTestClientService(t)
+	if c := createTestClient(t, s, "No Family Size"); c.FamilySize != 1 {
+		t.Errorf("family size = %d, want 1", c.FamilySize)
+	}
+}
+
+func TestRemovePhotoClearsURLAndAudits(t *testing.T) {
+	s := newTestClientService(t)
+	ctx := context.Background()
+	client, err := s.Create(ctx, &model.CreateClientRequest{
+		Name: "Photo Client", Address: "1 Photo Row", ConsentDataStorage: true,
+		ConsentPhoto: true, PhotoURL: strPtr("https://example.com/photo.jpg"),
+	}, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+
+	cleared, err := s.RemovePhoto(ctx, client.ID, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("remove photo: %v", err)
+	}
+	if cleared.PhotoURL != nil {
+		t.Errorf("photo_url = %q, want it cleared", *cleared.PhotoURL)
+	}
+
+	// Removing it again changes nothing and writes no second entry
+	if _, err := s.RemovePhoto(ctx, client.ID, model.SystemStaffID); err != nil {
+		t.Fatalf("remove photo again: %v", err)
+	}
+	entries, err := s.auditRepo.GetByRecordID(ctx, "clients", client.ID)
+	if err != nil {
+		t.Fatalf("audit lookup: %v", err)
+	}
+	removed := 0
+	for _, e := range entries {
+		if e.Action == "PHOTO_REMOVED" {
+			removed++
+		}
+	}
+	if removed != 1 {
+		t.Errorf("%d PHOTO_REMOVED entries, want 1", removed)
+	}
+
+	if _, err := s.RemovePhoto(ctx, uuid.New(), model.SystemStaffID); !errors.Is(err, repository.ErrClientNotFound) {
+		t.Errorf("unknown client: err = %v, want ErrClientNotFound", err)
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestGetProfileCapsEachSection(t *testing.T) {
+	s := newTestClientService(t)
+	ctx := context.Background()
+
+	// A new client still gets every section, empty rather than null
+	quiet := createTestClient(t, s, "Quiet Client")
+	profile, err := s.GetProfile(ctx, quiet.ID)
+	if err != nil {
+		t.Fatalf("profile: %v", err)
+	}
+	if profile.Client == nil || profile.Attendance == nil || profile.Audit == nil {
+		t.Errorf("profile = %+v, want client, attendance and audit sections", profile)
+	}
+
+	busy := createTestClient(t, s, "Busy Client")
+	for i := 0; i < profileAttendanceLimit+2; i++ {
+		if _, _, err := s.RecordAttendance(ctx, busy.ID, model.SystemStaffID, false, ""); err != nil {
+			t.Fatalf("record attendance %d: %v", i, err)
+		}
+	}
+	for i := 0; i < profileAuditLimit+5; i++ {
+		if err := s.auditRepo.Log(ctx, "clients", busy.ID, "UPDATE", nil, map[string]int{"edit": i}, model.SystemStaffID); err != nil {
+			t.Fatalf("audit %d: %v", i, err)
+		}
+	}
+
+	profile, err = s.GetProfile(ctx, busy.ID)
+	if err != nil {
+		t.Fatalf("profile: %v", err)
+	}
+	if profile.Client.ID != busy.ID {
+		t.Errorf("client = %v, want %v", profile.Client.ID, busy.ID)
+	}
+	if len(profile.Attendance) != profileAttendanceLimit {
+		t.Errorf("%d attendance entries, want %d", len(profile.Attendance), profileAttendanceLimit)
+	}
+	if len(profile.Audit) != profileAuditLimit {
+		t.Errorf("%d audit entries, want %d", len(profile.Audit), profileAuditLimit)
+	}
+}
+
+func TestVoidAttendanceHidesVisit(t *testing.T) {
+	s := newTestClientService(t)
+	ctx := context.Background()
+	client := createTestClient(t, s, "Wrong Scan")
+	other := createTestClient(t, s, "Other Client")
+
+	kept, _, err := s.RecordAttendance(ctx, client.ID, model.SystemStaffID, false, "")
+	if err != nil {
+		t.Fatalf("record attendance: %v", err)
+	}
+	mistake, _, err := s.RecordAttendance(ctx, client.ID, model.SystemStaffID, false, "")
+	if err != nil {
+		t.Fatalf("record attendance: %v", err)
+	}
+
+	// A visit can only be voided through its own client
+	if _, err := s.VoidAttendance(ctx, other.ID, mistake.ID, model.SystemStaffID); !errors.Is(err, repository.ErrAttendanceNotFound) {
+		t.Errorf("void via another client: err = %v, want ErrAttendanceNotFound", err)
+	}
+
+	voided, err := s.VoidAttendance(ctx, client.ID, mistake.ID, model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("void: %v", err)
+	}
+	if voided.VoidedAt == nil {
+		t.Error("voided_at not set")
+	}
+	// Voiding twice is a no-op and is not audited again
+	if _, err := s.VoidAttendance(ctx, client.ID, mistake.ID, model.SystemStaffID); err != nil {
+		t.Errorf("second void: %v", err)
+	}
+
+	history, err := s.GetAttendanceHistory(ctx, client.ID, model.AttendanceQuery{})
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 1 || history[0].ID != kept.ID {
+		t.Errorf("history = %+v, want only the kept visit", history)
+	}
+
+	summary, err := s.AttendanceSummaryByMonth(ctx, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	visits := 0
+	for _, m := range summary {
+		visits += m.Visits
+	}
+	if visits != 1 {
+		t.Errorf("summary counts %d visits, want 1", visits)
+	}
+
+	entries, err := s.auditRepo.GetByRecordID(ctx, "attendance", mistake.ID)
+	if err != nil {
+		t.Fatalf("audit lookup: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != "VOID" {
+		t.Errorf("audit entries = %+v, want one VOID", entries)
+	}
+}
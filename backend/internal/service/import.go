@@ -2,23 +2,28 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
+	"errors"
 	"fmt"
-	"regexp"
+	"runtime"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/finchley-foodbank/foodbank/internal/model"
 	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/validate"
 )
 
 type ImportService struct {
 	db         *pgxpool.Pool
 	clientRepo *repository.ClientRepository
 	auditRepo  *repository.AuditRepository
+	// workers is how many batches import concurrently
+	workers int
+	// strictDuplicates turns duplicate warnings into errors and always skips them
+	strictDuplicates bool
 }
 
 func NewImportService(db *pgxpool.Pool, clientRepo *repository.ClientRepository, auditRepo *repository.AuditRepository) *ImportService {
@@ -26,15 +31,32 @@ func NewImportService(db *pgxpool.Pool, clientRepo *repository.ClientRepository,
 		db:         db,
 		clientRepo: clientRepo,
 		auditRepo:  auditRepo,
+		workers:    1,
 	}
 }
 
-var validAppointmentDays = map[string]bool{
-	"monday": true, "tuesday": true, "wednesday": true,
-	"thursday": true, "friday": true, "saturday": true,
+// SetStrictDuplicates makes validation report rows matching an existing
+// client's name and address as errors, and import always skip them
+func (s *ImportService) SetStrictDuplicates(strict bool) {
+	s.strictDuplicates = strict
 }
 
-var timeRegex = regexp.MustCompile(`^([01]?[0-9]|2[0-3]):([0-5][0-9])$`)
+// SetWorkers sets how many batches ImportClients runs concurrently, each in
+// its own transaction. Zero or less uses runtime.NumCPU(). A worker holds
+// one connection for its whole batch, duplicate lookups included, so the
+// count is capped at half the pool size to leave the rest for other requests.
+func (s *ImportService) SetWorkers(n int) {
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+	if limit := int(s.db.Config().MaxConns) / 2; n > limit {
+		n = limit
+	}
+	if n < 1 {
+		n = 1
+	}
+	s.workers = n
+}
 
 // ValidateRows validates all rows without importing
 func (s *ImportService) ValidateRows(ctx context.Context, rows []model.ImportClientRow) (*model.ValidationResult, error) {
@@ -45,8 +67,9 @@ func (s *ImportService) ValidateRows(ctx context.Context, rows []model.ImportCli
 	}
 
 	validCount := 0
+	repeated := repeatedNameAddress(rows)
 
-	for _, row := range rows {
+	for i, row := range rows {
 		rowValid := true
 
 		// Validate required fields
@@ -89,9 +112,20 @@ func (s *ImportService) ValidateRows(ctx context.Context, rows []model.ImportCli
 		}
 
 		// Validate optional fields
+		if row.ChildrenAges != nil && *row.ChildrenAges != "" {
+			if _, err := validate.ChildrenAges(*row.ChildrenAges); err != nil {
+				result.Errors = append(result.Errors, model.ValidationError{
+					Row:     row.RowNumber,
+					Field:   "children_ages",
+					Message: childrenAgesMessage,
+					Value:   *row.ChildrenAges,
+				})
+				rowValid = false
+			}
+		}
+
 		if row.AppointmentDay != nil && *row.AppointmentDay != "" {
-			day := strings.ToLower(strings.TrimSpace(*row.AppointmentDay))
-			if !validAppointmentDays[day] {
+			if _, err := validate.AppointmentDay(*row.AppointmentDay); err != nil {
 				result.Errors = append(result.Errors, model.ValidationError{
 					Row:     row.RowNumber,
 					Field:   "appointment_day",
@@ -102,8 +136,17 @@ func (s *ImportService) ValidateRows(ctx context.Context, rows []model.ImportCli
 			}
 		}
 
+		if row.DietaryNotes != nil && len(*row.DietaryNotes) > model.MaxDietaryNotesLength {
+			result.Errors = append(result.Errors, model.ValidationError{
+				Row:     row.RowNumber,
+				Field:   "dietary_notes",
+				Message: fmt.Sprintf("Dietary notes cannot exceed %d characters", model.MaxDietaryNotesLength),
+			})
+			rowValid = false
+		}
+
 		if row.AppointmentTime != nil && *row.AppointmentTime != "" {
-			if !timeRegex.MatchString(*row.AppointmentTime) {
+			if _, err := validate.AppointmentTime(*row.AppointmentTime); err != nil {
 				result.Errors = append(result.Errors, model.ValidationError{
 					Row:     row.RowNumber,
 					Field:   "appointment_time",
@@ -114,10 +157,25 @@ func (s *ImportService) ValidateRows(ctx context.Context, rows []model.ImportCli
 			}
 		}
 
-		// Check for duplicates in database
-		if rowValid && strings.TrimSpace(row.Name) != "" && strings.TrimSpace(row.Address) != "" {
+		// Check for duplicates earlier in the file, then in the database
+		if rowValid && repeated[i] && strings.TrimSpace(row.Name) != "" && strings.TrimSpace(row.Address) != "" {
+			message := fmt.Sprintf("'%s' at '%s' appears earlier in this file", row.Name, truncateAddress(row.Address))
+			if s.strictDuplicates {
+				result.Errors = append(result.Errors, model.ValidationError{Row: row.RowNumber, Field: "name", Message: "Duplicate: " + message})
+				rowValid = false
+			} else {
+				result.Warnings = append(result.Warnings, model.ValidationWarning{Row: row.RowNumber, Field: "name", Message: "Potential duplicate: " + message})
+			}
+		} else if rowValid && strings.TrimSpace(row.Name) != "" && strings.TrimSpace(row.Address) != "" {
 			existingID, err := s.findDuplicateClient(ctx, row.Name, row.Address)
-			if err == nil && existingID != uuid.Nil {
+			if err == nil && existingID != uuid.Nil && s.strictDuplicates {
+				result.Errors = append(result.Errors, model.ValidationError{
+					Row:     row.RowNumber,
+					Field:   "name",
+					Message: fmt.Sprintf("Duplicate: '%s' at '%s' already exists (client %s)", row.Name, truncateAddress(row.Address), existingID),
+				})
+				rowValid = false
+			} else if err == nil && existingID != uuid.Nil {
 				result.Warnings = append(result.Warnings, model.ValidationWarning{
 					Row:        row.RowNumber,
 					Field:      "name",
@@ -138,39 +196,70 @@ func (s *ImportService) ValidateRows(ctx context.Context, rows []model.ImportCli
 	return result, nil
 }
 
-// ImportClients imports clients in batches
-func (s *ImportService) ImportClients(ctx context.Context, rows []model.ImportClientRow, staffID uuid.UUID, batchSize int, skipDuplicates bool) (*model.ImportResult, error) {
+// ImportClients imports clients in batches spread across the configured
+// workers. Each batch commits or fails on its own; results are reported in
+// batch order regardless of which finished first. With dryRun every batch is
+// rolled back instead of committed, so the result previews the import
+// (including the barcodes it drew) without persisting anything; a real run
+// afterwards generates fresh barcodes.
+func (s *ImportService) ImportClients(ctx context.Context, rows []model.ImportClientRow, staffID uuid.UUID, batchSize int, skipDuplicates, dryRun bool) (*model.ImportResult, error) {
 	if batchSize <= 0 {
 		batchSize = 50
 	}
 	if batchSize > 100 {
 		batchSize = 100
 	}
+	skipDuplicates = skipDuplicates || s.strictDuplicates
 
 	result := &model.ImportResult{
+		DryRun:          dryRun,
 		Total:           len(rows),
 		Results:         []model.BatchResult{},
 		ImportedClients: []model.ImportedClient{},
 	}
 
-	// Process in batches
-	for i := 0; i < len(rows); i += batchSize {
-		end := i + batchSize
-		if end > len(rows) {
-			end = len(rows)
-		}
+	// Later copies of a name and address in the file are skipped up front,
+	// since batches run concurrently and can't see each other's inserts
+	var repeated []bool
+	if skipDuplicates {
+		repeated = repeatedNameAddress(rows)
+	}
 
-		batch := rows[i:end]
-		batchNum := (i / batchSize) + 1
+	numBatches := (len(rows) + batchSize - 1) / batchSize
+	batchResults := make([]model.BatchResult, numBatches)
+	batchImported := make([][]model.ImportedClient, numBatches)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(s.workers, numBatches); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				start := b * batchSize
+				end := min(start+batchSize, len(rows))
+				var batchRepeated []bool
+				if repeated != nil {
+					batchRepeated = repeated[start:end]
+				}
+				batchResults[b], batchImported[b] = s.importBatch(ctx, rows[start:end], batchRepeated, staffID, skipDuplicates, dryRun, b+1, start+1, end)
+			}
+		}()
+	}
+	for b := 0; b < numBatches; b++ {
+		jobs <- b
+	}
+	close(jobs)
+	wg.Wait()
 
-		batchResult := s.importBatch(ctx, batch, staffID, skipDuplicates, batchNum, i+1, end)
+	for b, batchResult := range batchResults {
 		result.Results = append(result.Results, batchResult)
 		result.Imported += batchResult.Success
 		result.Skipped += batchResult.Skipped
 		result.Failed += batchResult.Failed
 
 		// Collect imported clients from this batch
-		// Note: We'll need to track this in importBatch
+		result.ImportedClients = append(result.ImportedClients, batchImported[b]...)
 	}
 
 	result.Success = result.Failed == 0
@@ -178,51 +267,118 @@ func (s *ImportService) ImportClients(ctx context.Context, rows []model.ImportCl
 	return result, nil
 }
 
-func (s *ImportService) importBatch(ctx context.Context, rows []model.ImportClientRow, staffID uuid.UUID, skipDuplicates bool, batchNum, start, end int) model.BatchResult {
+// repeatedNameAddress marks each row whose name and address, compared as
+// FindByNameAddress does, appeared in an earlier row
+func repeatedNameAddress(rows []model.ImportClientRow) []bool {
+	repeated := make([]bool, len(rows))
+	seen := make(map[[2]string]bool, len(rows))
+	for i, row := range rows {
+		key := [2]string{strings.ToLower(strings.TrimSpace(row.Name)), strings.ToLower(strings.TrimSpace(row.Address))}
+		repeated[i] = seen[key]
+		seen[key] = true
+	}
+	return repeated
+}
+
+// importBatch inserts a batch of rows in a single transaction and returns the
+// batch summary along with the clients that were successfully inserted.
+// Rows marked in repeated are skipped as duplicates of earlier rows in the
+// file. A dry run does all the same work and then rolls the transaction back.
+func (s *ImportService) importBatch(ctx context.Context, rows []model.ImportClientRow, repeated []bool, staffID uuid.UUID, skipDuplicates, dryRun bool, batchNum, start, end int) (model.BatchResult, []model.ImportedClient) {
 	result := model.BatchResult{
 		Batch: batchNum,
 		Start: start,
 		End:   end,
 	}
+	imported := []model.ImportedClient{}
 
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
 		result.Error = fmt.Sprintf("Failed to begin transaction: %v", err)
 		result.Failed = len(rows)
-		return result
+		return result, nil
 	}
 	defer tx.Rollback(ctx)
 
-	for _, row := range rows {
-		// Check for duplicates if skip mode is enabled
+	// Hold each row's name and address lock until commit, so a concurrent
+	// strict create or import can't add the same client after our lookup
+	if skipDuplicates {
+		pairs := make([][2]string, len(rows))
+		for i, row := range rows {
+			pairs[i] = [2]string{row.Name, row.Address}
+		}
+		if err := s.clientRepo.LockNameAddressesTx(ctx, tx, pairs); err != nil {
+			result.Error = fmt.Sprintf("Failed to lock duplicate keys: %v", err)
+			result.Failed = len(rows)
+			return result, nil
+		}
+	}
+
+	for i, row := range rows {
+		// Use the original CSV row number when provided, otherwise the position in the upload
+		rowNumber := row.RowNumber
+		if rowNumber == 0 {
+			rowNumber = start + i
+		}
+
+		// Check for duplicates if skip mode is enabled. The lookup runs on the
+		// batch's own transaction so a worker never needs a second connection.
 		if skipDuplicates {
-			existingID, _ := s.findDuplicateClient(ctx, row.Name, row.Address)
-			if existingID != uuid.Nil {
+			if repeated != nil && repeated[i] {
 				result.Skipped++
 				continue
 			}
+			_, err := s.clientRepo.FindByNameAddressTx(ctx, tx, row.Name, row.Address)
+			if err == nil {
+				result.Skipped++
+				continue
+			}
+			if !errors.Is(err, repository.ErrClientNotFound) {
+				result.Failed++
+				continue
+			}
 		}
 
-		// Generate barcode
-		barcodeID := generateClientBarcodeID()
-
 		// Insert client
 		query := `
 			INSERT INTO clients (barcode_id, name, address, family_size, num_children, children_ages,
 			                     reason, photo_url, appointment_day, appointment_time,
-			                     pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking, created_by)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			                     pref_gluten_free, pref_halal, pref_vegetarian, pref_no_cooking,
+			                     dietary_notes, created_by,
+			                     consent_data_storage, consent_photo, consent_recorded_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+			        $17, $18, CASE WHEN $17 OR $18 THEN NOW() END)
 			RETURNING id`
 
+		name := strings.TrimSpace(row.Name)
+
+		// Each attempt runs in a savepoint so a failed insert doesn't abort the batch
 		var clientID uuid.UUID
-		err := tx.QueryRow(ctx, query,
-			barcodeID, strings.TrimSpace(row.Name), strings.TrimSpace(row.Address),
-			row.FamilySize, row.NumChildren, row.ChildrenAges,
-			row.Reason, nil, // photo_url is always nil for imports
-			normalizeAppointmentDay(row.AppointmentDay), row.AppointmentTime,
-			row.PrefGlutenFree, row.PrefHalal, row.PrefVegetarian, row.PrefNoCooking,
-			staffID,
-		).Scan(&clientID)
+		var barcodeID string
+		err := withUniqueBarcode(func(candidate string) error {
+			sp, err := tx.Begin(ctx)
+			if err != nil {
+				return err
+			}
+			err = sp.QueryRow(ctx, query,
+				candidate, name, strings.TrimSpace(row.Address),
+				row.FamilySize, row.NumChildren, normalizeChildrenAges(row.ChildrenAges),
+				row.Reason, nil, // photo_url is always nil for imports
+				normalizeAppointmentDay(row.AppointmentDay), row.AppointmentTime,
+				row.PrefGlutenFree, row.PrefHalal, row.PrefVegetarian, row.PrefNoCooking,
+				row.DietaryNotes, staffID,
+				row.ConsentDataStorage, row.ConsentPhoto,
+			).Scan(&clientID)
+			if err == nil && row.AppointmentDay != nil {
+				err = s.clientRepo.SyncPrimaryAppointmentTx(ctx, sp, clientID)
+			}
+			if err != nil {
+				sp.Rollback(ctx)
+				return err
+			}
+			barcodeID = candidate
+			return sp.Commit(ctx)
+		})
 
 		if err != nil {
 			result.Failed++
@@ -230,6 +386,17 @@ func (s *ImportService) importBatch(ctx context.Context, rows []model.ImportClie
 		}
 
 		result.Success++
+		imported = append(imported, model.ImportedClient{
+			Row:       rowNumber,
+			ID:        clientID,
+			BarcodeID: barcodeID,
+			Name:      name,
+		})
+	}
+
+	if dryRun {
+		// The deferred Rollback discards everything this batch inserted
+		return result, imported
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -237,38 +404,39 @@ func (s *ImportService) importBatch(ctx context.Context, rows []model.ImportClie
 		result.Failed = len(rows)
 		result.Success = 0
 		result.Skipped = 0
-		return result
+		return result, nil
 	}
 
-	return result
+	return result, imported
 }
 
 // findDuplicateClient checks if a client with the same name and address exists
 func (s *ImportService) findDuplicateClient(ctx context.Context, name, address string) (uuid.UUID, error) {
-	query := `
-		SELECT id FROM clients
-		WHERE LOWER(TRIM(name)) = LOWER(TRIM($1))
-		  AND LOWER(TRIM(address)) = LOWER(TRIM($2))
-		LIMIT 1`
-
-	var id uuid.UUID
-	err := s.db.QueryRow(ctx, query, name, address).Scan(&id)
-	if err != nil {
-		return uuid.Nil, err
-	}
-	return id, nil
+	return s.clientRepo.FindByNameAddress(ctx, name, address)
 }
 
 // normalizeAppointmentDay capitalizes the first letter
 func normalizeAppointmentDay(day *string) *string {
-	if day == nil || *day == "" {
+	if day == nil {
 		return nil
 	}
-	d := strings.TrimSpace(*day)
-	if d == "" {
+	normalized, err := validate.AppointmentDay(*day)
+	if err != nil || normalized == "" {
 		return nil
 	}
-	normalized := strings.Title(strings.ToLower(d))
+	return &normalized
+}
+
+// normalizeChildrenAges puts a valid ages list in canonical form, leaving
+// anything else as given
+func normalizeChildrenAges(ages *string) *string {
+	if ages == nil {
+		return nil
+	}
+	normalized, err := validate.ChildrenAges(*ages)
+	if err != nil {
+		return ages
+	}
 	return &normalized
 }
 
@@ -282,20 +450,9 @@ func truncateAddress(addr string) string {
 
 // GenerateCSVTemplate returns a CSV template with headers and example rows
 func (s *ImportService) GenerateCSVTemplate() string {
-	return `name,address,family_size,num_children,children_ages,reason,appointment_day,appointment_time,pref_gluten_free,pref_halal,pref_vegetarian,pref_no_cooking
-"John Smith","123 High Street, London N12 0AB",4,2,"5, 8","Referred by GP",Tuesday,10:30,false,false,false,false
-"Jane Doe","45 Park Road, Barnet EN5 1AA",2,0,"","Job loss",Thursday,14:00,false,true,false,false
-"Bob Wilson","78 Church Lane, Finchley N3 2PQ",3,1,"3","Financial hardship",Monday,09:00,true,false,false,false
+	return `name,address,family_size,num_children,children_ages,reason,appointment_day,appointment_time,pref_gluten_free,pref_halal,pref_vegetarian,pref_no_cooking,dietary_notes,consent_data_storage,consent_photo
+"John Smith","123 High Street, London N12 0AB",4,2,"5, 8","Referred by GP",Tuesday,10:30,false,false,false,false,"Nut allergy",true,false
+"Jane Doe","45 Park Road, Barnet EN5 1AA",2,0,"","Job loss",Thursday,14:00,false,true,false,false,"",true,true
+"Bob Wilson","78 Church Lane, Finchley N3 2PQ",3,1,"3","Financial hardship",Monday,09:00,true,false,false,false,"",true,false
 `
 }
-
-// generateClientBarcodeID creates a unique barcode ID for client import
-func generateClientBarcodeID() string {
-	const charset = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
-	b := make([]byte, 5)
-	rand.Read(b)
-	for i := range b {
-		b[i] = charset[int(b[i])%len(charset)]
-	}
-	return fmt.Sprintf("FFB-%s-%s", time.Now().Format("200601"), string(b))
-}
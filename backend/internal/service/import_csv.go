@@ -0,0 +1,228 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+)
+
+// ErrInvalidCSV is returned when an uploaded file can't be read as a client
+// import at all (missing header, unknown or missing columns)
+var ErrInvalidCSV = errors.New("invalid CSV")
+
+// importRequiredColumns must appear in the header of an import file
+var importRequiredColumns = []string{"name", "address", "family_size", "num_children"}
+
+// importOptionalColumns may appear in the header of an import file
+var importOptionalColumns = []string{
+	"children_ages", "reason", "appointment_day", "appointment_time",
+	"pref_gluten_free", "pref_halal", "pref_vegetarian", "pref_no_cooking",
+	"dietary_notes", "consent_data_storage", "consent_photo",
+}
+
+// utf8BOM is the byte order mark Excel writes at the start of UTF-8 CSVs
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// skipBOM drops a leading UTF-8 BOM. It has to go before csv.NewReader sees
+// the input: a BOM in front of a quoted field makes the quote look like it
+// appears mid-field, so the first header fails to parse.
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if start, _ := br.Peek(len(utf8BOM)); bytes.Equal(start, utf8BOM) {
+		br.Discard(len(utf8BOM))
+	}
+	return br
+}
+
+// ParseCSV reads an import file into rows, mapping columns by header name so
+// their order doesn't matter. A leading UTF-8 BOM is ignored. Rows that can't
+// be parsed are left out and reported as validation errors carrying the row
+// number and file line. RowNumber counts data rows from 1, as the frontend does.
+func (s *ImportService) ParseCSV(r io.Reader) ([]model.ImportClientRow, []model.ValidationError, error) {
+	reader := csv.NewReader(skipBOM(r))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("%w: file is empty", ErrInvalidCSV)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: read header: %v", ErrInvalidCSV, err)
+	}
+
+	columns, err := mapImportColumns(header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []model.ImportClientRow
+	var rowErrs []model.ValidationError
+	for rowNum := 1; ; rowNum++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			rowErrs = append(rowErrs, model.ValidationError{
+				Row:     rowNum,
+				Message: fmt.Sprintf("Malformed CSV on line %d: %v", perr.StartLine, perr.Err),
+			})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(header) {
+			rowErrs = append(rowErrs, model.ValidationError{
+				Row:     rowNum,
+				Message: fmt.Sprintf("Line %d has %d fields, expected %d", line, len(record), len(header)),
+			})
+			continue
+		}
+
+		row, fieldErrs := parseImportRecord(record, columns, rowNum, line)
+		if len(fieldErrs) > 0 {
+			rowErrs = append(rowErrs, fieldErrs...)
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrs, nil
+}
+
+// parseImportBool reads a spreadsheet yes/no cell, ignoring case and
+// surrounding spaces. Accepted values:
+//
+//	true:  true, t, yes, y, 1, x, on
+//	false: false, f, no, n, 0, off, and a blank cell
+//
+// Anything else is an error so typos aren't silently imported as false.
+func parseImportBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "t", "yes", "y", "1", "x", "on":
+		return true, nil
+	case "", "false", "f", "no", "n", "0", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", s)
+}
+
+// mapImportColumns returns the record index of each known column name
+func mapImportColumns(header []string) (map[string]int, error) {
+	known := make(map[string]bool, len(importRequiredColumns)+len(importOptionalColumns))
+	for _, c := range importRequiredColumns {
+		known[c] = true
+	}
+	for _, c := range importOptionalColumns {
+		known[c] = true
+	}
+
+	columns := make(map[string]int, len(header))
+	var unknown []string
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(h))
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			unknown = append(unknown, name)
+			continue
+		}
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("%w: duplicate column %s", ErrInvalidCSV, name)
+		}
+		columns[name] = i
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: unknown columns: %s", ErrInvalidCSV, strings.Join(unknown, ", "))
+	}
+
+	var missing []string
+	for _, c := range importRequiredColumns {
+		if _, ok := columns[c]; !ok {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing required columns: %s", ErrInvalidCSV, strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// parseImportRecord converts one CSV record into an import row, reporting
+// fields that aren't valid numbers or booleans
+func parseImportRecord(record []string, columns map[string]int, rowNum, line int) (model.ImportClientRow, []model.ValidationError) {
+	row := model.ImportClientRow{RowNumber: rowNum}
+	var errs []model.ValidationError
+
+	get := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	optional := func(name string) *string {
+		if v := get(name); v != "" {
+			return &v
+		}
+		return nil
+	}
+	integer := func(name string) int {
+		v := get(name)
+		if v == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, model.ValidationError{
+				Row:     rowNum,
+				Field:   name,
+				Message: fmt.Sprintf("Line %d: must be a whole number", line),
+				Value:   v,
+			})
+		}
+		return n
+	}
+	boolean := func(name string) bool {
+		b, err := parseImportBool(get(name))
+		if err != nil {
+			errs = append(errs, model.ValidationError{
+				Row:     rowNum,
+				Field:   name,
+				Message: fmt.Sprintf("Line %d: must be yes or no (true/false, y/n, 1/0 or x)", line),
+				Value:   get(name),
+			})
+		}
+		return b
+	}
+
+	row.Name = get("name")
+	row.Address = get("address")
+	row.FamilySize = integer("family_size")
+	row.NumChildren = integer("num_children")
+	row.ChildrenAges = optional("children_ages")
+	row.Reason = optional("reason")
+	row.AppointmentDay = optional("appointment_day")
+	row.AppointmentTime = optional("appointment_time")
+	row.PrefGlutenFree = boolean("pref_gluten_free")
+	row.PrefHalal = boolean("pref_halal")
+	row.PrefVegetarian = boolean("pref_vegetarian")
+	row.PrefNoCooking = boolean("pref_no_cooking")
+	row.DietaryNotes = optional("dietary_notes")
+	row.ConsentDataStorage = boolean("consent_data_storage")
+	row.ConsentPhoto = boolean("consent_photo")
+
+	return row, errs
+}
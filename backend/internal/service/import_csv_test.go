@@ -0,0 +1,98 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCSVStripsBOM(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"plain header", "\ufeffname,address,family_size,num_children\nJane Doe,1 High St,2,0\n"},
+		{"quoted first header", "\ufeff\"name\",\"address\",family_size,num_children\nJane Doe,1 High St,2,0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, rowErrs, err := (&ImportService{}).ParseCSV(strings.NewReader(tt.csv))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if len(rowErrs) != 0 || len(rows) != 1 || rows[0].Name != "Jane Doe" {
+				t.Errorf("rows %+v, errors %+v; want Jane Doe and no errors", rows, rowErrs)
+			}
+		})
+	}
+}
+
+func TestParseAttendanceImportStripsBOM(t *testing.T) {
+	lines, err := (&ClientService{}).parseAttendanceImport(strings.NewReader("\ufeff\"barcode\"\nFB0001\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(lines) != 1 || lines[0].barcode != "FB0001" || lines[0].err != "" {
+		t.Errorf("lines = %+v, want only FB0001", lines)
+	}
+}
+
+func TestParseImportBool(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    bool
+		wantErr bool
+	}{
+		{in: "true", want: true},
+		{in: "TRUE", want: true},
+		{in: "t", want: true},
+		{in: "Yes", want: true},
+		{in: "y", want: true},
+		{in: "1", want: true},
+		{in: "x", want: true},
+		{in: "X", want: true},
+		{in: " on ", want: true},
+		{in: "false"},
+		{in: "F"},
+		{in: "No"},
+		{in: "n"},
+		{in: "0"},
+		{in: "off"},
+		{in: ""},
+		{in: "  "},
+		{in: "maybe", wantErr: true},
+		{in: "yes please", wantErr: true},
+		{in: "2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseImportBool(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseImportBool(%q) = %v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseImportBool(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSVReportsInvalidBooleans(t *testing.T) {
+	csv := "name,address,family_size,num_children,pref_halal,consent_photo\n" +
+		"Jane Doe,1 High St,2,0,Yes,x\n" +
+		"John Doe,2 High St,1,0,sometimes,\n"
+	rows, rowErrs, err := (&ImportService{}).ParseCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(rows) != 1 || !rows[0].PrefHalal || !rows[0].ConsentPhoto {
+		t.Errorf("rows = %+v, want Jane Doe with halal and photo consent", rows)
+	}
+	if len(rowErrs) != 1 || rowErrs[0].Row != 2 || rowErrs[0].Field != "pref_halal" || rowErrs[0].Value != "sometimes" {
+		t.Errorf("errors = %+v, want row 2 pref_halal %q", rowErrs, "sometimes")
+	}
+}
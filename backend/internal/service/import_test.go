@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+// newTestImportService returns an ImportService with the given number of
+// workers, backed by a fresh test schema
+func newTestImportService(tb testing.TB, workers int) (*ImportService, *repository.ClientRepository) {
+	tb.Helper()
+	db := testdb.Open(tb)
+	clientRepo := repository.NewClientRepository(db)
+	s := NewImportService(db, clientRepo, repository.NewAuditRepository(db))
+	s.SetWorkers(workers)
+	return s, clientRepo
+}
+
+// importRows returns n distinct, valid rows
+func importRows(n int) []model.ImportClientRow {
+	rows := make([]model.ImportClientRow, n)
+	for i := range rows {
+		rows[i] = model.ImportClientRow{
+			RowNumber:          i + 2,
+			Name:               fmt.Sprintf("Import Client %d", i),
+			Address:            fmt.Sprintf("%d Import Street", i),
+			FamilySize:         1 + i%5,
+			ConsentDataStorage: true,
+		}
+	}
+	return rows
+}
+
+func TestRepeatedNameAddress(t *testing.T) {
+	rows := []model.ImportClientRow{
+		{Name: "Jane Doe", Address: "1 High St"},
+		{Name: "John Doe", Address: "1 High St"},
+		{Name: " jane doe ", Address: "1 HIGH ST"},
+		{Name: "Jane Doe", Address: "2 High St"},
+	}
+	want := []bool{false, false, true, false}
+	if got := repeatedNameAddress(rows); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("repeatedNameAddress = %v, want %v", got, want)
+	}
+}
+
+func TestImportClients500Rows(t *testing.T) {
+	s, clientRepo := newTestImportService(t, 4)
+	ctx := context.Background()
+
+	rows := importRows(500)
+	// Copies of the first row in later batches, which run concurrently
+	for i := 100; i < 500; i += 100 {
+		rows[i].Name = "IMPORT CLIENT 0"
+		rows[i].Address = " 0 import street "
+	}
+
+	result, err := s.ImportClients(ctx, rows, model.SystemStaffID, 50, true, false)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if result.Imported != 496 || result.Skipped != 4 || result.Failed != 0 {
+		t.Fatalf("imported %d, skipped %d, failed %d; want 496, 4, 0", result.Imported, result.Skipped, result.Failed)
+	}
+
+	barcodes := make(map[string]bool)
+	for _, c := range result.ImportedClients {
+		if barcodes[c.BarcodeID] {
+			t.Errorf("barcode %s issued twice", c.BarcodeID)
+		}
+		barcodes[c.BarcodeID] = true
+	}
+
+	count, err := clientRepo.Count(ctx, &model.ClientSearchParams{})
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 496 {
+		t.Errorf("stored %d clients, want 496", count)
+	}
+}
+
+func TestImportDryRunPersistsNothing(t *testing.T) {
+	s, clientRepo := newTestImportService(t, 2)
+	ctx := context.Background()
+
+	result, err := s.ImportClients(ctx, importRows(25), model.SystemStaffID, 10, false, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !result.DryRun || result.Imported != 25 || result.Failed != 0 {
+		t.Errorf("dry run %v imported %d failed %d; want a dry run reporting 25 imported", result.DryRun, result.Imported, result.Failed)
+	}
+	if len(result.ImportedClients) != 25 || result.ImportedClients[0].BarcodeID == "" {
+		t.Errorf("preview has %d clients, want 25 with their barcodes", len(result.ImportedClients))
+	}
+
+	count, err := clientRepo.Count(ctx, &model.ClientSearchParams{})
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("dry run stored %d clients, want none", count)
+	}
+}
+
+func BenchmarkImportClients(b *testing.B) {
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			s, _ := newTestImportService(b, workers)
+			rows := importRows(500)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// A dry run rolls back, so every iteration imports the same rows
+				if _, err := s.ImportClients(context.Background(), rows, model.SystemStaffID, 50, true, true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRowsStrictRejectsRepeatsInFile(t *testing.T) {
+	s, _ := newTestImportService(t, 1)
+	s.SetStrictDuplicates(true)
+
+	rows := importRows(3)
+	rows[2].Name = rows[0].Name
+	rows[2].Address = rows[0].Address
+
+	result, err := s.ValidateRows(context.Background(), rows)
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if result.Valid || result.ValidRows != 2 || len(result.Errors) != 1 || result.Errors[0].Row != rows[2].RowNumber {
+		t.Errorf("valid %v, valid rows %d, errors %+v; want the repeated row rejected", result.Valid, result.ValidRows, result.Errors)
+	}
+}
+
+func TestImportClientsStrictSkipsExistingAndRepeated(t *testing.T) {
+	s, _ := newTestImportService(t, 2)
+	s.SetStrictDuplicates(true)
+	ctx := context.Background()
+
+	rows := importRows(4)
+	if _, err := s.ImportClients(ctx, rows[:1], model.SystemStaffID, 50, false, false); err != nil {
+		t.Fatalf("seed import: %v", err)
+	}
+
+	// rows[0] now exists; rows[3] repeats rows[1] in another batch
+	rows[3].Name = rows[1].Name
+	rows[3].Address = rows[1].Address
+	result, err := s.ImportClients(ctx, rows, model.SystemStaffID, 2, false, false)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if result.Imported != 2 || result.Skipped != 2 || result.Failed != 0 {
+		t.Errorf("imported %d, skipped %d, failed %d; want 2, 2, 0", result.Imported, result.Skipped, result.Failed)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+)
+
+// verificationCodeRetention is how long expired verification codes are kept
+// before cleanup, so recent failures can still be investigated
+const verificationCodeRetention = 24 * time.Hour
+
+// registrationRequestRetention is how long a pending request is kept after
+// its approval link expires, so an admin can still regenerate the link
+const registrationRequestRetention = 30 * 24 * time.Hour
+
+// emailLogRetention is how long delivery records are kept; they hold
+// recipient addresses, so they aren't kept for longer than troubleshooting needs
+const emailLogRetention = 90 * 24 * time.Hour
+
+// CleanupResult reports how many rows were deleted from each table
+type CleanupResult struct {
+	VerificationCodes    int64 `json:"verification_codes"`
+	RegistrationRequests int64 `json:"registration_requests"`
+	EmailLog             int64 `json:"email_log"`
+}
+
+type MaintenanceService struct {
+	verificationRepo        *repository.VerificationRepository
+	registrationRequestRepo *repository.RegistrationRequestRepository
+	emailLogRepo            *repository.EmailLogRepository
+}
+
+func NewMaintenanceService(verificationRepo *repository.VerificationRepository, registrationRequestRepo *repository.RegistrationRequestRepository, emailLogRepo *repository.EmailLogRepository) *MaintenanceService {
+	return &MaintenanceService{
+		verificationRepo:        verificationRepo,
+		registrationRequestRepo: registrationRequestRepo,
+		emailLogRepo:            emailLogRepo,
+	}
+}
+
+// Cleanup deletes expired verification codes, pending registration requests
+// whose approval link expired more than the retention period ago, and email
+// log entries past their retention
+func (s *MaintenanceService) Cleanup(ctx context.Context) (*CleanupResult, error) {
+	codes, err := s.verificationRepo.DeleteExpired(ctx, time.Now().Add(-verificationCodeRetention))
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := s.registrationRequestRepo.DeleteExpiredPending(ctx, time.Now().Add(-registrationRequestRetention))
+	if err != nil {
+		return nil, err
+	}
+
+	emails, err := s.emailLogRepo.DeleteOlderThan(ctx, time.Now().Add(-emailLogRetention))
+	if err != nil {
+		return nil, err
+	}
+
+	return &CleanupResult{VerificationCodes: codes, RegistrationRequests: requests, EmailLog: emails}, nil
+}
+
+// Run performs Cleanup every interval until ctx is cancelled
+func (s *MaintenanceService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := s.Cleanup(ctx)
+			if err != nil {
+				log.Printf("Maintenance cleanup failed: %v", err)
+				continue
+			}
+			if result.VerificationCodes > 0 || result.RegistrationRequests > 0 || result.EmailLog > 0 {
+				log.Printf("Maintenance cleanup removed %d verification codes, %d registration requests, %d email log entries",
+					result.VerificationCodes, result.RegistrationRequests, result.EmailLog)
+			}
+		}
+	}
+}
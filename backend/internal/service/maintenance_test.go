@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+func TestCleanupPurgesEmailLogPastRetention(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	emailLogRepo := repository.NewEmailLogRepository(db)
+	maintenance := NewMaintenanceService(repository.NewVerificationRepository(db), repository.NewRegistrationRequestRepository(db), emailLogRepo)
+
+	for _, recipient := range []string{"old@example.com", "recent@example.com"} {
+		entry := &model.EmailLog{Recipient: recipient, MessageType: "test", Status: model.EmailStatusSent, Attempts: 1}
+		if err := emailLogRepo.Create(ctx, entry); err != nil {
+			t.Fatalf("create entry: %v", err)
+		}
+	}
+	if _, err := db.Exec(ctx, `UPDATE email_log SET created_at = $1 WHERE recipient = 'old@example.com'`,
+		time.Now().Add(-emailLogRetention-time.Hour)); err != nil {
+		t.Fatalf("backdate entry: %v", err)
+	}
+
+	result, err := maintenance.Cleanup(ctx)
+	if err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if result.EmailLog != 1 {
+		t.Errorf("removed %d email log entries, want 1", result.EmailLog)
+	}
+	entries, _, err := emailLogRepo.List(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Recipient != "recent@example.com" {
+		t.Errorf("remaining entries = %+v, want only the recent one", entries)
+	}
+}
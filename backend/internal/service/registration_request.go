@@ -1,10 +1,13 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +15,7 @@ import (
 	"github.com/finchley-foodbank/foodbank/internal/auth0"
 	"github.com/finchley-foodbank/foodbank/internal/email"
 	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/redact"
 	"github.com/finchley-foodbank/foodbank/internal/repository"
 )
 
@@ -20,29 +24,64 @@ var (
 	ErrStaffAlreadyExists   = errors.New("a staff member with this email already exists")
 	ErrTokenExpired         = errors.New("approval token has expired")
 	ErrRequestNotPending    = errors.New("request is not pending")
+	ErrInvalidRequestStatus = errors.New("invalid registration request status")
+	ErrRequestStillPending  = errors.New("request has not been reviewed yet")
+	ErrNoDecisionEmail      = errors.New("rejected applicants are not sent a decision email")
 )
 
+// DefaultRegistrationTokenTTL is how long approval links stay valid unless configured
+const DefaultRegistrationTokenTTL = 7 * 24 * time.Hour
+
 type RegistrationRequestService struct {
 	repo         *repository.RegistrationRequestRepository
 	staffRepo    *repository.StaffRepository
+	auditRepo    *repository.AuditRepository
 	auth0Client  *auth0.Client
 	emailService *email.Service
+	tokenTTL     time.Duration
+
+	// staff re-sends invitations for approved requests, with the same
+	// guards and audit entry as the staff page's resend
+	staff *StaffService
+
+	// notifyMu guards notifyClosed; notifyWG tracks in-flight admin notifications
+	notifyMu     sync.Mutex
+	notifyWG     sync.WaitGroup
+	notifyClosed bool
 }
 
 func NewRegistrationRequestService(
 	repo *repository.RegistrationRequestRepository,
 	staffRepo *repository.StaffRepository,
+	auditRepo *repository.AuditRepository,
 	auth0Client *auth0.Client,
 	emailService *email.Service,
 ) *RegistrationRequestService {
 	return &RegistrationRequestService{
 		repo:         repo,
 		staffRepo:    staffRepo,
+		auditRepo:    auditRepo,
 		auth0Client:  auth0Client,
 		emailService: emailService,
+		tokenTTL:     DefaultRegistrationTokenTTL,
+		staff:        NewStaffService(staffRepo, auditRepo, auth0Client),
+	}
+}
+
+// SetTokenTTL sets how long approval links stay valid. Non-positive values
+// keep the default.
+func (s *RegistrationRequestService) SetTokenTTL(d time.Duration) {
+	if d > 0 {
+		s.tokenTTL = d
 	}
 }
 
+// NotificationsEnabled reports whether admins are emailed about new requests.
+// When false, requests are only visible by checking the admin dashboard.
+func (s *RegistrationRequestService) NotificationsEnabled() bool {
+	return s.emailService != nil && s.emailService.IsConfigured()
+}
+
 // Submit creates a new registration request and sends notifications to admins
 func (s *RegistrationRequestService) Submit(ctx context.Context, req model.CreateRegistrationRequestRequest) (*model.RegistrationRequest, error) {
 	// Check if there's already a pending request for this email
@@ -64,23 +103,70 @@ func (s *RegistrationRequestService) Submit(ctx context.Context, req model.Creat
 	}
 
 	// Create the registration request
-	request, err := s.repo.Create(ctx, req.Name, req.Email, req.Mobile, req.Address)
+	request, err := s.repo.Create(ctx, req.Name, req.Email, req.Mobile, req.Address, s.tokenTTL)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	// Send admin notifications (async, don't block on failure)
-	go s.notifyAdmins(request)
+	s.goNotifyAdmins(request)
 
 	return request, nil
 }
 
+// goNotifyAdmins notifies admins in the background so the caller doesn't
+// wait on email delivery. Once Shutdown has begun no new notifications are
+// started; the request is still visible in the admin dashboard.
+func (s *RegistrationRequestService) goNotifyAdmins(request *model.RegistrationRequest) {
+	if !s.goTracked(func() { s.notifyAdmins(request) }) {
+		log.Printf("WARNING: Shutting down, not notifying admins about registration request %s", request.ID)
+	}
+}
+
+// goTracked runs fn in a goroutine that Shutdown waits for. It reports false,
+// without running fn, once Shutdown has begun.
+func (s *RegistrationRequestService) goTracked(fn func()) bool {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+	if s.notifyClosed {
+		return false
+	}
+
+	s.notifyWG.Add(1)
+	go func() {
+		defer s.notifyWG.Done()
+		fn()
+	}()
+	return true
+}
+
+// Shutdown stops new admin notifications and waits for in-flight ones to
+// finish, returning ctx's error if it is done first
+func (s *RegistrationRequestService) Shutdown(ctx context.Context) error {
+	s.notifyMu.Lock()
+	s.notifyClosed = true
+	s.notifyMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.notifyWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // notifyAdmins sends email notifications to all admin users
 func (s *RegistrationRequestService) notifyAdmins(request *model.RegistrationRequest) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	log.Printf("Notifying admins of new registration request from %s (%s)", request.Name, request.Email)
+	log.Printf("Notifying admins of new registration request from %s (%s)", request.Name, redact.Email(request.Email))
 
 	// Get all admin emails
 	admins, err := s.staffRepo.ListAdminEmails(ctx)
@@ -94,20 +180,20 @@ func (s *RegistrationRequestService) notifyAdmins(request *model.RegistrationReq
 		return
 	}
 
-	log.Printf("Found %d admin(s) to notify: %v", len(admins), admins)
+	log.Printf("Found %d admin(s) to notify: %v", len(admins), redact.Emails(admins))
 
-	if s.emailService == nil {
-		log.Printf("WARNING: Email service not configured, skipping admin notifications")
+	if !s.NotificationsEnabled() {
+		log.Printf("WARNING: Registration notifications disabled (email service not configured); request %s is only visible in the admin dashboard", request.ID)
 		return
 	}
 
-	failures := s.emailService.SendAdminNotification(admins, request)
+	failures := s.emailService.SendAdminNotification(admins, request, s.tokenTTL)
 	if failures == 0 {
-		log.Printf("Successfully sent admin notifications for registration request from %s", request.Email)
+		log.Printf("Successfully sent admin notifications for registration request from %s", redact.Email(request.Email))
 	} else if failures < len(admins) {
-		log.Printf("Partially sent admin notifications for %s (%d/%d failed)", request.Email, failures, len(admins))
+		log.Printf("Partially sent admin notifications for %s (%d/%d failed)", redact.Email(request.Email), failures, len(admins))
 	} else {
-		log.Printf("ERROR: Failed to send all admin notifications for %s", request.Email)
+		log.Printf("ERROR: Failed to send all admin notifications for %s", redact.Email(request.Email))
 	}
 }
 
@@ -133,7 +219,9 @@ func (s *RegistrationRequestService) GetByToken(ctx context.Context, token strin
 	return response, nil
 }
 
-// ApproveByToken approves a registration request using the token (email link flow)
+// ApproveByToken approves a registration request using the token (email
+// link flow). There is no signed-in reviewer, so the approval is attributed
+// to model.SystemStaffID.
 func (s *RegistrationRequestService) ApproveByToken(ctx context.Context, token string) (*model.Staff, error) {
 	request, err := s.repo.GetByToken(ctx, token)
 	if err != nil {
@@ -148,7 +236,7 @@ func (s *RegistrationRequestService) ApproveByToken(ctx context.Context, token s
 		return nil, ErrTokenExpired
 	}
 
-	return s.approveRequest(ctx, request, nil)
+	return s.approveRequest(ctx, request, model.SystemStaffID)
 }
 
 // ApproveByID approves a registration request by ID (admin dashboard flow)
@@ -162,48 +250,44 @@ func (s *RegistrationRequestService) ApproveByID(ctx context.Context, id uuid.UU
 		return nil, ErrRequestNotPending
 	}
 
-	return s.approveRequest(ctx, request, &reviewedBy)
+	return s.approveRequest(ctx, request, reviewedBy)
 }
 
 // approveRequest handles the actual approval logic
-func (s *RegistrationRequestService) approveRequest(ctx context.Context, request *model.RegistrationRequest, reviewedBy *uuid.UUID) (*model.Staff, error) {
+func (s *RegistrationRequestService) approveRequest(ctx context.Context, request *model.RegistrationRequest, reviewedBy uuid.UUID) (*model.Staff, error) {
 	// Check if Auth0 client is configured
 	if s.auth0Client == nil || !s.auth0Client.IsConfigured() {
 		return nil, ErrAuth0NotConfigured
 	}
 
 	// Create user in Auth0
-	auth0User, err := s.auth0Client.CreateUser(request.Email, request.Name)
+	appMetadata := map[string]interface{}{"role": model.RoleStaff}
+	if reviewedBy != model.SystemStaffID {
+		appMetadata["invited_by"] = reviewedBy.String()
+	}
+	auth0User, err := s.auth0Client.CreateUser(ctx, request.Email, request.Name, appMetadata)
 	if err != nil {
 		return nil, fmt.Errorf("create Auth0 user: %w", err)
 	}
 
 	// Create local staff record with 'staff' role
-	var staff *model.Staff
-	if reviewedBy != nil {
-		staff, err = s.staffRepo.CreateWithRole(ctx, auth0User.UserID, request.Name, request.Email, model.RoleStaff, request.Mobile, request.Address, reviewedBy)
-	} else {
-		staff, err = s.staffRepo.Create(ctx, auth0User.UserID, request.Name, request.Email, request.Mobile, request.Address, nil)
-	}
+	staff, err := s.staffRepo.CreateWithRole(ctx, auth0User.UserID, request.Name, request.Email, model.RoleStaff, request.Mobile, request.Address, &reviewedBy)
 	if err != nil {
-		// TODO: Consider rolling back Auth0 user creation on failure
+		// Roll back the Auth0 user so the request can be approved again
+		if delErr := s.auth0Client.DeleteUser(context.WithoutCancel(ctx), auth0User.UserID); delErr != nil {
+			log.Printf("ERROR: Failed to roll back Auth0 user %s after staff insert failure: %v", auth0User.UserID, delErr)
+		}
 		return nil, fmt.Errorf("create staff record: %w", err)
 	}
 
 	// Mark the request as approved
-	if reviewedBy != nil {
-		err = s.repo.Approve(ctx, request.ID, *reviewedBy)
-	} else {
-		// For token-based approval, we don't have a reviewer ID
-		// Update the request directly
-		err = s.repo.ApproveWithoutReviewer(ctx, request.ID)
-	}
-	if err != nil {
+	if err := s.repo.Approve(ctx, request.ID, reviewedBy); err != nil {
 		return nil, fmt.Errorf("mark request approved: %w", err)
 	}
+	s.logReview(ctx, request, model.RequestStatusApproved, reviewedBy)
 
 	// Send password set email (invitation)
-	_, err = s.auth0Client.SendPasswordSetEmail(auth0User.UserID)
+	_, err = s.auth0Client.SendPasswordSetEmail(ctx, auth0User.UserID)
 	if err != nil {
 		// User is created but invitation failed - they can request password reset
 		// Don't fail the whole operation
@@ -212,7 +296,8 @@ func (s *RegistrationRequestService) approveRequest(ctx context.Context, request
 	return staff, nil
 }
 
-// RejectByToken rejects a registration request using the token (email link flow)
+// RejectByToken rejects a registration request using the token (email link
+// flow), attributed to model.SystemStaffID
 func (s *RegistrationRequestService) RejectByToken(ctx context.Context, token string) error {
 	request, err := s.repo.GetByToken(ctx, token)
 	if err != nil {
@@ -227,7 +312,7 @@ func (s *RegistrationRequestService) RejectByToken(ctx context.Context, token st
 		return ErrTokenExpired
 	}
 
-	return s.repo.RejectWithoutReviewer(ctx, request.ID)
+	return s.reject(ctx, request, model.SystemStaffID)
 }
 
 // RejectByID rejects a registration request by ID (admin dashboard flow)
@@ -241,12 +326,138 @@ func (s *RegistrationRequestService) RejectByID(ctx context.Context, id uuid.UUI
 		return ErrRequestNotPending
 	}
 
-	return s.repo.Reject(ctx, id, reviewedBy)
+	return s.reject(ctx, request, reviewedBy)
+}
+
+func (s *RegistrationRequestService) reject(ctx context.Context, request *model.RegistrationRequest, reviewedBy uuid.UUID) error {
+	if err := s.repo.Reject(ctx, request.ID, reviewedBy); err != nil {
+		return err
+	}
+	s.logReview(ctx, request, model.RequestStatusRejected, reviewedBy)
+	return nil
+}
+
+// logReview records an approval or rejection in the audit log
+func (s *RegistrationRequestService) logReview(ctx context.Context, request *model.RegistrationRequest, status string, reviewedBy uuid.UUID) {
+	if s.auditRepo == nil {
+		return
+	}
+	reviewed := *request
+	now := time.Now()
+	reviewed.Status = status
+	reviewed.ReviewedAt = &now
+	reviewed.ReviewedBy = &reviewedBy
+	action := "APPROVE"
+	if status == model.RequestStatusRejected {
+		action = "REJECT"
+	}
+	if err := s.auditRepo.Log(ctx, "registration_requests", request.ID, action, request, &reviewed, reviewedBy); err != nil {
+		log.Printf("Failed to audit registration %s of %s: %v", status, request.ID, err)
+	}
 }
 
-// ListPending returns all pending registration requests
-func (s *RegistrationRequestService) ListPending(ctx context.Context) ([]model.RegistrationRequest, error) {
-	return s.repo.ListPending(ctx)
+// ResendApproval issues a fresh approval token for a pending request and
+// re-sends the admin notification with the new links
+func (s *RegistrationRequestService) ResendApproval(ctx context.Context, id uuid.UUID) (*model.RegistrationRequest, error) {
+	request, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Status != model.RequestStatusPending {
+		return nil, ErrRequestNotPending
+	}
+
+	request, err = s.repo.RegenerateToken(ctx, id, s.tokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	s.goNotifyAdmins(request)
+
+	return request, nil
+}
+
+// ResendDecision re-sends the applicant's decision email for a reviewed
+// request and returns the new ticket URL. Approved applicants get a fresh
+// invitation through StaffService.ResendInvite, so deactivated or already
+// verified staff are refused and the resend is audited; rejected applicants
+// are never emailed.
+func (s *RegistrationRequestService) ResendDecision(ctx context.Context, id, sentBy uuid.UUID) (string, error) {
+	request, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	switch request.Status {
+	case model.RequestStatusPending:
+		return "", ErrRequestStillPending
+	case model.RequestStatusRejected:
+		return "", ErrNoDecisionEmail
+	}
+
+	staff, err := s.staffRepo.GetByEmail(ctx, request.Email)
+	if err != nil {
+		return "", fmt.Errorf("look up approved staff: %w", err)
+	}
+	return s.staff.ResendInvite(ctx, staff.ID, sentBy)
+}
+
+// List returns a page of registration requests filtered by status ("" for all)
+func (s *RegistrationRequestService) List(ctx context.Context, status string, limit, offset int) ([]model.RegistrationRequest, int, error) {
+	if !validRequestStatus(status) {
+		return nil, 0, ErrInvalidRequestStatus
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.repo.List(ctx, status, limit, offset)
+}
+
+// ExportCSV returns registration requests with the given status ("" for all)
+// as CSV with a UTF-8 BOM. Approval tokens are never included.
+func (s *RegistrationRequestService) ExportCSV(ctx context.Context, status string) ([]byte, error) {
+	if !validRequestStatus(status) {
+		return nil, ErrInvalidRequestStatus
+	}
+
+	requests, err := s.repo.ListByStatus(ctx, status)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	// UTF-8 BOM for Excel compatibility
+	buf.Write([]byte{0xEF, 0xBB, 0xBF})
+
+	cw := csv.NewWriter(&buf)
+	cw.Write([]string{"name", "email", "mobile", "address", "status", "created_at"})
+	for _, r := range requests {
+		cw.Write([]string{
+			r.Name, r.Email, ptrToString(r.Mobile), ptrToString(r.Address),
+			r.Status, r.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// validRequestStatus reports whether status is a known request status or empty
+func validRequestStatus(status string) bool {
+	switch status {
+	case "", model.RequestStatusPending, model.RequestStatusApproved, model.RequestStatusRejected:
+		return true
+	}
+	return false
 }
 
 // CountPending returns the count of pending requests
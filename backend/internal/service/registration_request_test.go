@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/finchley-foodbank/foodbank/internal/auth0"
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+func TestResendApprovalRevivesExpiredRequestAfterCleanup(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	requestRepo := repository.NewRegistrationRequestRepository(db)
+	svc := NewRegistrationRequestService(requestRepo, repository.NewStaffRepository(db), repository.NewAuditRepository(db), nil, nil)
+	t.Cleanup(func() { svc.Shutdown(context.Background()) })
+	maintenance := NewMaintenanceService(repository.NewVerificationRepository(db), requestRepo, repository.NewEmailLogRepository(db))
+
+	expired, err := requestRepo.Create(ctx, "Late Applicant", "late@example.com", nil, nil, -time.Hour)
+	if err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+
+	// The hourly cleanup must leave a recently expired request in place
+	if _, err := maintenance.Cleanup(ctx); err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+
+	renewed, err := svc.ResendApproval(ctx, expired.ID)
+	if err != nil {
+		t.Fatalf("resend approval: %v", err)
+	}
+	if renewed.ApprovalToken == expired.ApprovalToken {
+		t.Error("approval token was not regenerated")
+	}
+	if !renewed.TokenExpiresAt.After(time.Now()) {
+		t.Errorf("token expires at %v, want a future time", renewed.TokenExpiresAt)
+	}
+}
+
+func TestCleanupPurgesRequestsPastRetention(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	requestRepo := repository.NewRegistrationRequestRepository(db)
+	maintenance := NewMaintenanceService(repository.NewVerificationRepository(db), requestRepo, repository.NewEmailLogRepository(db))
+
+	stale, err := requestRepo.Create(ctx, "Stale Applicant", "stale@example.com", nil, nil, -registrationRequestRetention-time.Hour)
+	if err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+
+	result, err := maintenance.Cleanup(ctx)
+	if err != nil {
+		t.Fatalf("cleanup: %v", err)
+	}
+	if result.RegistrationRequests != 1 {
+		t.Errorf("removed %d registration requests, want 1", result.RegistrationRequests)
+	}
+	if _, err := requestRepo.GetByID(ctx, stale.ID); err != repository.ErrRegistrationRequestNotFound {
+		t.Errorf("GetByID after cleanup: err = %v, want ErrRegistrationRequestNotFound", err)
+	}
+}
+
+func TestResendDecisionGuards(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	requestRepo := repository.NewRegistrationRequestRepository(db)
+	staffRepo := repository.NewStaffRepository(db)
+	// Configured but never reached: every case is refused before Auth0 is called
+	auth0Client := auth0.NewClient("auth0.invalid", "client", "secret", "connection")
+	svc := NewRegistrationRequestService(requestRepo, staffRepo, repository.NewAuditRepository(db), auth0Client, nil)
+	t.Cleanup(func() { svc.Shutdown(context.Background()) })
+
+	tests := []struct {
+		name  string
+		setup func(req *model.RegistrationRequest, staff *model.Staff) error
+		want  error
+	}{
+		{"pending", func(req *model.RegistrationRequest, staff *model.Staff) error {
+			return nil
+		}, ErrRequestStillPending},
+		{"rejected", func(req *model.RegistrationRequest, staff *model.Staff) error {
+			return requestRepo.Reject(ctx, req.ID, model.SystemStaffID)
+		}, ErrNoDecisionEmail},
+		{"approved, already verified", func(req *model.RegistrationRequest, staff *model.Staff) error {
+			if err := requestRepo.Approve(ctx, req.ID, model.SystemStaffID); err != nil {
+				return err
+			}
+			return staffRepo.SetEmailVerified(ctx, staff.ID)
+		}, ErrStaffAlreadyVerified},
+		{"approved, deactivated", func(req *model.RegistrationRequest, staff *model.Staff) error {
+			if err := requestRepo.Approve(ctx, req.ID, model.SystemStaffID); err != nil {
+				return err
+			}
+			return staffRepo.Deactivate(ctx, staff.ID, model.SystemStaffID)
+		}, ErrStaffInactive},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := fmt.Sprintf("applicant%d@example.com", i)
+			req, err := requestRepo.Create(ctx, "Applicant", email, nil, nil, time.Hour)
+			if err != nil {
+				t.Fatalf("create request: %v", err)
+			}
+			staff, err := staffRepo.CreateWithRole(ctx, fmt.Sprintf("auth0|applicant%d", i), "Applicant", email, model.RoleStaff, nil, nil, &model.SystemStaffID)
+			if err != nil {
+				t.Fatalf("create staff: %v", err)
+			}
+			if err := tt.setup(req, staff); err != nil {
+				t.Fatalf("setup: %v", err)
+			}
+
+			if _, err := svc.ResendDecision(ctx, req.ID, model.SystemStaffID); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// newFakeAuth0 returns an Auth0 client talking to a test server that accepts
+// every user it is asked to create
+func newFakeAuth0(t *testing.T) *auth0.Client {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/oauth/token":
+			w.Write([]byte(`{"access_token":"test-token","expires_in":86400}`))
+		case "/api/v2/users":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"user_id":"auth0|approved","email":"approved@example.com"}`))
+		case "/api/v2/tickets/password-change":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"ticket":"https://example.com/ticket"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := auth0.NewClient(strings.TrimPrefix(srv.URL, "https://"), "client", "secret", "connection")
+	client.SetHTTPClient(srv.Client())
+	return client
+}
+
+func TestApproveByTokenAuditsAsSystemActor(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	requestRepo := repository.NewRegistrationRequestRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	svc := NewRegistrationRequestService(requestRepo, repository.NewStaffRepository(db), auditRepo, newFakeAuth0(t), nil)
+	t.Cleanup(func() { svc.Shutdown(context.Background()) })
+
+	request, err := requestRepo.Create(ctx, "Approved Applicant", "approved@example.com", nil, nil, time.Hour)
+	if err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+	staff, err := svc.ApproveByToken(ctx, request.ApprovalToken)
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if staff.CreatedBy == nil || *staff.CreatedBy != model.SystemStaffID {
+		t.Errorf("staff created_by = %v, want the system actor", staff.CreatedBy)
+	}
+
+	entries, err := auditRepo.GetByRecordID(ctx, "registration_requests", request.ID)
+	if err != nil {
+		t.Fatalf("audit lookup: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != "APPROVE" || entries[0].ChangedBy != model.SystemStaffID {
+		t.Errorf("audit entries = %+v, want one APPROVE by the system actor", entries)
+	}
+}
+
+func TestShutdownWaitsForPendingNotifications(t *testing.T) {
+	s := &RegistrationRequestService{}
+	release := make(chan struct{})
+	delivered := false
+	if !s.goTracked(func() {
+		<-release
+		delivered = true
+	}) {
+		t.Fatal("notification refused before shutdown")
+	}
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- s.Shutdown(context.Background()) }()
+
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("shutdown returned %v with a notification still pending", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-shutdownErr; err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if !delivered {
+		t.Error("shutdown returned before the notification finished")
+	}
+
+	if s.goTracked(func() { t.Error("notification ran after shutdown") }) {
+		t.Error("notification accepted after shutdown began")
+	}
+}
+
+func TestShutdownGivesUpAtDeadline(t *testing.T) {
+	s := &RegistrationRequestService{}
+	release := make(chan struct{})
+	defer close(release)
+	s.goTracked(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the deadline", err)
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"time"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+)
+
+// maxHeatmapRange bounds the heatmap query window
+const maxHeatmapRange = 366 * 24 * time.Hour
+
+// ReportService builds aggregate reports for operational planning
+type ReportService struct {
+	clientRepo *repository.ClientRepository
+	location   *time.Location
+}
+
+func NewReportService(clientRepo *repository.ClientRepository, loc *time.Location) *ReportService {
+	return &ReportService{
+		clientRepo: clientRepo,
+		location:   loc,
+	}
+}
+
+// AttendanceHeatmap returns visit counts for every weekday/hour slot between
+// from and to, bucketed in the app timezone. A zero to defaults to now and a
+// zero from defaults to 90 days before to.
+func (s *ReportService) AttendanceHeatmap(ctx context.Context, from, to time.Time) (*model.AttendanceHeatmap, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -90)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrInvalidDateRange)
+	}
+	if to.Sub(from) > maxHeatmapRange {
+		return nil, fmt.Errorf("%w: range cannot exceed 366 days", ErrInvalidDateRange)
+	}
+
+	counts, err := s.clientRepo.AttendanceByDayHour(ctx, from, to, s.location.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill every slot so the grid renders without gaps
+	cells := make([]model.HeatmapCell, 0, 7*24)
+	for dow := 0; dow < 7; dow++ {
+		for hour := 0; hour < 24; hour++ {
+			cells = append(cells, model.HeatmapCell{DayOfWeek: dow, Hour: hour})
+		}
+	}
+	for _, c := range counts {
+		if c.DayOfWeek < 0 || c.DayOfWeek > 6 || c.Hour < 0 || c.Hour > 23 {
+			continue
+		}
+		cells[c.DayOfWeek*24+c.Hour].Visits = c.Visits
+	}
+
+	return &model.AttendanceHeatmap{
+		From:     from,
+		To:       to,
+		Timezone: s.location.String(),
+		Cells:    cells,
+	}, nil
+}
+
+// ExportReport builds a ZIP with clients.csv (reporting columns plus visit
+// count and last visit) and attendance.csv. Zero from/to leave the range
+// open on that side; both files only count visits inside the range.
+func (s *ReportService) ExportReport(ctx context.Context, from, to time.Time) ([]byte, error) {
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrInvalidDateRange)
+	}
+	var fromPtr, toPtr *time.Time
+	if !from.IsZero() {
+		fromPtr = &from
+	}
+	if !to.IsZero() {
+		toPtr = &to
+	}
+
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+
+	// UTF-8 BOM for Excel compatibility
+	bom := []byte{0xEF, 0xBB, 0xBF}
+
+	if err := s.writeClientReportCSV(ctx, zipWriter, bom, fromPtr, toPtr); err != nil {
+		return nil, err
+	}
+	if err := s.writeAttendanceReportCSV(ctx, zipWriter, bom, fromPtr, toPtr); err != nil {
+		return nil, err
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close zip: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *ReportService) writeClientReportCSV(ctx context.Context, zw *zip.Writer, bom []byte, from, to *time.Time) error {
+	summaries, err := s.clientRepo.ClientVisitSummaries(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	f, err := zw.Create("clients.csv")
+	if err != nil {
+		return err
+	}
+	f.Write(bom)
+	w := csv.NewWriter(f)
+
+	w.Write([]string{"id", "barcode_id", "name", "address", "family_size", "num_children",
+		"appointment_day", "appointment_time", "pref_gluten_free", "pref_halal",
+		"pref_vegetarian", "pref_no_cooking", "created_at", "archived_at",
+		"visit_count", "last_visit"})
+
+	for _, c := range summaries {
+		w.Write([]string{
+			c.ID.String(), c.BarcodeID, c.Name, c.Address,
+			fmt.Sprintf("%d", c.FamilySize), fmt.Sprintf("%d", c.NumChildren),
+			ptrToString(c.AppointmentDay), ptrToString(c.AppointmentTime),
+			boolToString(c.PrefGlutenFree), boolToString(c.PrefHalal),
+			boolToString(c.PrefVegetarian), boolToString(c.PrefNoCooking),
+			c.CreatedAt.Format(time.RFC3339), timeToString(c.ArchivedAt),
+			fmt.Sprintf("%d", c.VisitCount), timeToString(c.LastVisit),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func (s *ReportService) writeAttendanceReportCSV(ctx context.Context, zw *zip.Writer, bom []byte, from, to *time.Time) error {
+	visits, err := s.clientRepo.AttendanceForReport(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	f, err := zw.Create("attendance.csv")
+	if err != nil {
+		return err
+	}
+	f.Write(bom)
+	w := csv.NewWriter(f)
+
+	w.Write([]string{"id", "client_id", "barcode_id", "client_name", "verified_by_name", "verified_at"})
+
+	for _, v := range visits {
+		w.Write([]string{
+			v.ID.String(), v.ClientID.String(), v.BarcodeID, v.ClientName, v.VerifiedByName,
+			v.VerifiedAt.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 
 	"github.com/google/uuid"
 
@@ -18,62 +19,62 @@ var (
 	ErrCannotDeactivateLastAdmin = errors.New("cannot deactivate the last admin")
 	ErrInvalidRole              = errors.New("invalid role: must be 'admin' or 'staff'")
 	ErrAuth0NotConfigured       = errors.New("auth0 management API not configured")
+	ErrAuth0Unavailable         = auth0.ErrUnavailable
+	ErrStaffAlreadyVerified     = errors.New("staff member has already verified their account")
+	ErrStaffInactive            = errors.New("staff member is deactivated")
+	ErrStaffEmailExists         = errors.New("a staff member with this email already exists")
+	ErrCannotUnverifySelf       = errors.New("cannot clear your own email verification while verification is required")
 )
 
 type StaffService struct {
 	repo        *repository.StaffRepository
+	auditRepo   *repository.AuditRepository
 	auth0Client *auth0.Client
+	// requireEmailVerification mirrors whether RequireVerifiedEmail is in use
+	requireEmailVerification bool
 }
 
-func NewStaffService(repo *repository.StaffRepository, auth0Client *auth0.Client) *StaffService {
+func NewStaffService(repo *repository.StaffRepository, auditRepo *repository.AuditRepository, auth0Client *auth0.Client) *StaffService {
 	return &StaffService{
 		repo:        repo,
+		auditRepo:   auditRepo,
 		auth0Client: auth0Client,
 	}
 }
 
-// FindOrCreate finds a staff member by Auth0 ID, or creates one if they don't exist.
-// Used for auto-registration on first login.
-// Also updates name/email if they were empty and are now available from Auth0.
-func (s *StaffService) FindOrCreate(ctx context.Context, auth0ID, name, email string) (*model.Staff, bool, error) {
-	staff, err := s.repo.GetByAuth0ID(ctx, auth0ID)
-	if err == nil {
-		// Staff exists - check if we should update name/email from Auth0
-		needsUpdate := false
-		updatedName := staff.Name
-		updatedEmail := staff.Email
-
-		// Update name if it was empty or is the same as email (placeholder)
-		if name != "" && (staff.Name == "" || staff.Name == staff.Email) {
-			updatedName = name
-			needsUpdate = true
-		}
-
-		// Update email if it was empty
-		if email != "" && staff.Email == "" {
-			updatedEmail = email
-			needsUpdate = true
-		}
-
-		if needsUpdate {
-			staff, err = s.repo.Update(ctx, staff.ID, updatedName, updatedEmail, staff.Mobile, staff.Address, staff.Theme, staff.BackgroundImage)
-			if err != nil {
-				return nil, false, err
-			}
-		}
+// SetRequireEmailVerification tells the service that unverified staff are
+// locked out, so admins can't clear their own verification
+func (s *StaffService) SetRequireEmailVerification(require bool) {
+	s.requireEmailVerification = require
+}
 
-		return staff, false, nil
+// SyncFromClaims fills in a staff member's name/email from Auth0 token claims
+// when the stored name is empty or flagged as a placeholder, or the email is
+// empty. Staff records are only ever created through invitation or
+// registration approval, never here.
+func (s *StaffService) SyncFromClaims(ctx context.Context, staff *model.Staff, name, email string) (*model.Staff, error) {
+	needsUpdate := false
+	updatedName := staff.Name
+	updatedEmail := staff.Email
+
+	// Update name only if it is empty or explicitly flagged as a placeholder;
+	// a real name that happens to equal the email is left alone
+	if name != "" && (staff.Name == "" || staff.NameIsPlaceholder) && name != staff.Name {
+		updatedName = name
+		needsUpdate = true
 	}
-	if !errors.Is(err, repository.ErrStaffNotFound) {
-		return nil, false, err
+
+	// Update email if it was empty
+	if email != "" && staff.Email == "" {
+		updatedEmail = email
+		needsUpdate = true
 	}
 
-	// Staff doesn't exist, create them with default 'staff' role
-	staff, err = s.repo.Create(ctx, auth0ID, name, email, nil, nil, nil)
-	if err != nil {
-		return nil, false, err
+	if !needsUpdate {
+		return staff, nil
 	}
-	return staff, true, nil
+
+	return s.repo.Update(ctx, staff.ID, updatedName, updatedEmail, staff.Mobile, staff.Address, staff.Theme, staff.BackgroundImage)
 }
 
 func (s *StaffService) GetByID(ctx context.Context, id uuid.UUID) (*model.Staff, error) {
@@ -84,15 +85,22 @@ func (s *StaffService) GetByAuth0ID(ctx context.Context, auth0ID string) (*model
 	return s.repo.GetByAuth0ID(ctx, auth0ID)
 }
 
-func (s *StaffService) Update(ctx context.Context, id uuid.UUID, name, email string, mobile, address *string, theme, backgroundImage string) (*model.Staff, error) {
+// Update updates a staff member's profile. A nil backgroundImage keeps the
+// existing value so clients that don't send it don't clear it.
+func (s *StaffService) Update(ctx context.Context, id uuid.UUID, name, email string, mobile, address *string, theme string, backgroundImage *string) (*model.Staff, error) {
 	// Check if email is changing
 	existing, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	bg := existing.BackgroundImage
+	if backgroundImage != nil {
+		bg = *backgroundImage
+	}
+
 	// Update the staff member
-	staff, err := s.repo.Update(ctx, id, name, email, mobile, address, theme, backgroundImage)
+	staff, err := s.repo.Update(ctx, id, name, email, mobile, address, theme, bg)
 	if err != nil {
 		return nil, err
 	}
@@ -110,12 +118,14 @@ func (s *StaffService) Update(ctx context.Context, id uuid.UUID, name, email str
 	return staff, nil
 }
 
+// List returns active staff; see ListInactive for deactivated staff
 func (s *StaffService) List(ctx context.Context) ([]model.Staff, error) {
 	return s.repo.List(ctx)
 }
 
-func (s *StaffService) ListAll(ctx context.Context) ([]model.Staff, error) {
-	return s.repo.ListAll(ctx)
+// ListInactive returns only deactivated staff
+func (s *StaffService) ListInactive(ctx context.Context) ([]model.Staff, error) {
+	return s.repo.ListInactive(ctx)
 }
 
 // InviteStaff creates a new staff member in Auth0 and local database,
@@ -126,13 +136,25 @@ func (s *StaffService) InviteStaff(ctx context.Context, req model.InviteStaffReq
 		return nil, "", ErrInvalidRole
 	}
 
+	// Reject duplicates before creating an Auth0 identity that would be orphaned
+	_, err := s.repo.GetByEmail(ctx, req.Email)
+	if err == nil {
+		return nil, "", ErrStaffEmailExists
+	}
+	if !errors.Is(err, repository.ErrStaffNotFound) {
+		return nil, "", fmt.Errorf("check existing staff: %w", err)
+	}
+
 	// Check if Auth0 client is configured
 	if s.auth0Client == nil || !s.auth0Client.IsConfigured() {
 		return nil, "", ErrAuth0NotConfigured
 	}
 
 	// Create user in Auth0
-	auth0User, err := s.auth0Client.CreateUser(req.Email, req.Name)
+	auth0User, err := s.auth0Client.CreateUser(ctx, req.Email, req.Name, map[string]interface{}{
+		"role":       req.Role,
+		"invited_by": invitedBy.String(),
+	})
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to create Auth0 user: %w", err)
 	}
@@ -140,12 +162,15 @@ func (s *StaffService) InviteStaff(ctx context.Context, req model.InviteStaffReq
 	// Create local staff record
 	staff, err := s.repo.CreateWithRole(ctx, auth0User.UserID, req.Name, req.Email, req.Role, req.Mobile, req.Address, &invitedBy)
 	if err != nil {
-		// TODO: Consider rolling back Auth0 user creation on failure
+		// Roll back the Auth0 user so the person can be re-invited
+		if delErr := s.auth0Client.DeleteUser(context.WithoutCancel(ctx), auth0User.UserID); delErr != nil {
+			log.Printf("ERROR: Failed to roll back Auth0 user %s after staff insert failure: %v", auth0User.UserID, delErr)
+		}
 		return nil, "", fmt.Errorf("failed to create staff record: %w", err)
 	}
 
 	// Send password set email (invitation)
-	ticketURL, err := s.auth0Client.SendPasswordSetEmail(auth0User.UserID)
+	ticketURL, err := s.auth0Client.SendPasswordSetEmail(ctx, auth0User.UserID)
 	if err != nil {
 		// User is created but invitation failed - they can request password reset
 		return staff, "", fmt.Errorf("staff created but failed to send invitation: %w", err)
@@ -180,17 +205,22 @@ func (s *StaffService) DeactivateStaff(ctx context.Context, id uuid.UUID, deacti
 
 	// Block in Auth0 if configured
 	if s.auth0Client != nil && s.auth0Client.IsConfigured() {
-		if err := s.auth0Client.BlockUser(staff.Auth0ID); err != nil {
+		if err := s.auth0Client.BlockUser(ctx, staff.Auth0ID); err != nil {
 			return fmt.Errorf("failed to block user in Auth0: %w", err)
 		}
 	}
 
 	// Mark as inactive locally
-	return s.repo.Deactivate(ctx, id, deactivatedBy)
+	if err := s.repo.Deactivate(ctx, id, deactivatedBy); err != nil {
+		return err
+	}
+
+	s.logAccessChange(ctx, staff, staff.Role, false, deactivatedBy)
+	return nil
 }
 
 // ReactivateStaff unblocks the user in Auth0 and marks them as active locally.
-func (s *StaffService) ReactivateStaff(ctx context.Context, id uuid.UUID) error {
+func (s *StaffService) ReactivateStaff(ctx context.Context, id, reactivatedBy uuid.UUID) error {
 	// Get the staff member to reactivate
 	staff, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -199,13 +229,29 @@ func (s *StaffService) ReactivateStaff(ctx context.Context, id uuid.UUID) error
 
 	// Unblock in Auth0 if configured
 	if s.auth0Client != nil && s.auth0Client.IsConfigured() {
-		if err := s.auth0Client.UnblockUser(staff.Auth0ID); err != nil {
+		if err := s.auth0Client.UnblockUser(ctx, staff.Auth0ID); err != nil {
 			return fmt.Errorf("failed to unblock user in Auth0: %w", err)
 		}
 	}
 
 	// Mark as active locally
-	return s.repo.Reactivate(ctx, id)
+	if err := s.repo.Reactivate(ctx, id); err != nil {
+		return err
+	}
+
+	s.logAccessChange(ctx, staff, staff.Role, true, reactivatedBy)
+	return nil
+}
+
+// logAccessChange records a change to a staff member's role or active status
+// as an UPDATE audit entry holding just those two fields
+func (s *StaffService) logAccessChange(ctx context.Context, before *model.Staff, role string, active bool, changedBy uuid.UUID) {
+	if s.auditRepo == nil {
+		return
+	}
+	oldValues := map[string]interface{}{"role": before.Role, "is_active": before.IsActive}
+	newValues := map[string]interface{}{"role": role, "is_active": active}
+	s.auditRepo.Log(ctx, "staff", before.ID, "UPDATE", oldValues, newValues, changedBy)
 }
 
 // UpdateRole changes a staff member's role.
@@ -237,7 +283,78 @@ func (s *StaffService) UpdateRole(ctx context.Context, id uuid.UUID, role string
 		}
 	}
 
-	return s.repo.UpdateRole(ctx, id, role)
+	updated, err := s.repo.UpdateRole(ctx, id, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if updated.Role != staff.Role {
+		s.logAccessChange(ctx, staff, updated.Role, updated.IsActive, updatedBy)
+	}
+	return updated, nil
+}
+
+// MarkEmailVerified verifies a staff member's email on an admin's say-so,
+// e.g. after confirming their identity in person. Already-verified staff are
+// returned unchanged.
+func (s *StaffService) MarkEmailVerified(ctx context.Context, id, verifiedBy uuid.UUID) (*model.Staff, error) {
+	staff, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if staff.EmailVerified {
+		return staff, nil
+	}
+
+	if err := s.repo.SetEmailVerified(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.logEmailVerification(ctx, staff, "EMAIL_VERIFIED", verifiedBy)
+}
+
+// ClearEmailVerification forces a staff member to verify their email again.
+// While verification is required an admin can't do this to themselves, as
+// they would lose access to the admin routes needed to undo it.
+func (s *StaffService) ClearEmailVerification(ctx context.Context, id, clearedBy uuid.UUID) (*model.Staff, error) {
+	if id == clearedBy && s.requireEmailVerification {
+		return nil, ErrCannotUnverifySelf
+	}
+
+	staff, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !staff.EmailVerified {
+		return staff, nil
+	}
+
+	if err := s.repo.ClearEmailVerified(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.logEmailVerification(ctx, staff, "EMAIL_UNVERIFIED", clearedBy)
+}
+
+// logEmailVerification reloads a staff member after an admin changed their
+// verification status and audits the change
+func (s *StaffService) logEmailVerification(ctx context.Context, before *model.Staff, action string, changedBy uuid.UUID) (*model.Staff, error) {
+	after, err := s.repo.GetByID(ctx, before.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.auditRepo != nil {
+		s.auditRepo.Log(ctx, "staff", before.ID, action,
+			map[string]interface{}{"email_verified": before.EmailVerified, "email_verified_at": before.EmailVerifiedAt},
+			map[string]interface{}{"email_verified": after.EmailVerified, "email_verified_at": after.EmailVerifiedAt},
+			changedBy)
+	}
+	return after, nil
+}
+
+// ListActivity returns the audit entries recorded against staffID's own
+// actions, newest first.
+func (s *StaffService) ListActivity(ctx context.Context, staffID uuid.UUID, limit, offset int) ([]model.AuditLog, int, error) {
+	return s.auditRepo.ListByActor(ctx, staffID, limit, offset)
 }
 
 // GetMFAStatus returns the MFA enrollment status for a user.
@@ -247,7 +364,7 @@ func (s *StaffService) GetMFAStatus(ctx context.Context, auth0ID string) (*model
 		return &model.MFAStatus{Enrolled: false, Factors: []string{}}, nil
 	}
 
-	enrollments, err := s.auth0Client.GetMFAEnrollments(auth0ID)
+	enrollments, err := s.auth0Client.GetMFAEnrollments(ctx, auth0ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get MFA enrollments: %w", err)
 	}
@@ -272,7 +389,7 @@ func (s *StaffService) EnrollMFA(ctx context.Context, auth0ID string) (string, e
 		return "", ErrAuth0NotConfigured
 	}
 
-	ticket, err := s.auth0Client.CreateMFAEnrollmentTicket(auth0ID)
+	ticket, err := s.auth0Client.CreateMFAEnrollmentTicket(ctx, auth0ID)
 	if err != nil {
 		return "", fmt.Errorf("failed to create MFA enrollment ticket: %w", err)
 	}
@@ -286,13 +403,13 @@ func (s *StaffService) DisableMFA(ctx context.Context, auth0ID string) error {
 		return ErrAuth0NotConfigured
 	}
 
-	enrollments, err := s.auth0Client.GetMFAEnrollments(auth0ID)
+	enrollments, err := s.auth0Client.GetMFAEnrollments(ctx, auth0ID)
 	if err != nil {
 		return fmt.Errorf("failed to get MFA enrollments: %w", err)
 	}
 
 	for _, e := range enrollments {
-		if err := s.auth0Client.DeleteMFAEnrollment(auth0ID, e.ID); err != nil {
+		if err := s.auth0Client.DeleteMFAEnrollment(ctx, auth0ID, e.ID); err != nil {
 			return fmt.Errorf("failed to delete MFA enrollment %s: %w", e.ID, err)
 		}
 	}
@@ -300,7 +417,123 @@ func (s *StaffService) DisableMFA(ctx context.Context, auth0ID string) error {
 	return nil
 }
 
-// Legacy method - kept for backward compatibility
+// GetOnboardingStatus reports whether a staff member has logged in, verified
+// their email and enrolled in MFA. Auth0 failures are logged and leave the
+// Auth0-derived fields unset rather than failing the request; if only the MFA
+// lookup fails, the login fields are still reported with MFA left unknown.
+func (s *StaffService) GetOnboardingStatus(ctx context.Context, id uuid.UUID) (*model.StaffOnboardingStatus, error) {
+	staff, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.StaffOnboardingStatus{
+		StaffID:         staff.ID,
+		EmailVerified:   staff.EmailVerified,
+		EmailVerifiedAt: staff.EmailVerifiedAt,
+	}
+
+	if s.auth0Client == nil || !s.auth0Client.IsConfigured() {
+		return status, nil
+	}
+
+	info, err := s.auth0Client.GetUserLoginInfo(ctx, staff.Auth0ID)
+	if err != nil {
+		log.Printf("Onboarding status: failed to get Auth0 login info for staff %s: %v", staff.ID, err)
+		return status, nil
+	}
+
+	loggedIn := info.LoginsCount > 0 || info.LastLogin != nil
+	status.Auth0Available = true
+	status.LoggedIn = &loggedIn
+	status.LastLoginAt = info.LastLogin
+
+	mfa, err := s.GetMFAStatus(ctx, staff.Auth0ID)
+	if err != nil {
+		log.Printf("Onboarding status: failed to get MFA status for staff %s: %v", staff.ID, err)
+		return status, nil
+	}
+	status.MFAEnrolled = &mfa.Enrolled
+	status.MFAFactors = mfa.Factors
+	status.Complete = loggedIn && staff.EmailVerified && mfa.Enrolled
+	return status, nil
+}
+
+// GetStaffMFAStatus returns the MFA enrollment status for another staff member.
+func (s *StaffService) GetStaffMFAStatus(ctx context.Context, id uuid.UUID) (*model.MFAStatus, error) {
+	// Unlike a user's own status, an admin shouldn't be told "not enrolled"
+	// when enrollment can't be checked at all
+	if s.auth0Client == nil || !s.auth0Client.IsConfigured() {
+		return nil, ErrAuth0NotConfigured
+	}
+	staff, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetMFAStatus(ctx, staff.Auth0ID)
+}
+
+// ResetStaffMFA removes all MFA enrollments for another staff member (e.g.
+// after a lost phone) and records the reset in the audit log.
+func (s *StaffService) ResetStaffMFA(ctx context.Context, id, resetBy uuid.UUID) error {
+	staff, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	before, err := s.GetMFAStatus(ctx, staff.Auth0ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.DisableMFA(ctx, staff.Auth0ID); err != nil {
+		return err
+	}
+
+	if s.auditRepo != nil {
+		after := &model.MFAStatus{Enrolled: false, Factors: []string{}}
+		s.auditRepo.Log(ctx, "staff", staff.ID, "MFA_RESET", before, after, resetBy)
+	}
+
+	return nil
+}
+
+// ResendInvite issues a fresh password-set ticket for an invited staff member
+// who has not yet verified their account, and returns the ticket URL.
+func (s *StaffService) ResendInvite(ctx context.Context, id, sentBy uuid.UUID) (string, error) {
+	if s.auth0Client == nil || !s.auth0Client.IsConfigured() {
+		return "", ErrAuth0NotConfigured
+	}
+
+	staff, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if !staff.IsActive {
+		return "", ErrStaffInactive
+	}
+	if staff.EmailVerified {
+		return "", ErrStaffAlreadyVerified
+	}
+
+	ticketURL, err := s.auth0Client.SendPasswordSetEmail(ctx, staff.Auth0ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to create password ticket: %w", err)
+	}
+
+	if s.auditRepo != nil {
+		s.auditRepo.Log(ctx, "staff", staff.ID, "INVITE_RESENT", nil, map[string]string{"email": staff.Email}, sentBy)
+	}
+
+	return ticketURL, nil
+}
+
+// Legacy method - kept for backward compatibility. Without a name the email
+// stands in for it, flagged so the first login replaces it from Auth0.
 func (s *StaffService) Create(ctx context.Context, auth0ID, name, email string, mobile, address *string, createdBy *uuid.UUID) (*model.Staff, error) {
-	return s.repo.Create(ctx, auth0ID, name, email, mobile, address, createdBy)
+	placeholder := name == ""
+	if placeholder {
+		name = email
+	}
+	return s.repo.Create(ctx, auth0ID, name, email, placeholder, mobile, address, createdBy)
 }
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+func newTestStaffService(t *testing.T) (*StaffService, *repository.StaffRepository) {
+	t.Helper()
+	db := testdb.Open(t)
+	repo := repository.NewStaffRepository(db)
+	return NewStaffService(repo, repository.NewAuditRepository(db), nil), repo
+}
+
+func TestSyncFromClaimsKeepsNameEqualToEmail(t *testing.T) {
+	s, repo := newTestStaffService(t)
+	ctx := context.Background()
+
+	// Invited with a name that happens to be their email address
+	staff, err := repo.CreateWithRole(ctx, "auth0|same", "sam@example.com", "sam@example.com", model.RoleStaff, nil, nil, &model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create staff: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		synced, err := s.SyncFromClaims(ctx, staff, "Samuel Claims", "sam@example.com")
+		if err != nil {
+			t.Fatalf("sync %d: %v", i, err)
+		}
+		if synced != staff {
+			t.Fatalf("sync %d rewrote the record: name %q", i, synced.Name)
+		}
+	}
+}
+
+func TestSyncFromClaimsReplacesPlaceholderName(t *testing.T) {
+	s, _ := newTestStaffService(t)
+	ctx := context.Background()
+
+	staff, err := s.Create(ctx, "auth0|legacy", "", "legacy@example.com", nil, nil, &model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create staff: %v", err)
+	}
+	if staff.Name != "legacy@example.com" || !staff.NameIsPlaceholder {
+		t.Fatalf("name %q placeholder %v, want the email flagged as a placeholder", staff.Name, staff.NameIsPlaceholder)
+	}
+
+	synced, err := s.SyncFromClaims(ctx, staff, "Lee Gacy", "legacy@example.com")
+	if err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	if synced.Name != "Lee Gacy" || synced.NameIsPlaceholder {
+		t.Errorf("name %q placeholder %v, want the claim name and the flag cleared", synced.Name, synced.NameIsPlaceholder)
+	}
+
+	// Once replaced, later logins leave the name alone
+	again, err := s.SyncFromClaims(ctx, synced, "Someone Else", "legacy@example.com")
+	if err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+	if again != synced {
+		t.Errorf("second sync rewrote the name to %q", again.Name)
+	}
+}
+
+// access is the role and active status logAccessChange records
+func access(role string, active bool) map[string]any {
+	return map[string]any{"role": role, "is_active": active}
+}
+
+func TestAccessChangesAreAudited(t *testing.T) {
+	s, repo := newTestStaffService(t)
+	ctx := context.Background()
+
+	admin, err := repo.CreateWithRole(ctx, "auth0|admin", "Ada Admin", "ada@example.com", model.RoleAdmin, nil, nil, &model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create admin: %v", err)
+	}
+	target, err := repo.CreateWithRole(ctx, "auth0|target", "Tom Target", "tom@example.com", model.RoleStaff, nil, nil, &admin.ID)
+	if err != nil {
+		t.Fatalf("create staff: %v", err)
+	}
+
+	steps := []struct {
+		name     string
+		change   func() error
+		old, new map[string]any
+	}{
+		{"promote", func() error { _, err := s.UpdateRole(ctx, target.ID, model.RoleAdmin, admin.ID); return err },
+			access(model.RoleStaff, true), access(model.RoleAdmin, true)},
+		{"deactivate", func() error { return s.DeactivateStaff(ctx, target.ID, admin.ID) },
+			access(model.RoleAdmin, true), access(model.RoleAdmin, false)},
+		{"reactivate", func() error { return s.ReactivateStaff(ctx, target.ID, admin.ID) },
+			access(model.RoleAdmin, false), access(model.RoleAdmin, true)},
+	}
+
+	for _, step := range steps {
+		if err := step.change(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		entries, err := s.auditRepo.GetRecentByRecordID(ctx, "staff", target.ID, 1)
+		if err != nil {
+			t.Fatalf("%s: audit lookup: %v", step.name, err)
+		}
+		if len(entries) != 1 {
+			t.Fatalf("%s: no audit entry", step.name)
+		}
+		e := entries[0]
+		var oldValues, newValues map[string]any
+		if err := json.Unmarshal(e.OldValues, &oldValues); err != nil {
+			t.Fatalf("%s: old values: %v", step.name, err)
+		}
+		if err := json.Unmarshal(e.NewValues, &newValues); err != nil {
+			t.Fatalf("%s: new values: %v", step.name, err)
+		}
+		if e.Action != "UPDATE" || e.ChangedBy != admin.ID ||
+			!reflect.DeepEqual(oldValues, step.old) || !reflect.DeepEqual(newValues, step.new) {
+			t.Errorf("%s: entry %s by %v, %v -> %v; want UPDATE by the admin, %v -> %v",
+				step.name, e.Action, e.ChangedBy, oldValues, newValues, step.old, step.new)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+)
+
+// statsCacheTTL is how long dashboard stats are reused before recomputing,
+// so a dashboard polling every few seconds costs a handful of queries a minute
+const statsCacheTTL = 30 * time.Second
+
+// StatsService computes the admin dashboard counts from the existing repositories
+type StatsService struct {
+	clientRepo              *repository.ClientRepository
+	staffRepo               *repository.StaffRepository
+	registrationRequestRepo *repository.RegistrationRequestRepository
+
+	mu     sync.Mutex
+	cached *model.DashboardStats
+}
+
+func NewStatsService(clientRepo *repository.ClientRepository, staffRepo *repository.StaffRepository, registrationRequestRepo *repository.RegistrationRequestRepository) *StatsService {
+	return &StatsService{
+		clientRepo:              clientRepo,
+		staffRepo:               staffRepo,
+		registrationRequestRepo: registrationRequestRepo,
+	}
+}
+
+// Dashboard returns the dashboard counts, served from cache when they are
+// younger than statsCacheTTL. GeneratedAt says when they were computed.
+// Callers arriving while the stats are being refreshed wait for that result
+// rather than each querying the database.
+func (s *StatsService) Dashboard(ctx context.Context) (*model.DashboardStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached != nil && time.Since(s.cached.GeneratedAt) < statsCacheTTL {
+		return s.cached, nil
+	}
+
+	now := time.Now()
+	stats := &model.DashboardStats{GeneratedAt: now}
+
+	var err error
+	if stats.ClientsTotal, stats.ClientsActive, err = s.clientRepo.CountTotals(ctx); err != nil {
+		return nil, err
+	}
+	if stats.StaffActive, err = s.staffRepo.CountActive(ctx); err != nil {
+		return nil, err
+	}
+	if stats.StaffAdmins, err = s.staffRepo.CountAdmins(ctx); err != nil {
+		return nil, err
+	}
+	if stats.PendingRequests, err = s.registrationRequestRepo.CountPending(ctx); err != nil {
+		return nil, err
+	}
+	if stats.AttendanceLast7Days, err = s.clientRepo.CountAttendanceSince(ctx, now.AddDate(0, 0, -7)); err != nil {
+		return nil, err
+	}
+
+	s.cached = stats
+	return stats, nil
+}
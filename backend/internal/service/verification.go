@@ -31,6 +31,36 @@ var (
 	ErrEmailNotConfigured = errors.New("email service not configured")
 )
 
+// InvalidCodeError is returned by VerifyCode for a wrong code and says how
+// many more tries the current code allows. It matches ErrInvalidCode with
+// errors.Is.
+type InvalidCodeError struct {
+	RemainingAttempts int
+}
+
+func (e *InvalidCodeError) Error() string {
+	return fmt.Sprintf("%s: %d attempts remaining", ErrInvalidCode, e.RemainingAttempts)
+}
+
+func (e *InvalidCodeError) Unwrap() error {
+	return ErrInvalidCode
+}
+
+// RateLimitedError is returned by SendCode when too many codes were sent in
+// the last hour. RetryAfter is how long until another may be sent. It
+// matches ErrRateLimited with errors.Is.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("%s (retry after %s)", ErrRateLimited, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitedError) Unwrap() error {
+	return ErrRateLimited
+}
+
 type VerificationService struct {
 	repo         *repository.VerificationRepository
 	staffRepo    *repository.StaffRepository
@@ -84,7 +114,17 @@ func (s *VerificationService) SendCode(ctx context.Context, staffID uuid.UUID) e
 		return fmt.Errorf("count recent codes: %w", err)
 	}
 	if count >= maxCodesPerHour {
-		return ErrRateLimited
+		// Another code may be sent once the oldest code counted against
+		// the limit falls out of the window
+		createdAt, err := s.repo.NthLatestCodeCreatedAt(ctx, staffID, maxCodesPerHour)
+		if err != nil {
+			return fmt.Errorf("find oldest recent code: %w", err)
+		}
+		retryAfter := time.Until(createdAt.Add(time.Hour))
+		if retryAfter < 0 {
+			retryAfter = 0
+		}
+		return &RateLimitedError{RetryAfter: retryAfter}
 	}
 
 	// Invalidate any previous active codes
@@ -151,7 +191,7 @@ func (s *VerificationService) VerifyCode(ctx context.Context, staffID uuid.UUID,
 
 	// Check if code matches
 	if vc.Code != code {
-		return ErrInvalidCode
+		return &InvalidCodeError{RemainingAttempts: maxAttempts - (vc.Attempts + 1)}
 	}
 
 	// Mark the code as verified
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/finchley-foodbank/foodbank/internal/email"
+	"github.com/finchley-foodbank/foodbank/internal/model"
+	"github.com/finchley-foodbank/foodbank/internal/repository"
+	"github.com/finchley-foodbank/foodbank/internal/testdb"
+)
+
+func newTestVerificationService(t *testing.T) (*VerificationService, *model.Staff) {
+	t.Helper()
+	db := testdb.Open(t)
+	staffRepo := repository.NewStaffRepository(db)
+	staff, err := staffRepo.CreateWithRole(context.Background(), "auth0|unverified", "Una Verified", "una@example.com", model.RoleStaff, nil, nil, &model.SystemStaffID)
+	if err != nil {
+		t.Fatalf("create staff: %v", err)
+	}
+	// Configured so SendCode gets as far as the rate limit; the tests never
+	// reach the point of sending
+	emailService := email.NewService("key", "from@example.com", "Foodbank", "https://example.com")
+	return NewVerificationService(repository.NewVerificationRepository(db), staffRepo, emailService), staff
+}
+
+func TestVerifyCodeCountsDownAttempts(t *testing.T) {
+	s, staff := newTestVerificationService(t)
+	ctx := context.Background()
+
+	if _, err := s.repo.Create(ctx, staff.ID, "123456", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("create code: %v", err)
+	}
+
+	for want := maxAttempts - 1; want >= 0; want-- {
+		err := s.VerifyCode(ctx, staff.ID, "000000")
+		var invalid *InvalidCodeError
+		if !errors.As(err, &invalid) {
+			t.Fatalf("err = %v, want an InvalidCodeError", err)
+		}
+		if invalid.RemainingAttempts != want {
+			t.Errorf("remaining attempts = %d, want %d", invalid.RemainingAttempts, want)
+		}
+		if !errors.Is(err, ErrInvalidCode) {
+			t.Error("InvalidCodeError does not match ErrInvalidCode")
+		}
+	}
+
+	// Out of attempts, even the right code is refused
+	if err := s.VerifyCode(ctx, staff.ID, "123456"); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("err = %v, want ErrTooManyAttempts", err)
+	}
+}
+
+func TestSendCodeReportsRetryAfter(t *testing.T) {
+	s, staff := newTestVerificationService(t)
+	ctx := context.Background()
+
+	for i := 0; i < maxCodesPerHour; i++ {
+		if _, err := s.repo.Create(ctx, staff.ID, "123456", time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("create code: %v", err)
+		}
+	}
+
+	err := s.SendCode(ctx, staff.ID)
+	var limited *RateLimitedError
+	if !errors.As(err, &limited) {
+		t.Fatalf("err = %v, want a RateLimitedError", err)
+	}
+	// The oldest code was created moments ago, so the window clears in
+	// just under an hour
+	if limited.RetryAfter <= 59*time.Minute || limited.RetryAfter > time.Hour {
+		t.Errorf("retry after %s, want just under an hour", limited.RetryAfter)
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Error("RateLimitedError does not match ErrRateLimited")
+	}
+}
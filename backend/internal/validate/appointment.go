@@ -0,0 +1,52 @@
+// Package validate holds field checks shared by the client API and the CSV importer.
+package validate
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidAppointmentDay  = errors.New("invalid appointment day")
+	ErrInvalidAppointmentTime = errors.New("invalid appointment time")
+)
+
+var appointmentDays = map[string]string{
+	"monday":    "Monday",
+	"tuesday":   "Tuesday",
+	"wednesday": "Wednesday",
+	"thursday":  "Thursday",
+	"friday":    "Friday",
+	"saturday":  "Saturday",
+}
+
+// timeRegex matches HH:MM, optionally with seconds as returned for TIME columns
+var timeRegex = regexp.MustCompile(`^([01]?[0-9]|2[0-3]):([0-5][0-9])(:[0-5][0-9])?$`)
+
+// AppointmentDay checks that day is Monday-Saturday (any case) and returns it
+// capitalized, e.g. "monday" -> "Monday". An empty day is returned as "".
+func AppointmentDay(day string) (string, error) {
+	d := strings.ToLower(strings.TrimSpace(day))
+	if d == "" {
+		return "", nil
+	}
+	normalized, ok := appointmentDays[d]
+	if !ok {
+		return "", ErrInvalidAppointmentDay
+	}
+	return normalized, nil
+}
+
+// AppointmentTime checks that t is a 24-hour HH:MM time. An empty time is
+// returned as "".
+func AppointmentTime(t string) (string, error) {
+	t = strings.TrimSpace(t)
+	if t == "" {
+		return "", nil
+	}
+	if !timeRegex.MatchString(t) {
+		return "", ErrInvalidAppointmentTime
+	}
+	return t, nil
+}
@@ -0,0 +1,50 @@
+package validate
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidChildrenAges = errors.New("invalid children ages")
+
+// MaxChildAge is the oldest age accepted in a children ages list
+const MaxChildAge = 25
+
+// ChildrenAges checks that s is a comma-separated list of whole-number ages
+// from 0 to MaxChildAge and returns it in canonical form, e.g. "5,8" -> "5, 8".
+// An empty list is returned as "".
+func ChildrenAges(s string) (string, error) {
+	ages, err := ParseChildrenAges(s)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, len(ages))
+	for i, age := range ages {
+		parts[i] = strconv.Itoa(age)
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// ParseChildrenAges returns the ages in a children ages list, in the order given
+func ParseChildrenAges(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	fields := strings.Split(s, ",")
+	ages := make([]int, 0, len(fields))
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		// Atoi would accept a sign, which isn't a valid way to write an age
+		if f == "" || strings.ContainsAny(f, "+-") {
+			return nil, ErrInvalidChildrenAges
+		}
+		age, err := strconv.Atoi(f)
+		if err != nil || age > MaxChildAge {
+			return nil, ErrInvalidChildrenAges
+		}
+		ages = append(ages, age)
+	}
+	return ages, nil
+}
@@ -0,0 +1,42 @@
+package validate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChildrenAges(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "5, 8", want: "5, 8"},
+		{in: "5,8", want: "5, 8"},
+		{in: " 0 ,  12 ,3 ", want: "0, 12, 3"},
+		{in: "", want: ""},
+		{in: "abc", wantErr: true},
+		{in: "5, abc", wantErr: true},
+		{in: "5,,8", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "+4", wantErr: true},
+		{in: "26", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ChildrenAges(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidChildrenAges) {
+				t.Errorf("ChildrenAges(%q) error = %v, want ErrInvalidChildrenAges", tt.in, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ChildrenAges(%q) unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ChildrenAges(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package validate
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+var ErrInvalidEmail = errors.New("invalid email")
+
+// Email checks that s is a bare address (no display name) with a plausible
+// domain: at least two non-empty labels that do not start or end with a
+// hyphen. Plus-addressing and internationalized domains are accepted.
+func Email(s string) error {
+	s = strings.TrimSpace(s)
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return ErrInvalidEmail
+	}
+
+	at := strings.LastIndex(s, "@")
+	if at <= 0 {
+		return ErrInvalidEmail
+	}
+	labels := strings.Split(s[at+1:], ".")
+	if len(labels) < 2 {
+		return ErrInvalidEmail
+	}
+	for _, label := range labels {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return ErrInvalidEmail
+		}
+	}
+	return nil
+}
@@ -0,0 +1 @@
+ALTER TABLE clients ADD COLUMN dietary_notes TEXT;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_clients_archived_at;
+ALTER TABLE clients DROP COLUMN archived_by;
+ALTER TABLE clients DROP COLUMN archived_at;
@@ -0,0 +1,4 @@
+ALTER TABLE clients ADD COLUMN archived_at TIMESTAMPTZ;
+ALTER TABLE clients ADD COLUMN archived_by UUID REFERENCES staff(id);
+
+CREATE INDEX idx_clients_archived_at ON clients(archived_at);
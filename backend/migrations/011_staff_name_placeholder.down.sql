@@ -0,0 +1 @@
+ALTER TABLE staff DROP COLUMN name_is_placeholder;
@@ -0,0 +1,5 @@
+-- Explicitly mark names that were filled in as placeholders (e.g. copied from
+-- the email) so they can be replaced from Auth0 claims without guessing.
+-- Existing names are kept as real: a name equal to the email may be genuine,
+-- and empty names are replaced from claims regardless of the flag.
+ALTER TABLE staff ADD COLUMN name_is_placeholder BOOLEAN NOT NULL DEFAULT false;
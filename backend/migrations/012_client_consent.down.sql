@@ -0,0 +1,3 @@
+ALTER TABLE clients DROP COLUMN consent_recorded_at;
+ALTER TABLE clients DROP COLUMN consent_photo;
+ALTER TABLE clients DROP COLUMN consent_data_storage;
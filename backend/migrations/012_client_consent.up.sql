@@ -0,0 +1,3 @@
+ALTER TABLE clients ADD COLUMN consent_data_storage BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE clients ADD COLUMN consent_photo BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE clients ADD COLUMN consent_recorded_at TIMESTAMPTZ;
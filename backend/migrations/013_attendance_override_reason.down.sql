@@ -0,0 +1 @@
+ALTER TABLE attendance DROP COLUMN override_reason;
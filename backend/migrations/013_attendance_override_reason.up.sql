@@ -0,0 +1 @@
+ALTER TABLE attendance ADD COLUMN override_reason TEXT;
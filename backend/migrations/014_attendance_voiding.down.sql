@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_attendance_voided_at;
+ALTER TABLE attendance DROP COLUMN voided_by;
+ALTER TABLE attendance DROP COLUMN voided_at;
@@ -0,0 +1,4 @@
+ALTER TABLE attendance ADD COLUMN voided_at TIMESTAMPTZ;
+ALTER TABLE attendance ADD COLUMN voided_by UUID REFERENCES staff(id);
+
+CREATE INDEX idx_attendance_voided_at ON attendance(voided_at);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS email_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    recipient VARCHAR(255) NOT NULL,
+    message_type VARCHAR(50) NOT NULL,
+    message_id VARCHAR(255),
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    attempts INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_log_created_at ON email_log(created_at DESC);
+
+ALTER TABLE email_log
+    ADD CONSTRAINT chk_email_log_status CHECK (status IN ('sent', 'failed'));
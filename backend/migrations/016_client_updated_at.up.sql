@@ -0,0 +1,3 @@
+ALTER TABLE clients ADD COLUMN updated_at TIMESTAMPTZ DEFAULT NOW();
+UPDATE clients SET updated_at = COALESCE(created_at, NOW());
+ALTER TABLE clients ALTER COLUMN updated_at SET NOT NULL;
@@ -0,0 +1,4 @@
+-- Must match clientSearchVector in internal/repository/client.go
+CREATE INDEX idx_clients_search ON clients USING GIN (
+    (setweight(to_tsvector('simple', name), 'A') || setweight(to_tsvector('simple', address), 'B'))
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS client_appointments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    client_id UUID REFERENCES clients(id) ON DELETE CASCADE NOT NULL,
+    day VARCHAR(20) NOT NULL,
+    time TIME,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_client_appointments_client_id ON client_appointments(client_id);
+
+-- At most one primary slot per client; it is mirrored in clients.appointment_day/time
+CREATE UNIQUE INDEX idx_client_appointments_primary ON client_appointments(client_id) WHERE is_primary;
+
+-- Existing single appointments become each client's primary slot
+INSERT INTO client_appointments (client_id, day, time, is_primary)
+SELECT id, appointment_day, appointment_time, TRUE
+FROM clients
+WHERE appointment_day IS NOT NULL AND appointment_day <> '';
@@ -0,0 +1,14 @@
+-- Audit entries attributed to the system actor can't be truthfully given to
+-- anyone else, and deleting them would erase history, so refuse to roll back
+-- once any exist
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM audit_log WHERE changed_by = '00000000-0000-0000-0000-000000000001') THEN
+        RAISE EXCEPTION 'audit_log has entries by the system actor; remove the system staff row by hand if that history can be discarded';
+    END IF;
+END $$;
+
+UPDATE registration_requests SET reviewed_by = NULL WHERE reviewed_by = '00000000-0000-0000-0000-000000000001';
+UPDATE staff SET created_by = NULL WHERE created_by = '00000000-0000-0000-0000-000000000001';
+UPDATE staff SET deactivated_by = NULL WHERE deactivated_by = '00000000-0000-0000-0000-000000000001';
+DELETE FROM staff WHERE id = '00000000-0000-0000-0000-000000000001';
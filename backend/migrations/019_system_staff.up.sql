@@ -0,0 +1,12 @@
+-- Seed the system actor used to attribute changes made without a signed-in
+-- staff member (recovery-token requests, token-based registration review).
+-- It is inactive and its auth0_id can never match a real Auth0 subject, so
+-- nobody can sign in as it. Keep the id in sync with model.SystemStaffID.
+INSERT INTO staff (id, auth0_id, name, email, role, is_active)
+VALUES ('00000000-0000-0000-0000-000000000001', 'system', 'System', 'system@foodbank.invalid', 'staff', false)
+ON CONFLICT (id) DO NOTHING;
+
+-- Token-based reviews made before the system actor existed
+UPDATE registration_requests
+SET reviewed_by = '00000000-0000-0000-0000-000000000001'
+WHERE status <> 'pending' AND reviewed_by IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_clients_dietary_notes_trgm;
+DROP INDEX IF EXISTS idx_clients_barcode_trgm;
+DROP INDEX IF EXISTS idx_clients_address_trgm;
+DROP INDEX IF EXISTS idx_clients_name_trgm;
+-- pg_trgm is left installed; other objects may depend on it
@@ -0,0 +1,10 @@
+-- Trigram indexes let the substring (ILIKE '%q%') arms of a client search use
+-- an index, so the planner can OR them with idx_clients_search instead of
+-- scanning every row. Keep in sync with searchWhere in
+-- internal/repository/client.go.
+CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public;
+
+CREATE INDEX idx_clients_name_trgm ON clients USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_clients_address_trgm ON clients USING GIN (address gin_trgm_ops);
+CREATE INDEX idx_clients_barcode_trgm ON clients USING GIN (barcode_id gin_trgm_ops);
+CREATE INDEX idx_clients_dietary_notes_trgm ON clients USING GIN (dietary_notes gin_trgm_ops);
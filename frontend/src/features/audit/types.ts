@@ -16,4 +16,7 @@ export interface AuditLogListResponse {
   total: number
   limit: number
   offset: number
+  has_next: boolean
+  has_prev: boolean
+  total_pages: number
 }
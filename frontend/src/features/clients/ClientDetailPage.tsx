@@ -216,6 +216,13 @@ export default function ClientDetailPage() {
               </div>
             )}
 
+            {client.dietary_notes && (
+              <div className="mt-6">
+                <h3 className="text-xs uppercase text-base-content/50 mb-1">Other Dietary Requirements</h3>
+                <p className="font-medium whitespace-pre-line">{client.dietary_notes}</p>
+              </div>
+            )}
+
             <div className="divider"></div>
 
             <div className="text-sm text-base-content/50">
@@ -38,6 +38,9 @@ export default function ClientEditPage() {
         pref_halal: clientData.pref_halal,
         pref_vegetarian: clientData.pref_vegetarian,
         pref_no_cooking: clientData.pref_no_cooking,
+        dietary_notes: clientData.dietary_notes || '',
+        consent_data_storage: clientData.consent_data_storage,
+        consent_photo: clientData.consent_photo,
       })
     } catch (err) {
       console.error('Failed to load client:', err)
@@ -67,7 +70,8 @@ export default function ClientEditPage() {
     try {
       await fetchWithAuth(`/api/clients/${client.id}`, {
         method: 'PUT',
-        body: JSON.stringify(form),
+        // Lets the server reject the save if someone else edited the client meanwhile
+        body: JSON.stringify({ ...form, expected_updated_at: client.updated_at }),
       })
       toast.success('Client updated successfully')
       navigate(`/clients/${client.id}`)
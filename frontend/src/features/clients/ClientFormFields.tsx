@@ -21,6 +21,9 @@ export const initialFormState: CreateClientRequest = {
   pref_halal: false,
   pref_vegetarian: false,
   pref_no_cooking: false,
+  dietary_notes: '',
+  consent_data_storage: false,
+  consent_photo: false,
 }
 
 export default function ClientFormFields({ form, updateField, isSubmitting }: ClientFormFieldsProps) {
@@ -195,6 +198,47 @@ export default function ClientFormFields({ form, updateField, isSubmitting }: Cl
           <span className="label-text">No Cooking</span>
         </label>
       </div>
+
+      <div className="form-control">
+        <label className="label">
+          <span className="label-text">Other Dietary Requirements</span>
+        </label>
+        <textarea
+          className="textarea textarea-bordered"
+          rows={2}
+          maxLength={500}
+          placeholder="e.g. nut allergy"
+          value={form.dietary_notes}
+          onChange={(e) => updateField('dietary_notes', e.target.value)}
+          disabled={isSubmitting}
+        />
+      </div>
+
+      <div className="divider">Consent</div>
+
+      <div className="grid grid-cols-1 md:grid-cols-2 gap-2">
+        <label className="label cursor-pointer justify-start gap-2">
+          <input
+            type="checkbox"
+            className="checkbox checkbox-primary"
+            checked={form.consent_data_storage}
+            onChange={(e) => updateField('consent_data_storage', e.target.checked)}
+            disabled={isSubmitting}
+          />
+          <span className="label-text">Consents to data storage</span>
+        </label>
+
+        <label className="label cursor-pointer justify-start gap-2">
+          <input
+            type="checkbox"
+            className="checkbox checkbox-primary"
+            checked={form.consent_photo}
+            onChange={(e) => updateField('consent_photo', e.target.checked)}
+            disabled={isSubmitting}
+          />
+          <span className="label-text">Consents to photo</span>
+        </label>
+      </div>
     </div>
   )
 }
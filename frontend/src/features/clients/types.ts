@@ -14,8 +14,15 @@ export interface Client {
   pref_halal: boolean
   pref_vegetarian: boolean
   pref_no_cooking: boolean
+  dietary_notes?: string
+  consent_data_storage: boolean
+  consent_photo: boolean
+  consent_recorded_at?: string
+  visit_count?: number
   created_at: string
   created_by: string
+  created_by_name?: string
+  updated_at: string
 }
 
 export interface CreateClientRequest {
@@ -32,6 +39,9 @@ export interface CreateClientRequest {
   pref_halal: boolean
   pref_vegetarian: boolean
   pref_no_cooking: boolean
+  dietary_notes?: string
+  consent_data_storage: boolean
+  consent_photo: boolean
 }
 
 export interface ClientListResponse {
@@ -39,6 +49,9 @@ export interface ClientListResponse {
   total: number
   limit: number
   offset: number
+  has_next: boolean
+  has_prev: boolean
+  total_pages: number
 }
 
 export interface Attendance {
@@ -49,3 +62,18 @@ export interface Attendance {
   client_name?: string
   verified_by_name?: string
 }
+
+export interface ClientAppointment {
+  id: string
+  client_id: string
+  day: string
+  time?: string
+  is_primary: boolean
+  created_at: string
+}
+
+export interface CreateAppointmentRequest {
+  day: string
+  time?: string
+  primary?: boolean
+}
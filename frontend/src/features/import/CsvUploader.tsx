@@ -8,7 +8,8 @@ const API_URL = import.meta.env.VITE_API_URL || ''
 const REQUIRED_COLUMNS = ['name', 'address', 'family_size']
 const OPTIONAL_COLUMNS = [
   'num_children', 'children_ages', 'reason', 'appointment_day', 'appointment_time',
-  'pref_gluten_free', 'pref_halal', 'pref_vegetarian', 'pref_no_cooking'
+  'pref_gluten_free', 'pref_halal', 'pref_vegetarian', 'pref_no_cooking', 'dietary_notes',
+  'consent_data_storage', 'consent_photo'
 ]
 const ALL_COLUMNS = [...REQUIRED_COLUMNS, ...OPTIONAL_COLUMNS]
 
@@ -24,7 +25,8 @@ export function CsvUploader({ onParsed }: CsvUploaderProps) {
 
   const parseBoolean = (value: string): boolean => {
     const v = value?.toLowerCase()?.trim()
-    return v === 'true' || v === '1' || v === 'yes'
+    // Keep in sync with parseImportBool in the backend
+    return ['true', 't', 'yes', 'y', '1', 'x', 'on'].includes(v)
   }
 
   const convertToImportRow = (row: ParsedCsvRow, index: number): ImportClientRow => {
@@ -42,6 +44,9 @@ export function CsvUploader({ onParsed }: CsvUploaderProps) {
       pref_halal: parseBoolean(row.pref_halal),
       pref_vegetarian: parseBoolean(row.pref_vegetarian),
       pref_no_cooking: parseBoolean(row.pref_no_cooking),
+      dietary_notes: row.dietary_notes?.trim() || undefined,
+      consent_data_storage: parseBoolean(row.consent_data_storage),
+      consent_photo: parseBoolean(row.consent_photo),
     }
   }
 
@@ -13,6 +13,9 @@ export interface ImportClientRow {
   pref_halal: boolean
   pref_vegetarian: boolean
   pref_no_cooking: boolean
+  dietary_notes?: string
+  consent_data_storage: boolean
+  consent_photo: boolean
 }
 
 // Validation error for a specific field
@@ -50,6 +53,7 @@ export interface ImportRequest {
   clients: ImportClientRow[]
   skip_duplicates: boolean
   batch_size: number
+  dry_run?: boolean
 }
 
 // Successfully imported client
@@ -74,6 +78,7 @@ export interface BatchResult {
 // Complete import result
 export interface ImportResult {
   success: boolean
+  dry_run: boolean
   total: number
   imported: number
   skipped: number
@@ -99,4 +104,7 @@ export interface ParsedCsvRow {
   pref_halal: string
   pref_vegetarian: string
   pref_no_cooking: string
+  dietary_notes: string
+  consent_data_storage: string
+  consent_photo: string
 }
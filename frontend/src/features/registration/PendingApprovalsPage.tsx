@@ -25,7 +25,7 @@ export default function PendingApprovalsPage() {
 
   const fetchRequests = useCallback(async () => {
     try {
-      const data = await fetchWithAuth('/api/registration-requests')
+      const data = await fetchWithAuth('/api/registration-requests?status=pending&limit=200')
       setRequests(data || [])
     } catch (err) {
       console.error('Failed to fetch requests:', err)
@@ -189,7 +189,7 @@ export default function TokenActionPage() {
           </div>
           <h1 className="text-3xl font-bold mb-4">Link Expired</h1>
           <p className="text-base-content/70 mb-6">
-            This approval link has expired. Registration requests expire after 7 days.
+            This approval link has expired.
             You can still manage pending requests from the admin dashboard.
           </p>
           <Link to="/" className="btn btn-primary">
@@ -47,9 +47,12 @@ export default function StaffPage() {
     setIsLoading(true)
     setError(null)
     try {
-      const url = isAdmin && showAll ? '/api/staff?all=true' : '/api/staff'
-      const data = await fetchWithAuth(url)
-      setStaff(data || [])
+      const active: Staff[] = (await fetchWithAuth('/api/staff')) || []
+      // Deactivated staff come from their own admin-only endpoint
+      const inactive: Staff[] = isAdmin && showAll
+        ? (await fetchWithAuth('/api/staff/deactivated')) || []
+        : []
+      setStaff([...active, ...inactive])
     } catch (err) {
       console.error('Failed to load staff:', err)
       setError('Failed to load staff members')